@@ -1,32 +1,150 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
 func main() {
-	// 通过Wire初始化整个应用
-	app, err := InitializeApp()
+	// 通过Wire初始化整个应用，cleanup 按依赖的相反顺序关闭数据库和 Redis 连接池
+	app, cleanup, err := InitializeApp()
 	if err != nil {
 		log.Fatalf("Failed to initialize app: %v", err)
 	}
+	os.Exit(run(app, cleanup))
+}
+
+// run 启动服务并阻塞到收到退出信号，返回进程的退出码
+func run(app *App, cleanup func()) int {
+	logger := app.Logger
+	// 等待后台任务超时时，它们可能仍在使用数据库和 Redis，不关闭连接池，由进程退出释放
+	closePools := true
+	defer func() {
+		if closePools {
+			cleanup()
+		}
+	}()
+
+	handler, err := app.Router.SetupRouter()
+	if err != nil {
+		logger.Error("Failed to set up router", "err", err)
+		return 1
+	}
 
 	// 启动定时任务
 	c := cron.New(cron.WithSeconds())
-	_, err = c.AddFunc("0 */2 * * * *", app.SyncTask.SyncViewCountsToDB)
-	if err != nil {
-		log.Fatalf("Failed to add cron job: %v", err)
+	jobs := []struct {
+		spec string
+		fn   func()
+	}{
+		{"0 */2 * * * *", app.SyncTask.SyncViewCountsToDB},
+		{"30 * * * * *", app.PublishTask.PublishScheduledPosts},
+		{"0 15 * * * *", app.CleanupTask.CleanOrphanAttachments},
+		{"15 */5 * * * *", app.RankTask.RefreshRanks},
+		{"*/10 * * * * *", app.MailTask.RequeueRetries},
+	}
+	for _, job := range jobs {
+		if _, err := c.AddFunc(job.spec, job.fn); err != nil {
+			logger.Error("Failed to add cron job", "spec", job.spec, "err", err)
+			return 1
+		}
 	}
 	c.Start()
-	defer c.Stop()
+
+	// 启动邮件发送队列
+	mailCtx, stopMail := context.WithCancel(context.Background())
+	mailDone := make(chan struct{})
+	go func() {
+		defer close(mailDone)
+		app.MailTask.Run(mailCtx)
+	}()
 
 	// 启动Web服务
-	router := app.Router.SetupRouter()
-	log.Printf("Server starting on :%d", app.Config.Server.Port)
-	if err := router.Run(fmt.Sprintf(":%d", app.Config.Server.Port)); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	cfg := app.Config.Server
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           handler,
+		ReadTimeout:       time.Duration(cfg.ReadTimeoutSeconds) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeoutSeconds) * time.Second,
+		WriteTimeout:      time.Duration(cfg.WriteTimeoutSeconds) * time.Second,
+		IdleTimeout:       time.Duration(cfg.IdleTimeoutSeconds) * time.Second,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("Server starting", "port", cfg.Port)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	signalCtx, stopSignal := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignal()
+
+	code := 0
+	select {
+	case <-signalCtx.Done():
+		logger.Info("收到退出信号，开始关闭服务")
+	case err := <-serveErr:
+		logger.Error("Failed to start server", "err", err)
+		code = 1
 	}
+	// 恢复默认的信号处理，关闭过程中再次收到信号时直接退出
+	stopSignal()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	// 1. 不再接受新请求，等待处理中的请求结束
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("等待请求结束超时", "err", err)
+		code = 1
+	}
+
+	// 2. 停止定时任务，等待正在执行的任务结束
+	cronStopped := true
+	select {
+	case <-c.Stop().Done():
+	case <-ctx.Done():
+		logger.Error("等待定时任务结束超时")
+		cronStopped = false
+		code = 1
+	}
+
+	// 3. 停止从发送队列取邮件，正在发送的邮件会发完
+	stopMail()
+	select {
+	case <-mailDone:
+	case <-ctx.Done():
+		logger.Error("等待邮件发送结束超时")
+		closePools = false
+		code = 1
+	}
+
+	// 4. 等待请求和定时任务中启动的通知、推送等异步任务
+	if !app.Background.Wait(ctx) {
+		logger.Error("等待异步任务结束超时")
+		closePools = false
+		code = 1
+	}
+
+	// 5. 把 Redis 中还没同步的浏览量写入数据库。
+	// 定时任务没有结束时可能正在同步，不再重复执行，也不能关闭它还在使用的连接池
+	if !cronStopped {
+		logger.Warn("定时任务仍在执行，跳过最后一次浏览量同步")
+		closePools = false
+		return code
+	}
+	app.SyncTask.SyncViewCountsToDB()
+
+	logger.Info("请求和后台任务已全部停止")
+	return code
 }
@@ -7,30 +7,54 @@ import (
 	"Nuxus/configs"
 	"Nuxus/internal/controller"
 	"Nuxus/internal/dao"
+	"Nuxus/internal/logger"
+	"Nuxus/internal/mailer"
+	"Nuxus/internal/metrics"
 	"Nuxus/internal/middleware"
 	"Nuxus/internal/routers"
 	"Nuxus/internal/service"
+	"Nuxus/internal/storage"
 	"Nuxus/internal/tasks"
+	"log/slog"
+
 	"github.com/google/wire"
 )
 
 type App struct {
 	Router              *routers.Router
 	SyncTask            *tasks.SyncTask
+	PublishTask         *tasks.PublishTask
+	CleanupTask         *tasks.CleanupTask
+	RankTask            *tasks.RankTask
+	MailTask            *tasks.MailTask
+	Background          *service.Background
 	Config              *configs.Config
+	Logger              *slog.Logger
 	MiddlewareManager   *middleware.MiddlewareManager
 }
 
 func NewApp(
 	router *routers.Router,
 	syncTask *tasks.SyncTask,
+	publishTask *tasks.PublishTask,
+	cleanupTask *tasks.CleanupTask,
+	rankTask *tasks.RankTask,
+	mailTask *tasks.MailTask,
+	background *service.Background,
 	config *configs.Config,
+	logger *slog.Logger,
 	middlewareManager *middleware.MiddlewareManager,
 ) *App {
 	return &App{
 		Router:            router,
 		SyncTask:          syncTask,
+		PublishTask:       publishTask,
+		CleanupTask:       cleanupTask,
+		RankTask:          rankTask,
+		MailTask:          mailTask,
+		Background:        background,
 		Config:            config,
+		Logger:            logger,
 		MiddlewareManager: middlewareManager,
 	}
 }
@@ -41,15 +65,29 @@ var ProviderSet = wire.NewSet(
 	configs.LoadConfig,
 	
 	// 基础设施层
+	logger.New,
+	metrics.New,
 	dao.NewDB,
 	dao.NewClient,
 	dao.NewRedisClient,
 	dao.NewRepository,
+	storage.NewObjectStore,
+	mailer.NewMailer,
+	mailer.LoadTemplates,
 	
 	// DAO层
 	dao.NewUserDAO,
 	dao.NewPostDAO,
 	dao.NewTagDAO,
+	dao.NewAuditDAO,
+	dao.NewReportDAO,
+	dao.NewNotificationDAO,
+	dao.NewMentionDAO,
+	dao.NewFollowDAO,
+	dao.NewRevisionDAO,
+	dao.NewAttachmentDAO,
+	dao.NewMySQLSearchBackend,
+	wire.Bind(new(dao.SearchBackend), new(*dao.MySQLSearchBackend)),
 	
 	// Middleware层
 	middleware.NewMiddlewareManager,
@@ -58,25 +96,49 @@ var ProviderSet = wire.NewSet(
 	service.NewEmailService,
 	service.NewAccountService,
 	service.NewUserService,
+	service.NewSessionService,
 	service.NewPostService,
 	service.NewTagService,
+	service.NewAuditService,
+	service.NewAdminService,
+	service.NewReportService,
+	service.NewNotificationService,
+	service.NewMentionService,
+	service.NewFeedService,
+	service.NewFollowService,
+	service.NewAttachmentService,
+	service.NewHealthService,
+	service.NewRankService,
+	service.NewPostCache,
+	service.NewBackground,
 	
 	// Controller层
 	controller.NewUserController,
 	controller.NewPostController,
 	controller.NewTagController,
+	controller.NewAdminController,
+	controller.NewReportController,
+	controller.NewNotificationController,
+	controller.NewMentionController,
+	controller.NewFollowController,
+	controller.NewFileController,
+	controller.NewHealthController,
 	
 	// Router层
 	routers.NewRouter,
 	
 	// Tasks
 	tasks.NewSyncTask,
+	tasks.NewPublishTask,
+	tasks.NewCleanupTask,
+	tasks.NewRankTask,
+	tasks.NewMailTask,
 	
 	// App
 	NewApp,
 )
 
-func InitializeApp() (*App, error) {
+func InitializeApp() (*App, func(), error) {
 	wire.Build(ProviderSet)
-	return &App{}, nil
+	return &App{}, nil, nil
 }
\ No newline at end of file
@@ -10,40 +10,107 @@ import (
 	"Nuxus/configs"
 	"Nuxus/internal/controller"
 	"Nuxus/internal/dao"
+	"Nuxus/internal/logger"
+	"Nuxus/internal/mailer"
+	"Nuxus/internal/metrics"
 	"Nuxus/internal/middleware"
 	"Nuxus/internal/routers"
 	"Nuxus/internal/service"
+	"Nuxus/internal/storage"
 	"Nuxus/internal/tasks"
 	"github.com/google/wire"
+	"log/slog"
 )
 
 // Injectors from wire.go:
 
-func InitializeApp() (*App, error) {
+func InitializeApp() (*App, func(), error) {
 	config, err := configs.LoadConfig()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	slogLogger := logger.New(config)
+	metricsMetrics := metrics.New()
+	db, cleanup, err := dao.NewDB(config, slogLogger, metricsMetrics)
+	if err != nil {
+		return nil, nil, err
 	}
-	db := dao.NewDB(config)
 	userDAO := dao.NewUserDAO(db)
-	client := dao.NewClient(config)
+	client, cleanup2, err := dao.NewClient(config, slogLogger, metricsMetrics)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
 	redisClient := dao.NewRedisClient(client)
-	emailService := service.NewEmailService(config)
-	userService := service.NewUserService(userDAO, redisClient, emailService, config)
-	accountService := service.NewAccountService(userDAO, config)
-	middlewareManager := middleware.NewMiddlewareManager(config)
-	userController := controller.NewUserController(userService, accountService, middlewareManager)
+	mailerMailer, err := mailer.NewMailer(config, slogLogger)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	templates, err := mailer.LoadTemplates(config)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	emailService := service.NewEmailService(mailerMailer, templates, redisClient, config, slogLogger)
+	objectStore, err := storage.NewObjectStore(config)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	accountService := service.NewAccountService(userDAO, objectStore, config, slogLogger)
+	background := service.NewBackground(slogLogger)
+	userService := service.NewUserService(userDAO, redisClient, emailService, accountService, background, config, slogLogger)
+	sessionService := service.NewSessionService(userDAO, redisClient, config, slogLogger)
+	middlewareManager := middleware.NewMiddlewareManager(config, redisClient, slogLogger, metricsMetrics)
+	userController := controller.NewUserController(userService, accountService, sessionService, middlewareManager)
 	postDAO := dao.NewPostDAO(db)
 	tagDAO := dao.NewTagDAO(db)
 	repository := dao.NewRepository(db)
-	postService := service.NewPostService(postDAO, tagDAO, repository, redisClient)
+	auditDAO := dao.NewAuditDAO(db)
+	auditService := service.NewAuditService(auditDAO, slogLogger)
+	mySQLSearchBackend := dao.NewMySQLSearchBackend(db)
+	notificationDAO := dao.NewNotificationDAO(db)
+	notificationService := service.NewNotificationService(notificationDAO, userDAO, redisClient, slogLogger)
+	mentionDAO := dao.NewMentionDAO(db)
+	mentionService := service.NewMentionService(mentionDAO, userDAO, notificationService, background, slogLogger)
+	followDAO := dao.NewFollowDAO(db)
+	feedService := service.NewFeedService(followDAO, postDAO, userDAO, redisClient, notificationService, config, slogLogger)
+	revisionDAO := dao.NewRevisionDAO(db)
+	attachmentDAO := dao.NewAttachmentDAO(db)
+	attachmentService := service.NewAttachmentService(attachmentDAO, postDAO, userDAO, objectStore, config, slogLogger)
+	rankService := service.NewRankService(postDAO, redisClient, config, slogLogger)
+	postCache := service.NewPostCache(redisClient, config, slogLogger)
+	postService := service.NewPostService(postDAO, tagDAO, repository, redisClient, auditService, mySQLSearchBackend, notificationService, mentionService, feedService, revisionDAO, attachmentService, rankService, postCache, background, config, slogLogger)
 	postController := controller.NewPostController(postService)
 	tagService := service.NewTagService(tagDAO)
 	tagController := controller.NewTagController(tagService)
-	router := routers.NewRouter(userController, postController, tagController, middlewareManager)
-	syncTask := tasks.NewSyncTask(postDAO, redisClient)
-	app := NewApp(router, syncTask, config, middlewareManager)
-	return app, nil
+	adminService := service.NewAdminService(userDAO, postDAO, tagDAO, redisClient, auditService, mentionService, postCache)
+	adminController := controller.NewAdminController(adminService, auditService)
+	reportDAO := dao.NewReportDAO(db)
+	reportService := service.NewReportService(reportDAO, postDAO, userDAO, redisClient, emailService, auditService, postCache, background, slogLogger)
+	reportController := controller.NewReportController(reportService)
+	notificationController := controller.NewNotificationController(notificationService)
+	mentionController := controller.NewMentionController(mentionService)
+	followService := service.NewFollowService(followDAO, userDAO, tagDAO, feedService, slogLogger)
+	followController := controller.NewFollowController(followService, feedService)
+	fileController := controller.NewFileController(objectStore, attachmentService, config)
+	healthService := service.NewHealthService(repository, redisClient, slogLogger)
+	healthController := controller.NewHealthController(healthService)
+	router := routers.NewRouter(userController, postController, tagController, adminController, reportController, notificationController, mentionController, followController, fileController, healthController, middlewareManager, config)
+	syncTask := tasks.NewSyncTask(postDAO, redisClient, config, slogLogger, metricsMetrics)
+	publishTask := tasks.NewPublishTask(postService)
+	cleanupTask := tasks.NewCleanupTask(attachmentService)
+	rankTask := tasks.NewRankTask(rankService)
+	mailTask := tasks.NewMailTask(emailService, slogLogger)
+	app := NewApp(router, syncTask, publishTask, cleanupTask, rankTask, mailTask, background, config, slogLogger, middlewareManager)
+	return app, func() {
+		cleanup2()
+		cleanup()
+	}, nil
 }
 
 // wire.go:
@@ -51,23 +118,40 @@ func InitializeApp() (*App, error) {
 type App struct {
 	Router            *routers.Router
 	SyncTask          *tasks.SyncTask
+	PublishTask       *tasks.PublishTask
+	CleanupTask       *tasks.CleanupTask
+	RankTask          *tasks.RankTask
+	MailTask          *tasks.MailTask
+	Background        *service.Background
 	Config            *configs.Config
+	Logger            *slog.Logger
 	MiddlewareManager *middleware.MiddlewareManager
 }
 
 func NewApp(
 	router *routers.Router,
 	syncTask *tasks.SyncTask,
-	config *configs.Config,
+	publishTask *tasks.PublishTask,
+	cleanupTask *tasks.CleanupTask,
+	rankTask *tasks.RankTask,
+	mailTask *tasks.MailTask,
+	background *service.Background,
+	config *configs.Config, logger2 *slog.Logger,
 	middlewareManager *middleware.MiddlewareManager,
 ) *App {
 	return &App{
 		Router:            router,
 		SyncTask:          syncTask,
+		PublishTask:       publishTask,
+		CleanupTask:       cleanupTask,
+		RankTask:          rankTask,
+		MailTask:          mailTask,
+		Background:        background,
 		Config:            config,
+		Logger:            logger2,
 		MiddlewareManager: middlewareManager,
 	}
 }
 
 // Wire Provider Set
-var ProviderSet = wire.NewSet(configs.LoadConfig, dao.NewDB, dao.NewClient, dao.NewRedisClient, dao.NewRepository, dao.NewUserDAO, dao.NewPostDAO, dao.NewTagDAO, middleware.NewMiddlewareManager, service.NewEmailService, service.NewAccountService, service.NewUserService, service.NewPostService, service.NewTagService, controller.NewUserController, controller.NewPostController, controller.NewTagController, routers.NewRouter, tasks.NewSyncTask, NewApp)
+var ProviderSet = wire.NewSet(configs.LoadConfig, logger.New, metrics.New, dao.NewDB, dao.NewClient, dao.NewRedisClient, dao.NewRepository, storage.NewObjectStore, mailer.NewMailer, mailer.LoadTemplates, dao.NewUserDAO, dao.NewPostDAO, dao.NewTagDAO, dao.NewAuditDAO, dao.NewReportDAO, dao.NewNotificationDAO, dao.NewMentionDAO, dao.NewFollowDAO, dao.NewRevisionDAO, dao.NewAttachmentDAO, dao.NewMySQLSearchBackend, wire.Bind(new(dao.SearchBackend), new(*dao.MySQLSearchBackend)), middleware.NewMiddlewareManager, service.NewEmailService, service.NewAccountService, service.NewUserService, service.NewSessionService, service.NewPostService, service.NewTagService, service.NewAuditService, service.NewAdminService, service.NewReportService, service.NewNotificationService, service.NewMentionService, service.NewFeedService, service.NewFollowService, service.NewAttachmentService, service.NewHealthService, service.NewRankService, service.NewPostCache, service.NewBackground, controller.NewUserController, controller.NewPostController, controller.NewTagController, controller.NewAdminController, controller.NewReportController, controller.NewNotificationController, controller.NewMentionController, controller.NewFollowController, controller.NewFileController, controller.NewHealthController, routers.NewRouter, tasks.NewSyncTask, tasks.NewPublishTask, tasks.NewCleanupTask, tasks.NewRankTask, tasks.NewMailTask, NewApp)
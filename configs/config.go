@@ -11,16 +11,50 @@ import (
 // var Conf = new(Config)
 
 type Config struct {
-	Server ServerConfig `mapstructure:"server"`
-	MySQL  MySQLConfig  `mapstructure:"mysql"`
-	Redis  RedisConfig  `mapstructure:"redis"`
-	SMTP   SMTPConfig   `mapstructure:"smtp"`
-	JWT    JWTConfig    `mapstructure:"jwt"`
-	Qiniu  QiniuConfig  `mapstructure:"qiniu"`
+	Server  ServerConfig  `mapstructure:"server"`
+	Log     LogConfig     `mapstructure:"log"`
+	Metrics MetricsConfig `mapstructure:"metrics"`
+	MySQL   MySQLConfig   `mapstructure:"mysql"`
+	Redis   RedisConfig   `mapstructure:"redis"`
+	SMTP    SMTPConfig    `mapstructure:"smtp"`
+	Mail    MailConfig    `mapstructure:"mail"`
+	JWT     JWTConfig     `mapstructure:"jwt"`
+	Auth    AuthConfig    `mapstructure:"auth"`
+	Qiniu   QiniuConfig   `mapstructure:"qiniu"`
+	Storage StorageConfig `mapstructure:"storage"`
+	Feed    FeedConfig    `mapstructure:"feed"`
+	Upload  UploadConfig  `mapstructure:"upload"`
+	View    ViewConfig    `mapstructure:"view"`
+	Rank    RankConfig    `mapstructure:"rank"`
+	Cache   CacheConfig   `mapstructure:"cache"`
+
+	RateLimit RateLimitConfig `mapstructure:"rateLimit"`
+}
+
+// LogConfig 定义了日志的级别和输出格式
+type LogConfig struct {
+	Level  string `mapstructure:"level"`  // debug、info、warn、error
+	Format string `mapstructure:"format"` // text 便于开发时阅读，json 便于生产环境采集
+}
+
+// MetricsConfig 定义了 Prometheus 指标接口
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
 }
 
 type ServerConfig struct {
 	Port int `mapstructure:"port"`
+
+	ReadTimeoutSeconds       int `mapstructure:"readTimeoutSeconds"`       // 读取整个请求（含上传的文件）的超时时间
+	ReadHeaderTimeoutSeconds int `mapstructure:"readHeaderTimeoutSeconds"` // 读取请求头的超时时间
+	WriteTimeoutSeconds      int `mapstructure:"writeTimeoutSeconds"`      // 从读完请求头到写完响应的超时时间
+	IdleTimeoutSeconds       int `mapstructure:"idleTimeoutSeconds"`       // keep-alive 连接的空闲超时时间
+	ShutdownTimeoutSeconds   int `mapstructure:"shutdownTimeoutSeconds"`   // 退出时等待请求和后台任务结束的最长时间
+
+	// 可信的反向代理的 IP 或网段（如 10.0.0.0/8），只有来自这些地址的请求才会读取 X-Forwarded-For 获取客户端 IP。
+	// 登录锁定、限流、浏览量去重都依赖客户端 IP；为空表示前面没有代理，直接使用连接的对端地址
+	TrustedProxies []string `mapstructure:"trustedProxies"`
 }
 
 type MySQLConfig struct {
@@ -44,8 +78,52 @@ type SMTPConfig struct {
 
 // JWTConfig 定义了生成和验证 JWT 所需的配置
 type JWTConfig struct {
-	Secret      string `mapstructure:"secret"`
-	ExpireHours int    `mapstructure:"expireHours"`
+	Secret string `mapstructure:"secret"`
+	// 访问令牌有效期（分钟），应尽量短，过期后用刷新令牌换取新的
+	AccessExpireMinutes int `mapstructure:"accessExpireMinutes"`
+	// 刷新令牌有效期（小时），也是 Redis 中会话的存活时间
+	RefreshExpireHours int `mapstructure:"refreshExpireHours"`
+}
+
+// AuthConfig 定义了登录和验证码的防暴力破解策略
+type AuthConfig struct {
+	// 窗口期（分钟）内同一账号失败 maxAccountFailures 次、或同一 IP 失败 maxIPFailures 次后锁定
+	FailureWindowMinutes int `mapstructure:"failureWindowMinutes"`
+	MaxAccountFailures   int `mapstructure:"maxAccountFailures"`
+	MaxIPFailures        int `mapstructure:"maxIPFailures"`
+	// 24 小时内第 n 次锁定持续 lockBaseSeconds * 2^(n-1) 秒，最长 lockMaxSeconds 秒
+	LockBaseSeconds int `mapstructure:"lockBaseSeconds"`
+	LockMaxSeconds  int `mapstructure:"lockMaxSeconds"`
+	// 验证码输错该次数后失效，需要重新获取
+	MaxCodeAttempts int `mapstructure:"maxCodeAttempts"`
+}
+
+// RateLimitConfig 定义了接口限流，每条规则是一个令牌桶，路由通过规则名引用
+// 默认规则：global 作用于所有接口，auth 作用于登录注册等接口，write 作用于发帖、评论等写操作，interact 作用于点赞、收藏、关注
+type RateLimitConfig struct {
+	Enabled bool                     `mapstructure:"enabled"`
+	Rules   map[string]RateLimitRule `mapstructure:"rules"`
+}
+
+// RateLimitRule 每 periodSeconds 秒补充 limit 个令牌，桶最多存 burst 个（为 0 时等于 limit）
+type RateLimitRule struct {
+	Limit         int `mapstructure:"limit"`
+	PeriodSeconds int `mapstructure:"periodSeconds"`
+	Burst         int `mapstructure:"burst"`
+}
+
+// MailConfig 定义了邮件的发送方式、模板和发送队列
+type MailConfig struct {
+	// 发送方式：smtp 使用上面的 smtp 配置；file 把邮件写成 .eml 文件；log 只打印日志，用于开发环境
+	Driver      string `mapstructure:"driver"`
+	OutboxDir   string `mapstructure:"outboxDir"`   // file 方式写入的目录
+	TemplateDir string `mapstructure:"templateDir"` // 模板目录，每种语言一个子目录，如 zh-CN、en
+	DefaultLang string `mapstructure:"defaultLang"` // 找不到请求的语言时使用
+	// 发送失败后最多尝试的次数，超过后进入死信列表
+	MaxAttempts int `mapstructure:"maxAttempts"`
+	// 第 n 次重试等待 retryBaseSeconds * 2^(n-1) 秒，最多等待 retryMaxSeconds 秒
+	RetryBaseSeconds int `mapstructure:"retryBaseSeconds"`
+	RetryMaxSeconds  int `mapstructure:"retryMaxSeconds"`
 }
 
 type QiniuConfig struct {
@@ -54,8 +132,160 @@ type QiniuConfig struct {
 	Bucket    string `mapstructure:"bucket"`
 	Domain    string `mapstructure:"domain"`
 	Zone      string `mapstructure:"zone"`
+	Private   bool   `mapstructure:"private"`   // 私有空间，访问地址需要签名
+	UseHTTPS  bool   `mapstructure:"use_https"` // 域名是否支持 HTTPS
+}
+
+// StorageConfig 定义了对象存储的配置
+type StorageConfig struct {
+	// 使用的后端：qiniu、local、s3，七牛云沿用上面的 qiniu 配置
+	Driver string `mapstructure:"driver"`
+	// 私有空间签名地址的有效期（分钟）
+	URLExpireMinutes int                `mapstructure:"urlExpireMinutes"`
+	Local            LocalStorageConfig `mapstructure:"local"`
+	S3               S3StorageConfig    `mapstructure:"s3"`
 }
 
+// LocalStorageConfig 本地磁盘存储，文件由服务自身在 /uploads 下提供
+type LocalStorageConfig struct {
+	Root string `mapstructure:"root"` // 文件保存的目录
+	// 拼接访问地址用的站点地址，如 http://localhost:8080，留空时返回相对路径
+	BaseURL    string `mapstructure:"baseURL"`
+	Private    bool   `mapstructure:"private"`
+	SignSecret string `mapstructure:"signSecret"` // 私有模式下签名访问地址的密钥
+}
+
+// S3StorageConfig 兼容 S3 协议的对象存储
+type S3StorageConfig struct {
+	Endpoint  string `mapstructure:"endpoint"` // 不带协议，如 s3.amazonaws.com、minio:9000
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"accessKey"`
+	SecretKey string `mapstructure:"secretKey"`
+	UseSSL    bool   `mapstructure:"useSSL"`
+	Private   bool   `mapstructure:"private"`
+	// 公开空间的访问地址前缀（如 CDN 域名），留空时使用 <endpoint>/<bucket>
+	PublicBaseURL string `mapstructure:"publicBaseURL"`
+}
+
+// FeedConfig 定义了关注时间线的配置
+type FeedConfig struct {
+	// 粉丝数不超过该值的作者发帖时推送到每个粉丝的收件箱（写扩散），
+	// 超过的作者由粉丝在读取时间线时拉取（读扩散）
+	FanoutThreshold int `mapstructure:"fanoutThreshold"`
+	// 每个用户收件箱最多保留的帖子数
+	InboxSize int `mapstructure:"inboxSize"`
+}
+
+// UploadConfig 定义了帖子配图上传的限制
+type UploadConfig struct {
+	MaxImageMB     int `mapstructure:"maxImageMB"`     // 单张图片的大小上限（MB）
+	MaxImageWidth  int `mapstructure:"maxImageWidth"`  // 宽度上限（像素）
+	MaxImageHeight int `mapstructure:"maxImageHeight"` // 高度上限（像素）
+	// 宽×高的上限（百万像素），防止解码时占用过多内存
+	MaxImageMegapixels int `mapstructure:"maxImageMegapixels"`
+	// 动图的帧数上限，以及所有帧的像素之和的上限（百万像素），在解码所有帧之前检查
+	MaxGIFFrames     int `mapstructure:"maxGIFFrames"`
+	MaxGIFMegapixels int `mapstructure:"maxGIFMegapixels"`
+	// 缩略图的宽度，每个宽度生成一张原格式和一张 WebP 缩略图，不超过原图宽度的才生成
+	ThumbnailWidths []int `mapstructure:"thumbnailWidths"`
+	// 上传后超过该时长（小时）仍未被任何帖子引用的图片会被清理
+	OrphanGraceHours int `mapstructure:"orphanGraceHours"`
+}
+
+// ViewConfig 定义了帖子浏览量的统计
+type ViewConfig struct {
+	// 同一用户（未登录时按 IP）在该时长（分钟）内重复浏览同一帖子只计一次
+	DedupMinutes int `mapstructure:"dedupMinutes"`
+	// 写入数据库时每条 UPDATE 包含的帖子数
+	FlushBatchSize int `mapstructure:"flushBatchSize"`
+}
+
+// RankConfig 定义了热门榜单的计算方式
+type RankConfig struct {
+	// 每次浏览、点赞、评论、收藏获得的积分，取消点赞、收藏时扣除相同的积分
+	ViewWeight     float64 `mapstructure:"viewWeight"`
+	LikeWeight     float64 `mapstructure:"likeWeight"`
+	CommentWeight  float64 `mapstructure:"commentWeight"`
+	FavoriteWeight float64 `mapstructure:"favoriteWeight"`
+	// 热度 = 积分 / (发布后的小时数 + 2) ^ gravity，越大旧帖子下降得越快
+	Gravity float64 `mapstructure:"gravity"`
+	// 发布超过该天数的帖子不再进入热门榜
+	MaxAgeDays int `mapstructure:"maxAgeDays"`
+	HotSize    int `mapstructure:"hotSize"`   // 热门榜保留的帖子数
+	BoardSize  int `mapstructure:"boardSize"` // 每个累计榜保留的帖子数
+}
+
+// CacheConfig 定义了帖子详情和列表首页的缓存，开发环境可以关闭以便直接看到数据库中的修改
+type CacheConfig struct {
+	Enabled            bool `mapstructure:"enabled"`
+	PostTTLSeconds     int  `mapstructure:"postTTLSeconds"`     // 帖子详情的缓存时间
+	ListTTLSeconds     int  `mapstructure:"listTTLSeconds"`     // 列表首页的缓存时间
+	NegativeTTLSeconds int  `mapstructure:"negativeTTLSeconds"` // 不存在的帖子的缓存时间
+	// 缓存时间随机增加最多该百分比，避免大量缓存同时过期
+	JitterPercent int `mapstructure:"jitterPercent"`
+}
+
+// setDefaults 为未在配置文件中出现的字段设置默认值
+func setDefaults() {
+	viper.SetDefault("server.readTimeoutSeconds", 30)
+	viper.SetDefault("server.readHeaderTimeoutSeconds", 5)
+	viper.SetDefault("server.writeTimeoutSeconds", 60)
+	viper.SetDefault("server.idleTimeoutSeconds", 120)
+	viper.SetDefault("server.shutdownTimeoutSeconds", 30)
+	viper.SetDefault("log.level", "info")
+	viper.SetDefault("log.format", "text")
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("jwt.accessExpireMinutes", 15)
+	viper.SetDefault("jwt.refreshExpireHours", 7*24)
+	viper.SetDefault("auth.failureWindowMinutes", 15)
+	viper.SetDefault("auth.maxAccountFailures", 5)
+	viper.SetDefault("auth.maxIPFailures", 20)
+	viper.SetDefault("auth.lockBaseSeconds", 60)
+	viper.SetDefault("auth.lockMaxSeconds", 3600)
+	viper.SetDefault("auth.maxCodeAttempts", 5)
+	viper.SetDefault("rateLimit.enabled", true)
+	for rule, limit := range map[string]int{"global": 300, "auth": 10, "write": 30, "interact": 60} {
+		viper.SetDefault("rateLimit.rules."+rule+".limit", limit)
+		viper.SetDefault("rateLimit.rules."+rule+".periodSeconds", 60)
+	}
+	viper.SetDefault("mail.driver", "smtp")
+	viper.SetDefault("mail.outboxDir", "./outbox")
+	viper.SetDefault("mail.templateDir", "./templates/mail")
+	viper.SetDefault("mail.defaultLang", "zh-CN")
+	viper.SetDefault("mail.maxAttempts", 5)
+	viper.SetDefault("mail.retryBaseSeconds", 30)
+	viper.SetDefault("mail.retryMaxSeconds", 3600)
+	viper.SetDefault("storage.driver", "qiniu")
+	viper.SetDefault("storage.urlExpireMinutes", 60)
+	viper.SetDefault("storage.local.root", "./uploads")
+	viper.SetDefault("feed.fanoutThreshold", 1000)
+	viper.SetDefault("feed.inboxSize", 800)
+	viper.SetDefault("upload.maxImageMB", 10)
+	viper.SetDefault("upload.maxImageWidth", 8192)
+	viper.SetDefault("upload.maxImageHeight", 8192)
+	viper.SetDefault("upload.maxImageMegapixels", 40)
+	viper.SetDefault("upload.maxGIFFrames", 300)
+	viper.SetDefault("upload.maxGIFMegapixels", 100)
+	viper.SetDefault("upload.thumbnailWidths", []int{320, 960})
+	viper.SetDefault("upload.orphanGraceHours", 24)
+	viper.SetDefault("view.dedupMinutes", 30)
+	viper.SetDefault("view.flushBatchSize", 500)
+	viper.SetDefault("rank.viewWeight", 10)
+	viper.SetDefault("rank.likeWeight", 30)
+	viper.SetDefault("rank.commentWeight", 20)
+	viper.SetDefault("rank.favoriteWeight", 30)
+	viper.SetDefault("rank.gravity", 1.8)
+	viper.SetDefault("rank.maxAgeDays", 7)
+	viper.SetDefault("rank.hotSize", 500)
+	viper.SetDefault("rank.boardSize", 1000)
+	viper.SetDefault("cache.enabled", true)
+	viper.SetDefault("cache.postTTLSeconds", 300)
+	viper.SetDefault("cache.listTTLSeconds", 60)
+	viper.SetDefault("cache.negativeTTLSeconds", 30)
+	viper.SetDefault("cache.jitterPercent", 20)
+}
 
 // LoadConfig 用于Wire依赖注入
 func LoadConfig() (*Config, error) {
@@ -67,6 +297,7 @@ func LoadConfig() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(workDir)
+	setDefaults()
 
 	err = viper.ReadInConfig()
 	if err != nil {
@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"Nuxus/internal/dto"
+	"Nuxus/internal/models"
+	"Nuxus/internal/res"
+	"Nuxus/internal/service"
+	"Nuxus/pkg/erru"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	adminService *service.AdminService
+	auditService *service.AuditService
+}
+
+func NewAdminController(adminService *service.AdminService, auditService *service.AuditService) *AdminController {
+	return &AdminController{
+		adminService: adminService,
+		auditService: auditService,
+	}
+}
+
+// -------------------用户管理--------------------------------
+func (ac *AdminController) ListUsers(c *gin.Context) {
+	var reqDto dto.AdminListUsersReqDTO
+	if err := c.ShouldBindQuery(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	if reqDto.Page <= 0 {
+		reqDto.Page = 1
+	}
+	if reqDto.Size <= 0 || reqDto.Size > 100 {
+		reqDto.Size = 20
+	}
+
+	users, total, err := ac.adminService.ListUsers(c.Request.Context(), &reqDto)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	userInfos := make([]dto.AdminUserInfoDTO, 0, len(users))
+	for _, user := range users {
+		userInfos = append(userInfos, *userModel2AdminDto(user))
+	}
+
+	res.OkWithData(c, dto.AdminListUsersResDTO{
+		Total: total,
+		Users: userInfos,
+	})
+}
+
+func userModel2AdminDto(user *models.User) *dto.AdminUserInfoDTO {
+	return &dto.AdminUserInfoDTO{
+		ID:                user.ID,
+		Username:          user.Username,
+		Email:             user.Email,
+		Role:              user.Role,
+		Avatar:            user.Avatar,
+		Banned:            user.IsBanned(time.Now()),
+		BanReason:         user.BanReason,
+		BanExpiresAt:      user.BanExpiresAt,
+		MustResetPassword: user.MustResetPassword,
+		CreatedAt:         user.CreatedAt,
+	}
+}
+
+func (ac *AdminController) UpdateUserRole(c *gin.Context) {
+	var reqDto dto.UpdateUserRoleReqDTO
+	userId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := c.ShouldBindJSON(&reqDto); err != nil || userId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	adminId := c.MustGet("userID").(uint)
+
+	err := ac.adminService.UpdateUserRole(c.Request.Context(), adminId, c.GetString("role"), uint(userId), reqDto.Role)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "角色已更新")
+}
+
+func (ac *AdminController) BanUser(c *gin.Context) {
+	var reqDto dto.BanUserReqDTO
+	userId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := c.ShouldBindJSON(&reqDto); err != nil || userId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	adminId := c.MustGet("userID").(uint)
+
+	err := ac.adminService.BanUser(c.Request.Context(), adminId, c.GetString("role"), uint(userId), &reqDto)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "用户已封禁")
+}
+
+func (ac *AdminController) UnbanUser(c *gin.Context) {
+	userId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if userId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	adminId := c.MustGet("userID").(uint)
+
+	err := ac.adminService.UnbanUser(c.Request.Context(), adminId, c.GetString("role"), uint(userId))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "用户已解封")
+}
+
+func (ac *AdminController) ForcePasswordReset(c *gin.Context) {
+	userId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if userId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	adminId := c.MustGet("userID").(uint)
+
+	err := ac.adminService.ForcePasswordReset(c.Request.Context(), adminId, c.GetString("role"), uint(userId))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "已要求该用户重置密码")
+}
+
+// -------------------内容管理--------------------------------
+
+// SetPostFlag 返回一个设置帖子管理标记的 handler，POST 设置、DELETE 取消
+func (ac *AdminController) SetPostFlag(flag string, value bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+		if postId == 0 {
+			c.Error(erru.ErrInvalidParams)
+			return
+		}
+		adminId := c.MustGet("userID").(uint)
+
+		err := ac.adminService.SetPostFlag(c.Request.Context(), adminId, c.GetString("role"), uint(postId), flag, value)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		res.OkWithMsg(c, "操作成功")
+	}
+}
+
+func (ac *AdminController) RemoveComment(c *gin.Context) {
+	commentId, _ := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if commentId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	adminId := c.MustGet("userID").(uint)
+
+	err := ac.adminService.RemoveComment(c.Request.Context(), adminId, c.GetString("role"), uint(commentId))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "评论已删除")
+}
+
+// -------------------标签管理--------------------------------
+func (ac *AdminController) RenameTag(c *gin.Context) {
+	var reqDto dto.RenameTagReqDTO
+	tagId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := c.ShouldBindJSON(&reqDto); err != nil || tagId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	adminId := c.MustGet("userID").(uint)
+
+	err := ac.adminService.RenameTag(c.Request.Context(), adminId, c.GetString("role"), uint(tagId), reqDto.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "标签已重命名")
+}
+
+func (ac *AdminController) MergeTags(c *gin.Context) {
+	var reqDto dto.MergeTagReqDTO
+	tagId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := c.ShouldBindJSON(&reqDto); err != nil || tagId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	adminId := c.MustGet("userID").(uint)
+
+	err := ac.adminService.MergeTags(c.Request.Context(), adminId, c.GetString("role"), uint(tagId), reqDto.TargetID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "标签已合并")
+}
+
+// -------------------审计日志--------------------------------
+func (ac *AdminController) ListAuditLogs(c *gin.Context) {
+	targetType := c.Query("target_type")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+
+	logs, total, err := ac.auditService.ListAuditLogs(c.Request.Context(), targetType, page, size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	logsDto := make([]dto.AuditLogDTO, 0, len(logs))
+	for _, auditLog := range logs {
+		logsDto = append(logsDto, dto.AuditLogDTO{
+			ID:            auditLog.ID,
+			ActorID:       auditLog.ActorID,
+			ActorRole:     auditLog.ActorRole,
+			Action:        auditLog.Action,
+			TargetType:    auditLog.TargetType,
+			TargetID:      auditLog.TargetID,
+			TargetOwnerID: auditLog.TargetOwnerID,
+			Detail:        auditLog.Detail,
+			CreatedAt:     auditLog.CreatedAt,
+		})
+	}
+
+	res.OkWithData(c, dto.ListAuditLogsResDTO{
+		Total: total,
+		Logs:  logsDto,
+	})
+}
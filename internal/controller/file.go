@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"Nuxus/configs"
+	"Nuxus/internal/dto"
+	"Nuxus/internal/res"
+	"Nuxus/internal/service"
+	"Nuxus/internal/storage"
+	"Nuxus/pkg/erru"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FileController struct {
+	objectStore       storage.ObjectStore
+	attachmentService *service.AttachmentService
+	config            *configs.Config
+}
+
+func NewFileController(objectStore storage.ObjectStore, attachmentService *service.AttachmentService, config *configs.Config) *FileController {
+	return &FileController{
+		objectStore:       objectStore,
+		attachmentService: attachmentService,
+		config:            config,
+	}
+}
+
+// UploadImage 上传帖子配图，表单字段为 "image"
+func (fc *FileController) UploadImage(c *gin.Context) {
+	// 解析表单前限制请求体大小，额外留 1MB 给 multipart 的边界和其他字段
+	maxBytes := int64(fc.config.Upload.MaxImageMB+1) << 20
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+	file, err := c.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Error(erru.ErrInvalidParams.WithMsg(fmt.Sprintf("图片大小不能超过%dMB", fc.config.Upload.MaxImageMB)))
+			return
+		}
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	attachment, err := fc.attachmentService.UploadImage(c.Request.Context(), userID.(uint), file)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resDto := dto.UploadImageResDTO{
+		ID:          attachment.ID,
+		URL:         attachment.URL,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Width:       attachment.Width,
+		Height:      attachment.Height,
+		Variants:    make([]*dto.ImageVariantDTO, 0, len(attachment.Variants)),
+	}
+	for _, variant := range attachment.Variants {
+		resDto.Variants = append(resDto.Variants, &dto.ImageVariantDTO{
+			URL:         variant.URL,
+			ContentType: variant.ContentType,
+			Width:       variant.Width,
+			Height:      variant.Height,
+		})
+	}
+	res.OkWithData(c, resDto)
+}
+
+// Redirect 跳转到对象的访问地址，私有空间每次访问时重新签名
+// 只签名头像和配图记录中的对象，并按它们的归属检查访问权限
+func (fc *FileController) Redirect(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if key == "" {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	viewerID := c.GetUint("userID")
+	if err := fc.attachmentService.CheckObjectAccess(c.Request.Context(), key, viewerID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	expires := time.Duration(fc.config.Storage.URLExpireMinutes) * time.Minute
+	url, err := fc.objectStore.URL(key, expires)
+	if err != nil {
+		c.Error(erru.ErrInternalServer.Wrap(err))
+		return
+	}
+	// 签名地址会过期，不能让浏览器长期缓存这次跳转
+	c.Header("Cache-Control", "private, max-age=60")
+	c.Redirect(http.StatusFound, url)
+}
+
+// ServeLocal 提供本地存储中的文件，只有使用本地存储时可用
+func (fc *FileController) ServeLocal(c *gin.Context) {
+	local, ok := fc.objectStore.(*storage.LocalStore)
+	if !ok {
+		c.Error(erru.ErrResourceNotFound)
+		return
+	}
+	path, err := local.Open(c.Param("key"), c.Request.URL.Query())
+	if err != nil {
+		if err == storage.ErrInvalidSignature {
+			c.Error(erru.ErrUnauthorized)
+			return
+		}
+		c.Error(erru.ErrResourceNotFound)
+		return
+	}
+	c.File(path)
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"Nuxus/internal/dto"
+	"Nuxus/internal/models"
+	"Nuxus/internal/res"
+	"Nuxus/internal/service"
+	"Nuxus/pkg/erru"
+	"context"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FollowController struct {
+	followService *service.FollowService
+	feedService   *service.FeedService
+}
+
+func NewFollowController(followService *service.FollowService, feedService *service.FeedService) *FollowController {
+	return &FollowController{
+		followService: followService,
+		feedService:   feedService,
+	}
+}
+
+// -------------------关注用户--------------------------------
+func (fc *FollowController) FollowUser(c *gin.Context) {
+	targetId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if targetId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	if err := fc.followService.FollowUser(c.Request.Context(), userId, uint(targetId)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "关注成功")
+}
+
+func (fc *FollowController) UnfollowUser(c *gin.Context) {
+	targetId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if targetId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	if err := fc.followService.UnfollowUser(c.Request.Context(), userId, uint(targetId)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "已取消关注")
+}
+
+func (fc *FollowController) ListFollowers(c *gin.Context) {
+	fc.listFollowUsers(c, fc.followService.ListFollowers)
+}
+
+func (fc *FollowController) ListFollowing(c *gin.Context) {
+	fc.listFollowUsers(c, fc.followService.ListFollowing)
+}
+
+func (fc *FollowController) listFollowUsers(c *gin.Context, list func(ctx context.Context, userID uint, page, size int) ([]*models.User, int64, error)) {
+	userId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if userId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+
+	users, total, err := list(c.Request.Context(), uint(userId), page, size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	usersDto := make([]dto.UserInfoDTO, 0, len(users))
+	for _, user := range users {
+		usersDto = append(usersDto, *userModel2InfoDto(user))
+	}
+
+	res.OkWithData(c, dto.ListFollowUsersResDTO{
+		Total: total,
+		Users: usersDto,
+	})
+}
+
+// -------------------关注标签--------------------------------
+func (fc *FollowController) FollowTag(c *gin.Context) {
+	tagId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if tagId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	if err := fc.followService.FollowTag(c.Request.Context(), userId, uint(tagId)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "关注成功")
+}
+
+func (fc *FollowController) UnfollowTag(c *gin.Context) {
+	tagId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if tagId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	if err := fc.followService.UnfollowTag(c.Request.Context(), userId, uint(tagId)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "已取消关注")
+}
+
+// -------------------时间线--------------------------------
+// Feed 关注的用户和标签的帖子时间线，使用游标分页
+func (fc *FollowController) Feed(c *gin.Context) {
+	cursor := c.Query("cursor")
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if size <= 0 || size > 50 {
+		size = 20
+	}
+	userId := c.MustGet("userID").(uint)
+
+	posts, nextCursor, err := fc.feedService.Timeline(c.Request.Context(), userId, cursor, size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	writeFeed(c, posts, nextCursor)
+}
+
+// TagFeed 关注标签下的帖子，可以用 tag_id 只看其中一个标签
+func (fc *FollowController) TagFeed(c *gin.Context) {
+	cursor := c.Query("cursor")
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if size <= 0 || size > 50 {
+		size = 20
+	}
+	var tagId uint64
+	if tagIdStr := c.Query("tag_id"); tagIdStr != "" {
+		var err error
+		tagId, err = strconv.ParseUint(tagIdStr, 10, 32)
+		if err != nil {
+			c.Error(erru.ErrInvalidParams)
+			return
+		}
+	}
+	userId := c.MustGet("userID").(uint)
+
+	posts, nextCursor, err := fc.feedService.TagTimeline(c.Request.Context(), userId, uint(tagId), cursor, size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	writeFeed(c, posts, nextCursor)
+}
+
+func writeFeed(c *gin.Context, posts []*models.Post, nextCursor string) {
+	postInfos := make([]dto.PostInfoResDTO, 0, len(posts))
+	for _, post := range posts {
+		postInfos = append(postInfos, *service.PostModel2InfoDTO(post))
+	}
+
+	res.OkWithData(c, dto.FeedResDTO{
+		Posts:      postInfos,
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	})
+}
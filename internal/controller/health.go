@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"Nuxus/internal/dto"
+	"Nuxus/internal/res"
+	"Nuxus/internal/service"
+	"Nuxus/pkg/erru"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	healthService *service.HealthService
+}
+
+func NewHealthController(healthService *service.HealthService) *HealthController {
+	return &HealthController{
+		healthService: healthService,
+	}
+}
+
+// Healthz 存活探针，进程能处理请求就返回成功，不检查依赖，避免依赖故障时进程被反复重启
+func (hc *HealthController) Healthz(c *gin.Context) {
+	res.OkWithData(c, dto.HealthResDTO{Status: service.HealthOK})
+}
+
+// Readyz 就绪探针，MySQL 或 Redis 不可用时返回 503，负载均衡不再转发请求过来
+func (hc *HealthController) Readyz(c *gin.Context) {
+	checks, healthy := hc.healthService.CheckDependencies(c.Request.Context())
+	if !healthy {
+		res.FailWithStatus(c, http.StatusServiceUnavailable,
+			erru.ErrServiceUnavailable.WithData(dto.HealthResDTO{Status: service.HealthFail, Checks: checks}))
+		return
+	}
+	res.OkWithData(c, dto.HealthResDTO{Status: service.HealthOK, Checks: checks})
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"Nuxus/internal/dto"
+	"Nuxus/internal/res"
+	"Nuxus/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MentionController struct {
+	mentionService *service.MentionService
+}
+
+func NewMentionController(mentionService *service.MentionService) *MentionController {
+	return &MentionController{
+		mentionService: mentionService,
+	}
+}
+
+// ListMentions 查询当前用户被 @ 的记录
+func (mc *MentionController) ListMentions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+	userId := c.MustGet("userID").(uint)
+
+	mentions, total, err := mc.mentionService.ListUserMentions(c.Request.Context(), userId, page, size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	mentionsDto := make([]dto.UserMentionDTO, 0, len(mentions))
+	for _, mention := range mentions {
+		mentionsDto = append(mentionsDto, dto.UserMentionDTO{
+			ID:        mention.ID,
+			Actor:     *userModel2InfoDto(&mention.Actor),
+			PostID:    mention.PostID,
+			PostTitle: mention.Post.Title,
+			CommentID: mention.CommentID,
+			Excerpt:   mention.Excerpt,
+			CreatedAt: mention.CreatedAt,
+		})
+	}
+
+	res.OkWithData(c, dto.ListMentionsResDTO{
+		Total:    total,
+		Mentions: mentionsDto,
+	})
+}
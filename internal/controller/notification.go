@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"Nuxus/internal/dto"
+	"Nuxus/internal/models"
+	"Nuxus/internal/res"
+	"Nuxus/internal/service"
+	"Nuxus/pkg/erru"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationController(notificationService *service.NotificationService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+	}
+}
+
+func (nc *NotificationController) ListNotifications(c *gin.Context) {
+	var reqDto dto.ListNotificationsReqDTO
+	if err := c.ShouldBindQuery(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	if reqDto.Page <= 0 {
+		reqDto.Page = 1
+	}
+	if reqDto.Size <= 0 || reqDto.Size > 100 {
+		reqDto.Size = 20
+	}
+	userId := c.MustGet("userID").(uint)
+
+	notifications, total, err := nc.notificationService.ListNotifications(c.Request.Context(), userId, reqDto.UnreadOnly, reqDto.Page, reqDto.Size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	unread, err := nc.notificationService.UnreadCount(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	notificationsDto := make([]dto.NotificationDTO, 0, len(notifications))
+	for _, notification := range notifications {
+		notificationsDto = append(notificationsDto, *notificationModel2DTO(notification))
+	}
+
+	res.OkWithData(c, dto.ListNotificationsResDTO{
+		Total:         total,
+		Unread:        unread,
+		Notifications: notificationsDto,
+	})
+}
+
+func (nc *NotificationController) UnreadCount(c *gin.Context) {
+	userId := c.MustGet("userID").(uint)
+
+	unread, err := nc.notificationService.UnreadCount(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithData(c, dto.UnreadCountResDTO{Unread: unread})
+}
+
+func (nc *NotificationController) MarkRead(c *gin.Context) {
+	notificationId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if notificationId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	if err := nc.notificationService.MarkRead(c.Request.Context(), userId, uint(notificationId)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "已标记为已读")
+}
+
+func (nc *NotificationController) MarkAllRead(c *gin.Context) {
+	userId := c.MustGet("userID").(uint)
+
+	if err := nc.notificationService.MarkAllRead(c.Request.Context(), userId); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "已全部标记为已读")
+}
+
+// 各类型通知的动作描述
+var notificationActions = map[string]string{
+	models.NotifyTypeComment:  "评论了你的帖子",
+	models.NotifyTypeReply:    "回复了你的评论",
+	models.NotifyTypeLike:     "赞了你的帖子",
+	models.NotifyTypeFavorite: "收藏了你的帖子",
+	models.NotifyTypeMention:  "提到了你",
+}
+
+func notificationModel2DTO(notification *models.Notification) *dto.NotificationDTO {
+	var summary string
+	switch {
+	case notification.Type == models.NotifyTypeTagPost && notification.EventCount > 1:
+		summary = fmt.Sprintf("你关注的 #%s 有 %d 篇新帖子", notification.Tag.Name, notification.EventCount)
+	case notification.Type == models.NotifyTypeTagPost:
+		summary = fmt.Sprintf("%s 在你关注的 #%s 发布了新帖子", notification.Actor.Username, notification.Tag.Name)
+	case notification.ActorCount > 1:
+		summary = fmt.Sprintf("%s等 %d 人%s", notification.Actor.Username, notification.ActorCount, notificationActions[notification.Type])
+	default:
+		summary = notification.Actor.Username + notificationActions[notification.Type]
+	}
+
+	return &dto.NotificationDTO{
+		ID:         notification.ID,
+		Type:       notification.Type,
+		Actor:      *userModel2InfoDto(&notification.Actor),
+		ActorCount: notification.ActorCount,
+		EventCount: notification.EventCount,
+		Summary:    summary,
+		PostID:     notification.PostID,
+		CommentID:  notification.CommentID,
+		TagID:      notification.TagID,
+		Content:    notification.Content,
+		IsRead:     notification.IsRead,
+		CreatedAt:  notification.CreatedAt,
+		UpdatedAt:  notification.UpdatedAt,
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"Nuxus/internal/res"
 	"Nuxus/internal/service"
 	"Nuxus/pkg/erru"
-	"log"
+	"errors"
+	"fmt"
+	"io"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -37,33 +39,62 @@ func (pc *PostController)ListPosts(c *gin.Context) {
 		reqDto.Size = 10
 	}
 
-	posts, total, err := pc.postService.ListPosts(&reqDto)
+	listPostsResDTO, err := pc.postService.ListPosts(c.Request.Context(), &reqDto)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	postInfos := make([]dto.PostInfoResDTO, 0, len(posts))
-	for _, post := range posts {
-		postInfos = append(postInfos, *postModel2InfoDTO(post))
+	res.OkWithData(c, listPostsResDTO)
+}
+
+// SearchPosts 全文搜索帖子，结果附带高亮片段
+func (pc *PostController) SearchPosts(c *gin.Context) {
+	var reqDto dto.SearchPostsReqDTO
+	if err := c.ShouldBindQuery(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	if reqDto.Page <= 0 {
+		reqDto.Page = 1
+	}
+	if reqDto.Size <= 0 || reqDto.Size > 50 {
+		reqDto.Size = 10
 	}
 
-	listPostsResDTO := dto.ListPostsResDTO{
-		Total: total,
-		Post:  postInfos,
+	hits, total, err := pc.postService.SearchPosts(c.Request.Context(), &reqDto)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	res.OkWithData(c, listPostsResDTO)
+	postInfos := make([]dto.PostInfoResDTO, 0, len(hits))
+	for _, hit := range hits {
+		postInfo := service.PostModel2InfoDTO(hit.Post)
+		postInfo.Highlight = &dto.SearchHighlightDTO{
+			Title:   hit.TitleHighlight,
+			Snippet: hit.Snippet,
+		}
+		postInfos = append(postInfos, *postInfo)
+	}
+
+	res.OkWithData(c, dto.ListPostsResDTO{
+		Total: total,
+		Post:  postInfos,
+	})
 }
 
 func (pc *PostController)ListPopularPosts(c *gin.Context) {
-	limit := c.Param("limit")
-	limitNum, _ := strconv.Atoi(limit)
-	if limitNum == 0 {
-		limitNum = 10
+	var reqDto dto.ListPopularPostsReqDTO
+	if err := c.ShouldBindQuery(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	if reqDto.Limit <= 0 || reqDto.Limit > 50 {
+		reqDto.Limit = 10
 	}
 
-	posts, err := pc.postService.ListPopularPosts(limitNum)
+	posts, err := pc.postService.ListPopularPosts(c.Request.Context(), &reqDto)
 	if err != nil {
 		c.Error(err)
 		return
@@ -71,7 +102,7 @@ func (pc *PostController)ListPopularPosts(c *gin.Context) {
 
 	postInfos := make([]dto.PostInfoResDTO, 0, len(posts))
 	for _, post := range posts {
-		postInfos = append(postInfos, *postModel2InfoDTO(post))
+		postInfos = append(postInfos, *service.PostModel2InfoDTO(post))
 	}
 
 	res.OkWithData(c, postInfos)
@@ -88,73 +119,27 @@ func (pc *PostController)GetPost(c *gin.Context) {
 		return
 	}
 	// log.Println("postId:", postId)
-	post, err := pc.postService.GetPostById(uint(postId))
+	postDetail, err := pc.postService.GetPostDetail(c.Request.Context(), uint(postId))
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	log.Println(post.User)
-
-	// encapsulate
-	postDetail := postModel2DetailDTO(post)
+	// 登录用户按用户去重浏览量，未登录时按 IP
+	// ClientIP 只采用 server.trustedProxies 中的代理转发的 X-Forwarded-For，客户端伪造的请求头不能用来刷浏览量
+	viewer := "ip:" + c.ClientIP()
+	if userID, ok := c.Get("userID"); ok {
+		viewer = fmt.Sprintf("user:%d", userID.(uint))
+	}
+	tagIDs := make([]uint, 0, len(postDetail.Tags))
+	for _, tag := range postDetail.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	pc.postService.RecordView(c.Request.Context(), postDetail.ID, tagIDs, viewer)
 
 	res.OkWithData(c, postDetail)
 }
 
-func postModel2InfoDTO(post *models.Post) *dto.PostInfoResDTO {
-	postInfo := &dto.PostInfoResDTO{
-		ID:    post.ID,
-		Title: post.Title,
-		Author: dto.UserInfoDTO{
-			ID:       post.User.ID,
-			UserName: post.User.Username,
-			Email:    post.User.Email,
-			Avatar:   post.User.Avatar,
-		},
-
-		ViewCount:     post.ViewCount,
-		LikeCount:     post.LikeCount,
-		CommentCount:  post.CommentCount,
-		FavoriteCount: post.FavoriteCount,
-		CreatedAt:     post.CreatedAt,
-	}
-	tags := make([]dto.TagInfoDTO, 0, len(post.Tags))
-	for _, tag := range post.Tags {
-		tags = append(tags, *tagModel2InfoDTO(tag))
-	}
-	postInfo.Tags = tags
-	return postInfo
-}
-
-func postModel2DetailDTO(post *models.Post) *dto.PostDetailResDTO {
-	postInfo := &dto.PostDetailResDTO{
-		ID:            post.ID,
-		Title:         post.Title,
-		Author:        *userModel2InfoDto(&post.User),
-		Content:       post.Content,
-		ViewCount:     post.ViewCount,
-		LikeCount:     post.LikeCount,
-		CommentCount:  post.CommentCount,
-		FavoriteCount: post.FavoriteCount,
-		CreatedAt:     post.CreatedAt,
-		UpdatedAt:     post.UpdatedAt,
-	}
-	tags := make([]dto.TagInfoDTO, 0, len(post.Tags))
-	for _, tag := range post.Tags {
-		tags = append(tags, *tagModel2InfoDTO(tag))
-	}
-	postInfo.Tags = tags
-	return postInfo
-}
-
-func tagModel2InfoDTO(tag *models.Tag) *dto.TagInfoDTO {
-	return &dto.TagInfoDTO{
-		ID:   tag.ID,
-		Name: tag.Name,
-	}
-}
-
 func (pc *PostController)CreatePost(c *gin.Context) {
 	var reqDto dto.CreatePostReqDTO
 	err := c.ShouldBindJSON(&reqDto)
@@ -165,13 +150,13 @@ func (pc *PostController)CreatePost(c *gin.Context) {
 	// log.Println(reqDto)
 	userId := c.MustGet("userID").(uint)
 
-	post, err := pc.postService.CreatePost(userId, &reqDto)
+	post, err := pc.postService.CreatePost(c.Request.Context(), userId, &reqDto)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	postDetailResDto := postModel2DetailDTO(post)
+	postDetailResDto := service.PostModel2DetailDTO(post, pc.postService.RenderPost(c.Request.Context(), post))
 
 	res.Ok(c, postDetailResDto, "创建成功")
 }
@@ -187,14 +172,15 @@ func (pc *PostController)UpdatePost(c *gin.Context) {
 		return
 	}
 	userId := c.MustGet("userID").(uint)
+	role := c.GetString("role")
 
-	post, err := pc.postService.UpdatePost(userId, uint(postId), reqDto)
+	post, err := pc.postService.UpdatePost(c.Request.Context(), userId, role, uint(postId), reqDto)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	resDto := postModel2DetailDTO(post)
+	resDto := service.PostModel2DetailDTO(post, pc.postService.RenderPost(c.Request.Context(), post))
 	res.OkWithData(c, resDto)
 }
 
@@ -206,16 +192,263 @@ func (pc *PostController)DeletePost(c *gin.Context) {
 		return
 	}
 	userId := c.MustGet("userID").(uint)
+	role := c.GetString("role")
+
+	err := pc.postService.DeletePost(c.Request.Context(), uint(postId), userId, role)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "删除成功")
+}
+
+func (pc *PostController)ArchivePost(archived bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+		if postId == 0 {
+			c.Error(erru.ErrInvalidParams)
+			return
+		}
+		userId := c.MustGet("userID").(uint)
+		role := c.GetString("role")
+
+		if err := pc.postService.ArchivePost(c.Request.Context(), userId, role, uint(postId), archived); err != nil {
+			c.Error(err)
+			return
+		}
+
+		if archived {
+			res.OkWithMsg(c, "归档成功")
+		} else {
+			res.OkWithMsg(c, "已取消归档")
+		}
+	}
+}
+
+// --------------草稿------------------------------
+
+func (pc *PostController)ListDrafts(c *gin.Context) {
+	var reqDto dto.ListDraftsReqDTO
+	if err := c.ShouldBindQuery(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	if reqDto.Page <= 0 {
+		reqDto.Page = 1
+	}
+	if reqDto.Size <= 0 || reqDto.Size > 100 {
+		reqDto.Size = 10
+	}
+	userId := c.MustGet("userID").(uint)
+
+	drafts, total, err := pc.postService.ListDrafts(c.Request.Context(), userId, reqDto.Page, reqDto.Size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resDto := dto.ListDraftsResDTO{
+		Total:  total,
+		Drafts: make([]dto.DraftInfoDTO, 0, len(drafts)),
+	}
+	for _, draft := range drafts {
+		draftInfo := dto.DraftInfoDTO{
+			ID:        draft.ID,
+			Title:     draft.Title,
+			Tags:      make([]dto.TagInfoDTO, 0, len(draft.Tags)),
+			Status:    draft.Status,
+			PublishAt: draft.PublishAt,
+			UpdatedAt: draft.UpdatedAt,
+		}
+		for _, tag := range draft.Tags {
+			draftInfo.Tags = append(draftInfo.Tags, *service.TagModel2InfoDTO(tag))
+		}
+		resDto.Drafts = append(resDto.Drafts, draftInfo)
+	}
+	res.OkWithData(c, resDto)
+}
+
+func (pc *PostController)GetDraft(c *gin.Context) {
+	draftId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if draftId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
 
-	err := pc.postService.DeletePost(uint(postId), userId)
+	draft, err := pc.postService.GetDraft(c.Request.Context(), userId, uint(draftId))
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	res.OkWithData(c, service.PostModel2DetailDTO(draft, pc.postService.RenderPost(c.Request.Context(), draft)))
+}
 
+// SaveDraft 新建（POST）或自动保存（PUT）草稿
+func (pc *PostController)SaveDraft(c *gin.Context) {
+	var reqDto dto.SaveDraftReqDTO
+	if err := c.ShouldBindJSON(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	var draftId uint64
+	if idStr := c.Param("id"); idStr != "" {
+		draftId, _ = strconv.ParseUint(idStr, 10, 32)
+		if draftId == 0 {
+			c.Error(erru.ErrInvalidParams)
+			return
+		}
+	}
+	userId := c.MustGet("userID").(uint)
+
+	draft, err := pc.postService.SaveDraft(c.Request.Context(), userId, uint(draftId), &reqDto)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	res.Ok(c, service.PostModel2DetailDTO(draft, pc.postService.RenderPost(c.Request.Context(), draft)), "保存成功")
+}
+
+func (pc *PostController)DeleteDraft(c *gin.Context) {
+	draftId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if draftId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	if err := pc.postService.DeleteDraft(c.Request.Context(), userId, uint(draftId)); err != nil {
+		c.Error(err)
+		return
+	}
 	res.OkWithMsg(c, "删除成功")
 }
 
+func (pc *PostController)PublishDraft(c *gin.Context) {
+	var reqDto dto.PublishDraftReqDTO
+	draftId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	// 请求体可以为空，此时立即发布
+	if err := c.ShouldBindJSON(&reqDto); draftId == 0 || (err != nil && !errors.Is(err, io.EOF)) {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	post, err := pc.postService.PublishDraft(c.Request.Context(), userId, uint(draftId), reqDto.PublishAt)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	msg := "发布成功"
+	if post.Status == models.PostStatusScheduled {
+		msg = "已设置定时发布"
+	}
+	res.Ok(c, service.PostModel2DetailDTO(post, pc.postService.RenderPost(c.Request.Context(), post)), msg)
+}
+
+// --------------编辑历史------------------------------
+
+func (pc *PostController)ListRevisions(c *gin.Context) {
+	var reqDto dto.ListRevisionsReqDTO
+	postId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := c.ShouldBindQuery(&reqDto); postId == 0 || err != nil {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+	role := c.GetString("role")
+
+	post, revisions, err := pc.postService.ListRevisions(c.Request.Context(), userId, role, uint(postId))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resDto := dto.ListRevisionsResDTO{
+		Current:   post.Revision,
+		Revisions: make([]dto.PostRevisionDTO, 0, len(revisions)),
+	}
+	for _, revision := range revisions {
+		resDto.Revisions = append(resDto.Revisions, *revisionModel2DTO(revision, false))
+	}
+
+	if reqDto.From != 0 && reqDto.To != 0 {
+		diff, err := pc.postService.DiffRevisions(c.Request.Context(), userId, role, uint(postId), reqDto.From, reqDto.To)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resDto.Diff = &dto.RevisionDiffDTO{
+			From:        diff.From.Version,
+			To:          diff.To.Version,
+			Title:       diff.TitleDiff,
+			Content:     diff.ContentDiff,
+			TagsAdded:   diff.TagsAdded,
+			TagsRemoved: diff.TagsRemoved,
+		}
+	}
+
+	res.OkWithData(c, resDto)
+}
+
+func (pc *PostController)GetRevision(c *gin.Context) {
+	postId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	version, _ := strconv.Atoi(c.Param("version"))
+	if postId == 0 || version <= 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+	role := c.GetString("role")
+
+	revision, err := pc.postService.GetRevision(c.Request.Context(), userId, role, uint(postId), version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithData(c, revisionModel2DTO(revision, true))
+}
+
+func (pc *PostController)RollbackPost(c *gin.Context) {
+	postId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	version, _ := strconv.Atoi(c.Param("version"))
+	if postId == 0 || version <= 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+	role := c.GetString("role")
+
+	post, err := pc.postService.RollbackPost(c.Request.Context(), userId, role, uint(postId), version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.Ok(c, service.PostModel2DetailDTO(post, pc.postService.RenderPost(c.Request.Context(), post)), "回滚成功")
+}
+
+func revisionModel2DTO(revision *models.PostRevision, withContent bool) *dto.PostRevisionDTO {
+	revisionDto := &dto.PostRevisionDTO{
+		Version:      revision.Version,
+		Title:        revision.Title,
+		Tags:         revision.Tags,
+		Editor:       *userModel2InfoDto(&revision.Editor),
+		RollbackFrom: revision.RollbackFrom,
+		CreatedAt:    revision.CreatedAt,
+	}
+	if revisionDto.Tags == nil {
+		revisionDto.Tags = make([]string, 0)
+	}
+	if withContent {
+		revisionDto.Content = revision.Content
+	}
+	return revisionDto
+}
+
 // --------------交互相关：点赞、收藏、评论------------------------------
 
 func (pc *PostController)ListComment(c *gin.Context) {
@@ -223,7 +456,13 @@ func (pc *PostController)ListComment(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
 
-	comments, total, err := pc.postService.ListComment(uint(postID), page, size)
+	// mode=tree 时按楼层返回，每个楼层内联前几条回复
+	if c.Query("mode") == "tree" {
+		pc.listCommentTree(c, uint(postID), page, size)
+		return
+	}
+
+	comments, total, err := pc.postService.ListComment(c.Request.Context(), uint(postID), page, size)
 	if err != nil {
 		c.Error(err)
 		return
@@ -242,11 +481,81 @@ func (pc *PostController)ListComment(c *gin.Context) {
 	res.OkWithData(c, resDto)
 }
 
+func (pc *PostController) listCommentTree(c *gin.Context, postID uint, page int, size int) {
+	replyLimit, _ := strconv.Atoi(c.DefaultQuery("replies", "3"))
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 50 {
+		size = 10
+	}
+	if replyLimit < 0 || replyLimit > 20 {
+		replyLimit = 3
+	}
+
+	threads, total, err := pc.postService.ListCommentTree(c.Request.Context(), postID, page, size, replyLimit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	threadsDto := make([]dto.CommentThreadDTO, 0, len(threads))
+	for _, thread := range threads {
+		replies := make([]dto.CommentInfo, 0, len(thread.Replies))
+		for _, reply := range thread.Replies {
+			replies = append(replies, *commentModel2ResDTO(reply))
+		}
+		threadsDto = append(threadsDto, dto.CommentThreadDTO{
+			CommentInfo: *commentModel2ResDTO(thread.Comment),
+			ReplyCount:  thread.ReplyCount,
+			Replies:     replies,
+		})
+	}
+
+	res.OkWithData(c, dto.ListCommentTreeResDto{
+		Total:    total,
+		Comments: threadsDto,
+	})
+}
+
+// ListReplies 按游标分页查询楼层内的回复
+func (pc *PostController) ListReplies(c *gin.Context) {
+	commentId, _ := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	cursor, _ := strconv.ParseUint(c.DefaultQuery("cursor", "0"), 10, 32)
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	if commentId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	if size <= 0 || size > 50 {
+		size = 10
+	}
+
+	replies, hasMore, err := pc.postService.ListReplies(c.Request.Context(), uint(commentId), uint(cursor), size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resDto := dto.ListRepliesResDto{
+		Replies: make([]dto.CommentInfo, 0, len(replies)),
+		HasMore: hasMore,
+	}
+	for _, reply := range replies {
+		resDto.Replies = append(resDto.Replies, *commentModel2ResDTO(reply))
+	}
+	if len(replies) > 0 {
+		resDto.NextCursor = replies[len(replies)-1].ID
+	}
+
+	res.OkWithData(c, resDto)
+}
+
 func (pc *PostController)GetUserStatus(c *gin.Context) {
 	postId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	userId := c.MustGet("userID").(uint)
 
-	liked, favorited, err := pc.postService.GetUserStatus(userId, uint(postId))
+	liked, favorited, err := pc.postService.GetUserStatus(c.Request.Context(), userId, uint(postId))
 	if err != nil {
 		c.Error(err)
 	}
@@ -265,6 +574,8 @@ func commentModel2ResDTO(comment *models.Comment) *dto.CommentInfo {
 		Content:   comment.Content,
 		Author:    *userModel2InfoDto(&comment.User),
 		ParentId:  comment.ParentID,
+		RootId:    comment.RootID,
+		Mentions:  service.MentionModels2DTO(comment.Mentions),
 		CreatedAt: comment.CreatedAt,
 	}
 }
@@ -280,7 +591,7 @@ func (pc *PostController)CreateComment(c *gin.Context) {
 	postId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	userId := c.MustGet("userID").(uint)
 
-	comment, err := pc.postService.CreateComment(&reqDto, userId, uint(postId))
+	comment, err := pc.postService.CreateComment(c.Request.Context(), &reqDto, userId, uint(postId))
 	if err != nil {
 		c.Error(err)
 		return
@@ -289,11 +600,31 @@ func (pc *PostController)CreateComment(c *gin.Context) {
 	res.OkWithData(c, resDto)
 }
 
+func (pc *PostController) UpdateComment(c *gin.Context) {
+	var reqDto dto.UpdateCommentReqDTO
+	if err := c.ShouldBindJSON(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	commentId, _ := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	userId := c.MustGet("userID").(uint)
+	role := c.GetString("role")
+
+	comment, err := pc.postService.UpdateComment(c.Request.Context(), uint(commentId), userId, role, &reqDto)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithData(c, commentModel2ResDTO(comment))
+}
+
 func (pc *PostController)DeleteComment(c *gin.Context) {
 	commentId, _ := strconv.ParseUint(c.Param("commentId"), 10, 32)
 	userId := c.MustGet("userID").(uint)
+	role := c.GetString("role")
 
-	err := pc.postService.DeleteComment(uint(commentId), userId)
+	err := pc.postService.DeleteComment(c.Request.Context(), uint(commentId), userId, role)
 	if err != nil {
 		c.Error(err)
 		return
@@ -307,7 +638,7 @@ func (pc *PostController)LikePost(c *gin.Context) {
 	postId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	userId := c.MustGet("userID").(uint)
 
-	actionState, newLikeCount, err := pc.postService.LikePost(uint(postId), userId)
+	actionState, newLikeCount, err := pc.postService.LikePost(c.Request.Context(), uint(postId), userId)
 	if err != nil {
 		c.Error(err)
 		return
@@ -325,7 +656,7 @@ func (pc *PostController)FavoritePost(c *gin.Context) {
 	postId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	userId := c.MustGet("userID").(uint)
 
-	actionState, newFavoriteCount, err := pc.postService.FavoritePost(uint(postId), userId)
+	actionState, newFavoriteCount, err := pc.postService.FavoritePost(c.Request.Context(), uint(postId), userId)
 	if err != nil {
 		c.Error(err)
 		return
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"Nuxus/internal/dto"
+	"Nuxus/internal/models"
+	"Nuxus/internal/res"
+	"Nuxus/internal/service"
+	"Nuxus/pkg/erru"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportController struct {
+	reportService *service.ReportService
+}
+
+func NewReportController(reportService *service.ReportService) *ReportController {
+	return &ReportController{
+		reportService: reportService,
+	}
+}
+
+func (rc *ReportController) CreateReport(c *gin.Context) {
+	var reqDto dto.CreateReportReqDTO
+	if err := c.ShouldBindJSON(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	report, err := rc.reportService.CreateReport(c.Request.Context(), userId, &reqDto)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.Ok(c, reportModel2InfoDTO(report), "举报已提交，我们会尽快处理")
+}
+
+// -------------------处理队列--------------------------------
+func (rc *ReportController) ListReports(c *gin.Context) {
+	var reqDto dto.ListReportsReqDTO
+	if err := c.ShouldBindQuery(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+	if reqDto.Page <= 0 {
+		reqDto.Page = 1
+	}
+	if reqDto.Size <= 0 || reqDto.Size > 100 {
+		reqDto.Size = 20
+	}
+
+	reports, total, err := rc.reportService.ListReports(c.Request.Context(), &reqDto)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	reportsDto := make([]dto.ReportInfoDTO, 0, len(reports))
+	for _, report := range reports {
+		reportsDto = append(reportsDto, *reportModel2InfoDTO(report))
+	}
+
+	res.OkWithData(c, dto.ListReportsResDTO{
+		Total:   total,
+		Reports: reportsDto,
+	})
+}
+
+func (rc *ReportController) ResolveReport(c *gin.Context) {
+	var reqDto dto.HandleReportReqDTO
+	reportId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := c.ShouldBindJSON(&reqDto); err != nil || reportId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	err := rc.reportService.ResolveReport(c.Request.Context(), userId, c.GetString("role"), uint(reportId), &reqDto)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "举报已处理")
+}
+
+func (rc *ReportController) DismissReport(c *gin.Context) {
+	var reqDto dto.HandleReportReqDTO
+	reportId, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := c.ShouldBindJSON(&reqDto); err != nil || reportId == 0 {
+		c.Error(erru.ErrInvalidParams)
+		return
+	}
+	userId := c.MustGet("userID").(uint)
+
+	err := rc.reportService.DismissReport(c.Request.Context(), userId, uint(reportId), &reqDto)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "举报已驳回")
+}
+
+func reportModel2InfoDTO(report *models.Report) *dto.ReportInfoDTO {
+	return &dto.ReportInfoDTO{
+		ID:           report.ID,
+		Reporter:     *userModel2InfoDto(&report.Reporter),
+		TargetType:   report.TargetType,
+		TargetID:     report.TargetID,
+		Reason:       report.Reason,
+		Detail:       report.Detail,
+		Status:       report.Status,
+		HandlerID:    report.HandlerID,
+		HandledAt:    report.HandledAt,
+		HandleNote:   report.HandleNote,
+		TargetHidden: report.TargetHidden,
+		CreatedAt:    report.CreatedAt,
+	}
+}
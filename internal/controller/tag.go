@@ -18,12 +18,24 @@ func NewTagController(tagService *service.TagService) *TagController {
 }
 
 func (tc *TagController) ListTags(c *gin.Context) {
-	sortedBy := c.Param("sort")
+	sortedBy := c.Query("sort")
 	if sortedBy == "" {
 		sortedBy = "post_count"
 	}
 
-	resDto, err := tc.tagService.ListTags(sortedBy)
+	resDto, err := tc.tagService.ListTags(c.Request.Context(), sortedBy)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithData(c, resDto)
+}
+
+func (tc *TagController) ListSubscribedTags(c *gin.Context) {
+	userId := c.MustGet("userID").(uint)
+
+	resDto, err := tc.tagService.ListSubscribedTags(c.Request.Context(), userId)
 	if err != nil {
 		c.Error(err)
 		return
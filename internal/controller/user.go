@@ -7,7 +7,7 @@ import (
 	"Nuxus/internal/res"
 	"Nuxus/internal/service"
 	"Nuxus/pkg/erru"
-	"path/filepath"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,13 +15,15 @@ import (
 type UserController struct {
 	userService    *service.UserService
 	accountService *service.AccountService
+	sessionService *service.SessionService
 	middleware     *middleware.MiddlewareManager
 }
 
-func NewUserController(userService *service.UserService, accountService *service.AccountService, middleware *middleware.MiddlewareManager) *UserController {
+func NewUserController(userService *service.UserService, accountService *service.AccountService, sessionService *service.SessionService, middleware *middleware.MiddlewareManager) *UserController {
 	return &UserController{
 		userService:    userService,
 		accountService: accountService,
+		sessionService: sessionService,
 		middleware:     middleware,
 	}
 }
@@ -33,8 +35,8 @@ func (uc *UserController) Register(c *gin.Context) {
 		c.Error(erru.ErrInvalidParams.Wrap(err))
 		return
 	}
-	// log.Println(reqDTO)
-	err = uc.userService.Register(&reqDTO)
+	reqDTO.Lang = c.GetHeader("Accept-Language")
+	err = uc.userService.Register(c.Request.Context(), &reqDTO)
 	if err != nil {
 		c.Error(err)
 		return
@@ -51,7 +53,7 @@ func (uc *UserController) VerifyRegister(c *gin.Context) {
 		return
 	}
 
-	err = uc.userService.VerifyRegister(&reqDto)
+	err = uc.userService.VerifyRegister(c.Request.Context(), &reqDto)
 	if err != nil {
 		c.Error(err)
 		return
@@ -66,12 +68,20 @@ func (uc *UserController) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := uc.userService.Login(&reqDTO)
+	reqDTO.ClientIP = c.ClientIP()
+	user, err := uc.userService.Login(c.Request.Context(), &reqDTO)
 	if err != nil {
 		c.Error(err)
+		return
+	}
+	// 每次登录创建一个新会话
+	sessionID, refreshToken, err := uc.sessionService.CreateSession(c.Request.Context(), user.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.Error(err)
+		return
 	}
 	//token
-	token, err := uc.middleware.GenerateToken(user.ID)
+	token, err := uc.middleware.GenerateToken(user.ID, user.Role, sessionID)
 	if err != nil {
 		c.Error(erru.New("token 生成错误"))
 		return
@@ -84,12 +94,41 @@ func (uc *UserController) Login(c *gin.Context) {
 		Avatar:   user.Avatar,
 	}
 	resDTO := dto.LoginResponseDTO{
-		User:  userInfo,
-		Token: token,
+		User:         userInfo,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(uc.middleware.AccessTokenTTL().Seconds()),
 	}
 	res.OkWithData(c, resDTO)
 }
 
+// RefreshToken 用刷新令牌换取新的访问令牌，同时轮换刷新令牌
+func (uc *UserController) RefreshToken(c *gin.Context) {
+	var reqDto dto.RefreshTokenReqDTO
+	if err := c.ShouldBindJSON(&reqDto); err != nil {
+		c.Error(erru.ErrInvalidParams.Wrap(err))
+		return
+	}
+
+	user, sessionID, refreshToken, err := uc.sessionService.RefreshSession(c.Request.Context(), reqDto.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	token, err := uc.middleware.GenerateToken(user.ID, user.Role, sessionID)
+	if err != nil {
+		c.Error(erru.New("token 生成错误"))
+		return
+	}
+
+	res.OkWithData(c, dto.RefreshTokenResDTO{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(uc.middleware.AccessTokenTTL().Seconds()),
+	})
+}
+
 func (uc *UserController) RequestReset(c *gin.Context) {
 	var reqDTO dto.RequestResetReqDTO
 	err := c.ShouldBindJSON(&reqDTO)
@@ -97,8 +136,8 @@ func (uc *UserController) RequestReset(c *gin.Context) {
 		c.Error(erru.ErrInvalidParams.Wrap(err))
 		return
 	}
-	// log.Println(reqDTO)
-	err = uc.userService.RequestReset(&reqDTO)
+	reqDTO.Lang = c.GetHeader("Accept-Language")
+	err = uc.userService.RequestReset(c.Request.Context(), &reqDTO)
 	if err != nil {
 		c.Error(err)
 		return
@@ -114,7 +153,7 @@ func (uc *UserController) VerifyReset(c *gin.Context) {
 		return
 	}
 
-	err = uc.userService.VerifyReset(&reqDto)
+	err = uc.userService.VerifyReset(c.Request.Context(), &reqDto)
 	if err != nil {
 		c.Error(err)
 		return
@@ -135,7 +174,7 @@ func userModel2InfoDto(user *models.User) *dto.UserInfoDTO {
 func (uc *UserController) GetProfile(c *gin.Context) {
 	userId := c.MustGet("userID").(uint)
 
-	user, err := uc.accountService.GetProfile(userId)
+	user, err := uc.accountService.GetProfile(c.Request.Context(), userId)
 	if err != nil {
 		c.Error(err)
 		return
@@ -155,7 +194,7 @@ func (uc *UserController) UpdateProfile(c *gin.Context) {
 
 	userId := c.MustGet("userID").(uint)
 
-	user, err := uc.accountService.UpdateProfile(userId, reqDto)
+	user, err := uc.accountService.UpdateProfile(c.Request.Context(), userId, reqDto)
 	if err != nil {
 		c.Error(err)
 		return
@@ -188,10 +227,72 @@ func userModel2ProfileDto(user *models.User) *dto.ProfileResDTO {
 			IsGenderPublic: user.IsGenderPublic,
 		},
 
+		FollowerCount:  user.FollowerCount,
+		FollowingCount: user.FollowingCount,
+
+		MutedNotifications: mutedNotifications(user),
+
 		CreatedAt: user.CreatedAt,
 	}
 }
 
+func mutedNotifications(user *models.User) []string {
+	if user.MutedNotifications == "" {
+		return []string{}
+	}
+	return strings.Split(user.MutedNotifications, ",")
+}
+
+// -------------------会话管理-----------------------------
+func (uc *UserController) ListSessions(c *gin.Context) {
+	userId := c.MustGet("userID").(uint)
+	currentId := c.GetString("sessionID")
+
+	sessions, err := uc.sessionService.ListSessions(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resDto := make([]dto.SessionInfoDTO, 0, len(sessions))
+	for _, session := range sessions {
+		resDto = append(resDto, dto.SessionInfoDTO{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentId,
+		})
+	}
+
+	res.OkWithData(c, resDto)
+}
+
+func (uc *UserController) RevokeSession(c *gin.Context) {
+	userId := c.MustGet("userID").(uint)
+	sessionId := c.Param("sessionId")
+
+	if err := uc.sessionService.RevokeSession(c.Request.Context(), userId, sessionId); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "会话已注销")
+}
+
+// RevokeOtherSessions 注销除当前设备以外的所有会话
+func (uc *UserController) RevokeOtherSessions(c *gin.Context) {
+	userId := c.MustGet("userID").(uint)
+
+	if err := uc.sessionService.RevokeOtherSessions(c.Request.Context(), userId, c.GetString("sessionID")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	res.OkWithMsg(c, "其他设备已全部下线")
+}
+
 // --------------------头像------------------
 // 处理头像上传请求
 func (uc *UserController) UpdateAvatar(c *gin.Context) {
@@ -203,15 +304,15 @@ func (uc *UserController) UpdateAvatar(c *gin.Context) {
 	}
 
 	// 2. 基础文件校验
-	// 限制大小为 5MB
+	// 限制大小为 8MB，文件类型由 Service 层根据内容判断
 	if file.Size > 8*1024*1024 {
 		_ = c.Error(erru.New("图片大小不能超过8MB"))
 		return
 	}
-	// 限制文件类型
-	ext := filepath.Ext(file.Filename)
-	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
-		_ = c.Error(erru.New("只支持上传 jpg, jpeg, png 格式的图片"))
+	// 可选的裁剪框
+	var reqDto dto.UpdateAvatarReqDTO
+	if err := c.ShouldBind(&reqDto); err != nil {
+		_ = c.Error(erru.ErrInvalidParams.Wrap(err))
 		return
 	}
 
@@ -219,12 +320,12 @@ func (uc *UserController) UpdateAvatar(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
 	// 4. 调用 Service 层处理核心逻辑
-	avatarURL, err := uc.accountService.UpdateAvatar(userID.(uint), file)
+	urls, err := uc.accountService.UpdateAvatar(c.Request.Context(), userID.(uint), file, &reqDto)
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
 	// 5. 构造并返回成功响应
-	res.OkWithData(c, dto.UpdateAvatarResDTO{AvatarURL: avatarURL})
+	res.OkWithData(c, dto.UpdateAvatarResDTO{AvatarURL: urls[service.AvatarSize], Sizes: urls})
 }
@@ -0,0 +1,62 @@
+package dao
+
+import (
+	"Nuxus/internal/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentDAO struct {
+	db *gorm.DB
+}
+
+func NewAttachmentDAO(db *gorm.DB) *AttachmentDAO {
+	return &AttachmentDAO{db: db}
+}
+
+func (a *AttachmentDAO) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	return a.db.WithContext(ctx).Create(attachment).Error
+}
+
+func (a *AttachmentDAO) GetAttachmentByUUID(ctx context.Context, uuid string) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := a.db.WithContext(ctx).Where("uuid = ?", uuid).First(&attachment).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// LinkAttachments 把用户自己上传、还没有被引用的图片关联到帖子
+func (a *AttachmentDAO) LinkAttachments(ctx context.Context, userID, postID uint, uuids []string) error {
+	return a.db.WithContext(ctx).Model(&models.Attachment{}).
+		Where("user_id = ? AND post_id = 0 AND uuid IN ?", userID, uuids).
+		Updates(map[string]interface{}{"post_id": postID, "unlinked_at": nil}).Error
+}
+
+// UnlinkAttachments 取消帖子关联的、uuid 不在 keep 中的图片，keep 为空时取消全部
+func (a *AttachmentDAO) UnlinkAttachments(ctx context.Context, postID uint, keep []string) error {
+	query := a.db.WithContext(ctx).Model(&models.Attachment{}).Where("post_id = ?", postID)
+	if len(keep) > 0 {
+		query = query.Where("uuid NOT IN ?", keep)
+	}
+	return query.Updates(map[string]interface{}{"post_id": 0, "unlinked_at": time.Now()}).Error
+}
+
+// ListOrphans 查询 before 之前上传、且在 before 之前取消关联（或从未关联）的图片
+func (a *AttachmentDAO) ListOrphans(ctx context.Context, before time.Time, limit int) ([]*models.Attachment, error) {
+	var attachments []*models.Attachment
+	err := a.db.WithContext(ctx).
+		Where("post_id = 0 AND created_at < ? AND (unlinked_at IS NULL OR unlinked_at < ?)", before, before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&attachments).Error
+	return attachments, err
+}
+
+// DeleteOrphan 删除仍未被引用的图片记录，返回是否删除；清理期间被引用的不会删除
+func (a *AttachmentDAO) DeleteOrphan(ctx context.Context, id uint) (bool, error) {
+	result := a.db.WithContext(ctx).Where("id = ? AND post_id = 0", id).Delete(&models.Attachment{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,41 @@
+package dao
+
+import (
+	"Nuxus/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type AuditDAO struct {
+	db *gorm.DB
+}
+
+func NewAuditDAO(db *gorm.DB) *AuditDAO {
+	return &AuditDAO{db: db}
+}
+
+func (a *AuditDAO) CreateAuditLog(ctx context.Context, auditLog *models.AuditLog) error {
+	return a.db.WithContext(ctx).Create(auditLog).Error
+}
+
+// ListAuditLogs 分页查询审计日志，targetType 为空时不过滤
+func (a *AuditDAO) ListAuditLogs(ctx context.Context, targetType string, page, size int) ([]*models.AuditLog, int64, error) {
+	var logs []*models.AuditLog
+	var total int64
+
+	query := a.db.WithContext(ctx).Model(&models.AuditLog{})
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := query.Order("id DESC").Offset(offset).Limit(size).Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
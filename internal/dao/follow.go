@@ -0,0 +1,158 @@
+package dao
+
+import (
+	"Nuxus/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FollowDAO struct {
+	db *gorm.DB
+}
+
+func NewFollowDAO(db *gorm.DB) *FollowDAO {
+	return &FollowDAO{db: db}
+}
+
+// FollowingAuthor 关注的作者及其粉丝数，用于区分写扩散和读扩散的作者
+type FollowingAuthor struct {
+	ID            uint
+	FollowerCount int
+}
+
+// CreateFollow 关注用户，同时维护双方的计数，返回 false 表示已经关注过
+func (f *FollowDAO) CreateFollow(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	created := false
+	err := f.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Follow{
+			FollowerID: followerID,
+			FolloweeID: followeeID,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return updateFollowCounters(tx, followerID, followeeID, 1)
+	})
+	return created, err
+}
+
+// DeleteFollow 取消关注，返回 false 表示原本就没有关注
+func (f *FollowDAO) DeleteFollow(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	deleted := false
+	err := f.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Delete(&models.Follow{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		deleted = true
+		return updateFollowCounters(tx, followerID, followeeID, -1)
+	})
+	return deleted, err
+}
+
+func updateFollowCounters(tx *gorm.DB, followerID, followeeID uint, delta int) error {
+	err := tx.Model(&models.User{}).Where("id = ?", followerID).
+		UpdateColumn("following_count", gorm.Expr("following_count + ?", delta)).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.User{}).Where("id = ?", followeeID).
+		UpdateColumn("follower_count", gorm.Expr("follower_count + ?", delta)).Error
+}
+
+func (f *FollowDAO) IsFollowing(ctx context.Context, followerID, followeeID uint) (bool, error) {
+	var count int64
+	err := f.db.WithContext(ctx).Model(&models.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListFollowers 分页查询用户的粉丝，最近关注的在前
+func (f *FollowDAO) ListFollowers(ctx context.Context, userID uint, page, size int) ([]*models.User, int64, error) {
+	query := f.db.WithContext(ctx).Model(&models.User{}).
+		Joins("JOIN follows ON follows.follower_id = users.id").
+		Where("follows.followee_id = ?", userID)
+	return listFollowUsers(query, page, size)
+}
+
+// ListFollowing 分页查询用户关注的人，最近关注的在前
+func (f *FollowDAO) ListFollowing(ctx context.Context, userID uint, page, size int) ([]*models.User, int64, error) {
+	query := f.db.WithContext(ctx).Model(&models.User{}).
+		Joins("JOIN follows ON follows.followee_id = users.id").
+		Where("follows.follower_id = ?", userID)
+	return listFollowUsers(query, page, size)
+}
+
+func listFollowUsers(query *gorm.DB, page, size int) ([]*models.User, int64, error) {
+	var users []*models.User
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * size
+	err := query.Order("follows.created_at DESC").Offset(offset).Limit(size).Find(&users).Error
+	return users, total, err
+}
+
+// ListFollowerIDs 按 ID 游标分批查询粉丝 ID，用于发帖时推送收件箱
+func (f *FollowDAO) ListFollowerIDs(ctx context.Context, userID uint, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := f.db.WithContext(ctx).Model(&models.Follow{}).
+		Where("followee_id = ? AND follower_id > ?", userID, afterID).
+		Order("follower_id ASC").
+		Limit(limit).
+		Pluck("follower_id", &ids).Error
+	return ids, err
+}
+
+// ListFollowingAuthors 查询用户关注的所有作者及其粉丝数
+func (f *FollowDAO) ListFollowingAuthors(ctx context.Context, userID uint) ([]*FollowingAuthor, error) {
+	var authors []*FollowingAuthor
+	err := f.db.WithContext(ctx).Model(&models.User{}).
+		Select("users.id, users.follower_count").
+		Joins("JOIN follows ON follows.followee_id = users.id").
+		Where("follows.follower_id = ?", userID).
+		Scan(&authors).Error
+	return authors, err
+}
+
+// -------------------标签关注--------------------------------
+// CreateTagSubscription 关注标签，返回 false 表示已经关注过
+func (f *FollowDAO) CreateTagSubscription(ctx context.Context, userID, tagID uint) (bool, error) {
+	res := f.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.TagSubscription{
+		UserID: userID,
+		TagID:  tagID,
+	})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (f *FollowDAO) DeleteTagSubscription(ctx context.Context, userID, tagID uint) error {
+	return f.db.WithContext(ctx).Where("user_id = ? AND tag_id = ?", userID, tagID).Delete(&models.TagSubscription{}).Error
+}
+
+func (f *FollowDAO) ListSubscribedTagIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := f.db.WithContext(ctx).Model(&models.TagSubscription{}).Where("user_id = ?", userID).Pluck("tag_id", &ids).Error
+	return ids, err
+}
+
+// ListTagSubscriberIDs 按 ID 游标分批查询标签的关注者
+func (f *FollowDAO) ListTagSubscriberIDs(ctx context.Context, tagID uint, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := f.db.WithContext(ctx).Model(&models.TagSubscription{}).
+		Where("tag_id = ? AND user_id > ?", tagID, afterID).
+		Order("user_id ASC").
+		Limit(limit).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,68 @@
+package dao
+
+import (
+	"Nuxus/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type MentionDAO struct {
+	db *gorm.DB
+}
+
+func NewMentionDAO(db *gorm.DB) *MentionDAO {
+	return &MentionDAO{db: db}
+}
+
+// ReplaceMentions 用新的提及替换帖子正文（commentID 为 0）或某条评论中原有的提及
+func (m *MentionDAO) ReplaceMentions(ctx context.Context, postID, commentID uint, mentions []*models.Mention) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("post_id = ? AND comment_id = ?", postID, commentID).Delete(&models.Mention{}).Error
+		if err != nil {
+			return err
+		}
+		if len(mentions) == 0 {
+			return nil
+		}
+		return tx.Create(mentions).Error
+	})
+}
+
+// ListMentionedUserIDs 查询帖子正文或某条评论中已经提及过的用户
+func (m *MentionDAO) ListMentionedUserIDs(ctx context.Context, postID, commentID uint) ([]uint, error) {
+	var userIDs []uint
+	err := m.db.WithContext(ctx).Model(&models.Mention{}).
+		Where("post_id = ? AND comment_id = ?", postID, commentID).
+		Distinct().Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+func (m *MentionDAO) ListPostMentions(ctx context.Context, postID uint) ([]*models.Mention, error) {
+	var mentions []*models.Mention
+	err := m.db.WithContext(ctx).Where("post_id = ? AND comment_id = 0", postID).Order("start ASC").Find(&mentions).Error
+	return mentions, err
+}
+
+// ListUserMentions 分页查询用户被提及的记录，已删除或被隐藏的帖子不再显示
+func (m *MentionDAO) ListUserMentions(ctx context.Context, userID uint, page, size int) ([]*models.Mention, int64, error) {
+	var mentions []*models.Mention
+	var total int64
+
+	query := m.db.WithContext(ctx).Model(&models.Mention{}).
+		Joins("JOIN posts ON posts.id = mentions.post_id AND posts.deleted_at IS NULL AND posts.status IN ?",
+			[]string{models.PostStatusPublished, models.PostStatusArchived}).
+		Where("mentions.user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := query.Order("mentions.id DESC").Offset(offset).Limit(size).
+		Preload("Actor").
+		Preload("Post", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "title")
+		}).
+		Find(&mentions).Error
+	return mentions, total, err
+}
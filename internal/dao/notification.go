@@ -0,0 +1,104 @@
+package dao
+
+import (
+	"Nuxus/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationDAO struct {
+	db *gorm.DB
+}
+
+func NewNotificationDAO(db *gorm.DB) *NotificationDAO {
+	return &NotificationDAO{db: db}
+}
+
+func (n *NotificationDAO) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	return n.db.WithContext(ctx).Create(notification).Error
+}
+
+// FindAggregatable 查找可以合并的未读通知：同一接收者、同一聚合键，且在 since 之后有过更新
+// 没有时返回 nil, nil
+func (n *NotificationDAO) FindAggregatable(ctx context.Context, userID uint, groupKey string, since time.Time) (*models.Notification, error) {
+	var notification models.Notification
+	err := n.db.WithContext(ctx).Where("user_id = ? AND group_key = ? AND is_read = ? AND updated_at >= ?", userID, groupKey, false, since).
+		Order("id DESC").
+		Take(&notification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// AddActor 记录聚合通知的参与用户，返回 false 表示该用户已经计过数
+func (n *NotificationDAO) AddActor(ctx context.Context, notificationID, userID uint) (bool, error) {
+	res := n.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.NotificationActor{
+		NotificationID: notificationID,
+		UserID:         userID,
+	})
+	return res.RowsAffected > 0, res.Error
+}
+
+// BumpNotification 把新的触发合并进已有通知，事件数加一，newActor 为 true 时参与人数也加一
+func (n *NotificationDAO) BumpNotification(ctx context.Context, id, actorID, postID uint, content string, newActor bool) error {
+	updates := map[string]any{
+		"actor_id":    actorID,
+		"post_id":     postID,
+		"content":     content,
+		"event_count": gorm.Expr("event_count + 1"),
+		"updated_at":  time.Now(),
+	}
+	if newActor {
+		updates["actor_count"] = gorm.Expr("actor_count + 1")
+	}
+	return n.db.WithContext(ctx).Model(&models.Notification{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ListNotifications 分页查询用户的通知，按最近更新时间倒序
+func (n *NotificationDAO) ListNotifications(ctx context.Context, userID uint, unreadOnly bool, page, size int) ([]*models.Notification, int64, error) {
+	var notifications []*models.Notification
+	var total int64
+
+	query := n.db.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := query.Order("updated_at DESC, id DESC").Offset(offset).Limit(size).
+		Preload("Actor").
+		Preload("Tag").
+		Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (n *NotificationDAO) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := n.db.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
+
+// MarkRead 把用户的一条通知标记为已读，返回受影响的行数
+func (n *NotificationDAO) MarkRead(ctx context.Context, userID, id uint) (int64, error) {
+	res := n.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND is_read = ?", id, userID, false).
+		Update("is_read", true)
+	return res.RowsAffected, res.Error
+}
+
+func (n *NotificationDAO) MarkAllRead(ctx context.Context, userID uint) error {
+	return n.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Update("is_read", true).Error
+}
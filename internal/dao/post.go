@@ -3,7 +3,11 @@ package dao
 import (
 	"Nuxus/internal/dto"
 	"Nuxus/internal/models"
+	"context"
 	"errors"
+	"slices"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -17,13 +21,14 @@ func NewPostDAO(db *gorm.DB) *PostDAO {
 	return &PostDAO{db: db}
 }
 
-func (p *PostDAO) ListPosts(reqDto *dto.ListPostsReqDTO) ([]*models.Post, int64, error) {
+func (p *PostDAO) ListPosts(ctx context.Context, reqDto *dto.ListPostsReqDTO) ([]*models.Post, int64, error) {
 	var posts []*models.Post
 	var total int64
 
 	// 1. 构建基础查询
 	// Preload("Tags") 是一个 GORM 的强大功能，它会高效地执行另一条查询，
-	query := p.db.Model(&models.Post{}).Preload("Tags").Preload("User")
+	query := p.db.WithContext(ctx).Model(&models.Post{}).Preload("Tags").Preload("User").
+		Where("posts.status = ?", models.PostStatusPublished)
 
 	// 2. 如果提供了 tag，则添加过滤条件
 	if reqDto.Tag != "" {
@@ -43,8 +48,8 @@ func (p *PostDAO) ListPosts(reqDto *dto.ListPostsReqDTO) ([]*models.Post, int64,
 	// 4. 然后，添加分页和排序条件
 	// Offset 计算：(页码 - 1) * 每页数量
 	offset := (reqDto.Page - 1) * reqDto.Size
-	// 按创建时间降序排序，最新的在前面
-	query = query.Offset(offset).Limit(reqDto.Size).Order("created_at DESC")
+	// 置顶帖在前，其余按创建时间降序排序，最新的在前面
+	query = query.Offset(offset).Limit(reqDto.Size).Order("posts.is_pinned DESC, posts.created_at DESC")
 
 	// 5. 执行最终查询，获取当前页的数据
 	if err := query.Find(&posts).Error; err != nil {
@@ -54,9 +59,9 @@ func (p *PostDAO) ListPosts(reqDto *dto.ListPostsReqDTO) ([]*models.Post, int64,
 	return posts, total, nil
 }
 
-func (p *PostDAO) GetPostById(id uint) (*models.Post, error) {
+func (p *PostDAO) GetPostById(ctx context.Context, id uint) (*models.Post, error) {
 	var post models.Post
-	err := p.db.Where("id=?", id).Preload("User").First(&post).Error
+	err := p.db.WithContext(ctx).Where("id=?", id).Preload("User").Preload("Tags").First(&post).Error
 	if err != nil {
 		return nil, err
 	}
@@ -64,71 +69,340 @@ func (p *PostDAO) GetPostById(id uint) (*models.Post, error) {
 	return &post, nil
 }
 
-func (p *PostDAO) AddPostViewCount(postId uint, incr int) error {
+// GetPostForUpdate 在事务中锁定帖子行，保证并发编辑时版本号依次递增
+func (p *PostDAO) GetPostForUpdate(tx *gorm.DB, id uint) (*models.Post, error) {
 	var post models.Post
-	err := p.db.Where("id=?", postId).First(&post).Error
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).Preload("Tags").First(&post).Error
 	if err != nil {
-		return err
+		return nil, err
 	}
-	post.ViewCount += incr
-	// gorm的更新设计简直是逆天
-	return p.db.Model(&post).Where("id=?", post.ID).Updates(post).Error
+	return &post, nil
 }
 
-func (p *PostDAO) GetPostsByIds(ids []string) ([]*models.Post, error) {
+// AddPostViewCounts 在一个事务中把浏览量增量累加到帖子上，每 batchSize 个帖子一条 UPDATE，
+// 直接在数据库中做加法，不会覆盖其他请求对帖子的修改
+func (p *PostDAO) AddPostViewCounts(ctx context.Context, counts map[uint]int64, batchSize int) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = len(counts)
+	}
+	ids := make([]uint, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	// 按 ID 顺序加行锁，避免和其他事务互相等待
+	slices.Sort(ids)
+
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(ids); start += batchSize {
+			batch := ids[start:min(start+batchSize, len(ids))]
+			// view_count = view_count + CASE id WHEN ? THEN ? ... END
+			var expr strings.Builder
+			args := make([]interface{}, 0, len(batch)*2)
+			expr.WriteString("view_count + CASE id")
+			for _, id := range batch {
+				expr.WriteString(" WHEN ? THEN ?")
+				args = append(args, id, counts[id])
+			}
+			expr.WriteString(" ELSE 0 END")
+			err := tx.Model(&models.Post{}).Where("id IN ?", batch).
+				UpdateColumn("view_count", gorm.Expr(expr.String(), args...)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *PostDAO) GetPostsByIds(ctx context.Context, ids []string) ([]*models.Post, error) {
 	var posts []*models.Post
-	err := p.db.Where("id IN (?)", ids).Preload("User").Find(&posts).Error
+	err := p.db.WithContext(ctx).Where("id IN (?) AND status = ?", ids, models.PostStatusPublished).Preload("Tags").Preload("User").Find(&posts).Error
 	if err != nil {
 		return nil, err
 	}
 	return posts, nil
 }
 
-func (p *PostDAO) CreatePost(post *models.Post) error {
-	return p.db.Create(post).Error
+// ListPublishTimes 查询已发布帖子的发布时间，不存在或未发布的帖子不在结果中
+func (p *PostDAO) ListPublishTimes(ctx context.Context, ids []uint) (map[uint]time.Time, error) {
+	times := make(map[uint]time.Time, len(ids))
+	if len(ids) == 0 {
+		return times, nil
+	}
+	var posts []*models.Post
+	err := p.db.WithContext(ctx).Select("id", "created_at").
+		Where("id IN ? AND status = ?", ids, models.PostStatusPublished).Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		times[post.ID] = post.CreatedAt
+	}
+	return times, nil
+}
+
+// FindPostReferencing 查询作者正文中包含 text 的任意一篇帖子或草稿，没有时返回 0
+func (p *PostDAO) FindPostReferencing(ctx context.Context, userID uint, text string) (uint, error) {
+	var ids []uint
+	err := p.db.WithContext(ctx).Model(&models.Post{}).
+		Where("user_id = ? AND content LIKE ?", userID, "%"+text+"%").
+		Limit(1).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// RankWeights 计算累计积分时浏览、点赞、评论、收藏各自的权重
+type RankWeights struct {
+	View     float64
+	Like     float64
+	Comment  float64
+	Favorite float64
+}
+
+// scoreExpr 帖子累计积分的 SQL 表达式和参数
+func (w RankWeights) scoreExpr() (string, []any) {
+	return "posts.view_count * ? + posts.like_count * ? + posts.comment_count * ? + posts.favorite_count * ?",
+		[]any{w.View, w.Like, w.Comment, w.Favorite}
+}
+
+// ListTopPostScores 按互动计数加权求出累计积分，查询积分最高的 limit 个已发布的帖子，不含积分不大于 0 的
+func (p *PostDAO) ListTopPostScores(ctx context.Context, weights RankWeights, limit int) ([]RankEntry, error) {
+	expr, args := weights.scoreExpr()
+	var entries []RankEntry
+	err := p.db.WithContext(ctx).Model(&models.Post{}).
+		Select("posts.id AS post_id, "+expr+" AS score", args...).
+		Where("posts.status = ?", models.PostStatusPublished).
+		Where(expr+" > 0", args...).
+		Order("score DESC").
+		Limit(limit).
+		Scan(&entries).Error
+	return entries, err
 }
 
-func (p *PostDAO) UpdatePost(post *models.Post) (*models.Post, error) {
-	res := p.db.Model(post).Where("id=?", post.ID).Updates(post)
+// ListTopTagPostScores 查询每个标签下累计积分最高的 limit 个已发布的帖子，键是标签 ID
+// 使用窗口函数一次取出所有标签的结果，需要 MySQL 8.0+
+func (p *PostDAO) ListTopTagPostScores(ctx context.Context, weights RankWeights, limit int) (map[uint][]RankEntry, error) {
+	expr, args := weights.scoreExpr()
+	ranked := p.db.WithContext(ctx).Model(&models.Post{}).
+		Select("post_tags.tag_id, posts.id AS post_id, "+expr+" AS score, "+
+			"ROW_NUMBER() OVER (PARTITION BY post_tags.tag_id ORDER BY "+expr+" DESC, posts.id DESC) AS rn",
+			append(append([]any{}, args...), args...)...).
+		Joins("JOIN post_tags ON post_tags.post_id = posts.id").
+		Where("posts.status = ?", models.PostStatusPublished).
+		Where(expr+" > 0", args...)
+
+	var rows []struct {
+		TagID  uint
+		PostID uint
+		Score  float64
+	}
+	err := p.db.WithContext(ctx).Table("(?) AS ranked", ranked).
+		Select("tag_id, post_id, score").
+		Where("rn <= ?", limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	boards := make(map[uint][]RankEntry)
+	for _, row := range rows {
+		boards[row.TagID] = append(boards[row.TagID], RankEntry{PostID: row.PostID, Score: row.Score})
+	}
+	return boards, nil
+}
+
+// ListPostRefsByAuthors 查询作者们在 before 及之前发布的帖子，只取 ID、作者和发布时间，用于拼装时间线
+func (p *PostDAO) ListPostRefsByAuthors(ctx context.Context, authorIDs []uint, before time.Time, limit int) ([]*models.Post, error) {
+	var posts []*models.Post
+	if len(authorIDs) == 0 {
+		return posts, nil
+	}
+	err := p.db.WithContext(ctx).Select("id", "user_id", "created_at").
+		Where("user_id IN ? AND created_at <= ? AND status = ?", authorIDs, before, models.PostStatusPublished).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&posts).Error
+	return posts, err
+}
+
+// ListPostRefsByTags 查询带有任一标签、在 before 及之前发布的帖子，只取 ID、作者和发布时间
+func (p *PostDAO) ListPostRefsByTags(ctx context.Context, tagIDs []uint, before time.Time, limit int) ([]*models.Post, error) {
+	var posts []*models.Post
+	if len(tagIDs) == 0 {
+		return posts, nil
+	}
+	tagged := p.db.WithContext(ctx).Table("post_tags").Select("post_id").Where("tag_id IN ?", tagIDs)
+	err := p.db.WithContext(ctx).Select("id", "user_id", "created_at").
+		Where("id IN (?) AND created_at <= ? AND status = ?", tagged, before, models.PostStatusPublished).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&posts).Error
+	return posts, err
+}
+
+func (p *PostDAO) CreatePost(ctx context.Context, post *models.Post) error {
+	return p.db.WithContext(ctx).Create(post).Error
+}
+
+// UpdatePost 在事务中更新帖子的标题、正文、摘要、版本信息，并用 post.Tags 整体替换原有标签
+func (p *PostDAO) UpdatePost(tx *gorm.DB, post *models.Post) error {
+	res := tx.Model(post).Where("id=?", post.ID).
+		Select("title", "content", "excerpt", "revision", "edited_at").
+		Updates(post)
 	if res.Error != nil {
-		return nil, res.Error
+		return res.Error
 	}
 	if res.RowsAffected == 0 {
-		return nil, errors.New("更新失败")
+		return errors.New("更新失败")
 	}
-	res.First(post)
-	return post, nil
+	return tx.Model(post).Association("Tags").Replace(post.Tags)
 }
 
-func (p *PostDAO) DeletePost(postId uint) error {
+func (p *PostDAO) DeletePost(ctx context.Context, postId uint) error {
 	// 关键！DB.Select(clause.Associations)
 	// 这会告诉 GORM 在删除 Post 的同时，也处理其关联数据。
 	// 对于 many2many("Tags")，GORM 会自动去删除 post_tags 表中的相关记录。
 	// 注意：这里的 Delete 是软删除，因为它会看到 gorm.DeletedAt 字段。
-	err := p.db.Select(clause.Associations).Delete(&models.Post{Model: gorm.Model{ID: postId}}).Error
+	err := p.db.WithContext(ctx).Select(clause.Associations).Delete(&models.Post{Model: gorm.Model{ID: postId}}).Error
 	return err
 }
 
+// UpdatePostFlag 更新帖子的管理状态，column 为 is_pinned / is_locked
+func (p *PostDAO) UpdatePostFlag(ctx context.Context, postID uint, column string, value bool) error {
+	return p.db.WithContext(ctx).Model(&models.Post{}).Where("id = ?", postID).Update(column, value).Error
+}
+
+// UpdatePostStatus 只有帖子当前处于 from 中的某个状态时才更新 columns，返回是否更新成功
+// 用条件更新代替先查后改，多个实例同时执行定时发布时也只会有一个成功
+func (p *PostDAO) UpdatePostStatus(ctx context.Context, postID uint, from []string, columns map[string]any) (bool, error) {
+	res := p.db.WithContext(ctx).Model(&models.Post{}).Where("id = ? AND status IN ?", postID, from).Updates(columns)
+	return res.RowsAffected > 0, res.Error
+}
+
+// ListDrafts 分页查询用户的草稿和定时发布的帖子，最近修改的在前
+func (p *PostDAO) ListDrafts(ctx context.Context, userID uint, page, size int) ([]*models.Post, int64, error) {
+	var posts []*models.Post
+	var total int64
+
+	query := p.db.WithContext(ctx).Model(&models.Post{}).
+		Where("user_id = ? AND status IN ?", userID, []string{models.PostStatusDraft, models.PostStatusScheduled})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * size
+	err := query.Order("updated_at DESC").Offset(offset).Limit(size).Preload("Tags").Find(&posts).Error
+	return posts, total, err
+}
+
+// ListDuePostIDs 查询发布时间已到的定时帖子
+func (p *PostDAO) ListDuePostIDs(ctx context.Context, now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := p.db.WithContext(ctx).Model(&models.Post{}).
+		Where("status = ? AND publish_at <= ?", models.PostStatusScheduled, now).
+		Order("publish_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
 // -----------------评论----------------------------
-func (p *PostDAO) ListComment(postID uint, page int, size int) ([]*models.Comment, int64, error) {
+func (p *PostDAO) ListComment(ctx context.Context, postID uint, page int, size int) ([]*models.Comment, int64, error) {
 	var comments []*models.Comment
 	var total int64
 
 	offset := (page - 1) * size
 
-	p.db.Model(&models.Comment{}).Where("post_id = ?", postID).Count(&total)
+	p.db.WithContext(ctx).Model(&models.Comment{}).Where("post_id = ? AND is_hidden = ?", postID, false).Count(&total)
 
 	// 查询分页数据，并预加载 User 信息以避免 N+1 查询
-	err := p.db.Where("post_id = ?", postID).
+	err := p.db.WithContext(ctx).Where("post_id = ? AND is_hidden = ?", postID, false).
 		Order("created_at ASC"). // 按创建时间升序
 		Limit(size).
 		Offset(offset).
 		Preload("User"). // 关键！预加载作者信息
+		Preload("Mentions").
 		Find(&comments).Error
 
 	return comments, total, err
 }
 
+// ListTopComments 分页查询帖子的顶级评论（楼层）
+func (p *PostDAO) ListTopComments(ctx context.Context, postID uint, page int, size int) ([]*models.Comment, int64, error) {
+	var comments []*models.Comment
+	var total int64
+
+	query := p.db.WithContext(ctx).Model(&models.Comment{}).Where("post_id = ? AND parent_id = 0 AND is_hidden = ?", postID, false)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := query.Order("id ASC").Limit(size).Offset(offset).Preload("User").Preload("Mentions").Find(&comments).Error
+	return comments, total, err
+}
+
+// ListReplyPreviews 查询每个楼层最早的 limit 条回复
+// 使用窗口函数一次取出所有楼层的回复，需要 MySQL 8.0+
+func (p *PostDAO) ListReplyPreviews(ctx context.Context, rootIDs []uint, limit int) ([]*models.Comment, error) {
+	var replies []*models.Comment
+	if len(rootIDs) == 0 {
+		return replies, nil
+	}
+
+	ranked := p.db.WithContext(ctx).Model(&models.Comment{}).
+		Select("comments.*, ROW_NUMBER() OVER (PARTITION BY root_id ORDER BY id ASC) AS rn").
+		Where("root_id IN ? AND is_hidden = ?", rootIDs, false)
+
+	err := p.db.WithContext(ctx).Table("(?) AS comments", ranked).
+		Where("rn <= ?", limit).
+		Order("id ASC").
+		Preload("User").
+		Preload("Mentions").
+		Find(&replies).Error
+	return replies, err
+}
+
+// CountReplies 统计每个楼层的回复数
+func (p *PostDAO) CountReplies(ctx context.Context, rootIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(rootIDs))
+	if len(rootIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		RootID uint
+		Count  int64
+	}
+	err := p.db.WithContext(ctx).Model(&models.Comment{}).
+		Select("root_id, COUNT(*) AS count").
+		Where("root_id IN ? AND is_hidden = ?", rootIDs, false).
+		Group("root_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.RootID] = row.Count
+	}
+	return counts, nil
+}
+
+// ListReplies 按游标查询某个楼层的回复，返回 id 大于 cursor 的前 size 条
+func (p *PostDAO) ListReplies(ctx context.Context, rootID uint, cursor uint, size int) ([]*models.Comment, error) {
+	var replies []*models.Comment
+	err := p.db.WithContext(ctx).Where("root_id = ? AND id > ? AND is_hidden = ?", rootID, cursor, false).
+		Order("id ASC").
+		Limit(size).
+		Preload("User").
+		Preload("Mentions").
+		Find(&replies).Error
+	return replies, err
+}
+
 func (p *PostDAO) CreateComment(tx *gorm.DB, comment *models.Comment) error {
 	return tx.Create(comment).Error
 }
@@ -141,21 +415,26 @@ func (p *PostDAO) UpdatePostCounter(tx *gorm.DB, postID uint, column string, amo
 		Update(column, gorm.Expr(column+" + ?", amount)).Error
 }
 
-func (p *PostDAO) GetCommentById(commentId uint) (*models.Comment, error) {
+func (p *PostDAO) GetCommentById(ctx context.Context, commentId uint) (*models.Comment, error) {
 	var comment models.Comment
-	err := p.db.Where("id=?", commentId).Preload("User").First(&comment).Error
+	err := p.db.WithContext(ctx).Where("id=?", commentId).Preload("User").First(&comment).Error
 	if err != nil {
 		return nil, err
 	}
 	return &comment, nil
 }
 
-func (p *PostDAO) DeleteComment(postId uint) error {
-	return p.db.Delete(&models.Comment{}, postId).Error
+func (p *PostDAO) DeleteComment(ctx context.Context, postId uint) error {
+	return p.db.WithContext(ctx).Delete(&models.Comment{}, postId).Error
+}
+
+// HideComment 隐藏评论
+func (p *PostDAO) HideComment(ctx context.Context, commentID uint) error {
+	return p.db.WithContext(ctx).Model(&models.Comment{}).Where("id = ?", commentID).Update("is_hidden", true).Error
 }
 
-func (p *PostDAO) UpdateComment(comment *models.Comment) error {
-	res := p.db.Model(comment).Where("id=?", comment.ID).Updates(comment)
+func (p *PostDAO) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	res := p.db.WithContext(ctx).Model(comment).Where("id=?", comment.ID).Updates(comment)
 	if res.Error != nil {
 		return res.Error
 	}
@@ -169,11 +448,11 @@ func (p *PostDAO) UpdateComment(comment *models.Comment) error {
 // --------------------点赞、收藏------------------------------
 // IsLiked 检查用户是否已点赞某帖子
 // 思路：检查中间表数量，不差出模型
-func (p *PostDAO) IsLiked(userID, postID uint) (bool, error) {
+func (p *PostDAO) IsLiked(ctx context.Context, userID, postID uint) (bool, error) {
 	var count int64
 	// 直接在中间表上执行 COUNT 查询
 	// 我们甚至不需要 .Model()，因为 Count() 不需要模型来确定表名
-	err := p.db.Table("user_post_likes"). // 使用我们之前定义的常量
+	err := p.db.WithContext(ctx).Table("user_post_likes"). // 使用我们之前定义的常量
 						Where("user_id = ? AND post_id = ?", userID, postID).
 						Count(&count).Error
 
@@ -186,9 +465,9 @@ func (p *PostDAO) IsLiked(userID, postID uint) (bool, error) {
 	return count > 0, nil
 }
 
-func (p *PostDAO) IsFavorite(userId, postId uint) (bool, error) {
+func (p *PostDAO) IsFavorite(ctx context.Context, userId, postId uint) (bool, error) {
 	var count int64
-	err := p.db.Table("user_post_favorites").
+	err := p.db.WithContext(ctx).Table("user_post_favorites").
 		Where("user_id = ? AND post_id = ?", userId, postId).
 		Count(&count).Error
 	if err != nil {
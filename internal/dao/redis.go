@@ -2,9 +2,11 @@ package dao
 
 import (
 	"Nuxus/configs"
+	"Nuxus/internal/metrics"
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,28 +16,38 @@ type RedisClient struct {
 	client *redis.Client
 }
 
-var (
-	// RedisClient *redis.Client
-	Ctx = context.Background()
-)
-
 func NewRedisClient(client *redis.Client) *RedisClient {
 	return &RedisClient{client: client}
 }
 
-func NewClient(config *configs.Config) *redis.Client {
+// NewClient 连接 Redis，返回的 cleanup 在退出时关闭连接池
+func NewClient(config *configs.Config, l *slog.Logger, m *metrics.Metrics) (*redis.Client, func(), error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     config.Redis.Addr,
 		Password: config.Redis.Password,
 		DB:       config.Redis.DB, // 0 default-DB
 		PoolSize: 10,              // conn-pool size
 	})
-	if err := client.Ping(Ctx).Err(); err != nil {
-		panic("Redis connect fail: " + err.Error())
+	client.AddHook(metrics.NewRedisHook(m))
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("connect to redis: %w", err)
+	}
+
+	l.Info("Redis connection successful!")
+	cleanup := func() {
+		if err := client.Close(); err != nil {
+			l.Error("关闭 Redis 连接池失败", "err", err)
+			return
+		}
+		l.Info("Redis 连接池已关闭")
 	}
+	return client, cleanup, nil
+}
 
-	log.Println("Redis connection successful!")
-	return client
+// Ping 检查 Redis 是否可用
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
 }
 
 // func InitRedis() {
@@ -53,72 +65,913 @@ func NewClient(config *configs.Config) *redis.Client {
 
 // 定义 Redis Keys 的前缀，方便管理
 const (
-	PrefixVerifyCode    = "nexus:verify_code:%s"   // %s 是邮箱
-	PrefixSendCooldown  = "nexus:send_cooldown:%s" // %s 是邮箱
-	PrefixPostViewCount = "nexus:post:view:%s"     // %d 是帖子 ID
-	KeyPopularPosts     = "nexus:posts:popular"    // 热门帖子的 ZSET Key
+	PrefixVerifyCode     = "nexus:verify_code:%s"          // %s 是邮箱
+	PrefixSendCooldown   = "nexus:send_cooldown:%s"        // %s 是邮箱
+	PrefixVerifyAttempts = "nexus:verify_code:attempts:%s" // %s 是邮箱，当前验证码输错的次数
+	PrefixPostViewCount  = "nexus:post:view:%s"            // %s 是帖子 ID，旧版本的浏览量计数，已改为 KeyPostViewsPending
+	KeyPopularPosts      = "nexus:posts:popular"           // 热门帖子的 ZSET Key，按时间衰减的热度，由定时任务计算
+	PrefixSession        = "nexus:session:%s"              // %s 是会话 ID，Hash 结构
+	PrefixUserSessions   = "nexus:user:sessions:%d"        // %d 是用户 ID，Set 结构，记录该用户的所有会话 ID
+	PrefixUserBanned     = "nexus:user:banned:%d"          // %d 是用户 ID，存在即表示封禁中，过期时间即封禁到期时间
+)
+
+// SetVerifyCode 保存新的验证码，同时清零旧验证码的输错次数
+func (r *RedisClient) SetVerifyCode(ctx context.Context, req_email, code string, duration time.Duration) error {
+	key := fmt.Sprintf(PrefixVerifyCode, req_email)
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, key, code, duration)
+	pipe.Del(ctx, fmt.Sprintf(PrefixVerifyAttempts, req_email))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetVerificationCode 读取验证码，不存在或已过期时返回空字符串
+func (r *RedisClient) GetVerificationCode(ctx context.Context, email string) (string, error) {
+	key := fmt.Sprintf(PrefixVerifyCode, email)
+	code, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return code, err
+}
+
+// DelVerificationCode 从 Redis 中删除验证码
+func (r *RedisClient) DelVerificationCode(ctx context.Context, email string) error {
+	return r.client.Del(ctx, fmt.Sprintf(PrefixVerifyCode, email), fmt.Sprintf(PrefixVerifyAttempts, email)).Err()
+}
+
+// IncrVerifyAttempts 记录一次验证码输错，返回当前验证码累计输错的次数
+func (r *RedisClient) IncrVerifyAttempts(ctx context.Context, email string, ttl time.Duration) (int64, error) {
+	key := fmt.Sprintf(PrefixVerifyAttempts, email)
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// CheckSendCooldown 检查发送冷却时间
+func (r *RedisClient) CheckSendCooldown(ctx context.Context, email string) (bool, error) {
+	key := fmt.Sprintf(PrefixSendCooldown, email)
+	// 使用 SetNX，如果 key 不存在则设置并返回 true，如果 key 已存在则不操作并返回 false
+	// 这利用了原子操作来避免竞态条件
+	wasSet, err := r.client.SetNX(ctx, key, 1, 60*time.Second).Result()
+	if err != nil {
+		return false, err
+	}
+	// 如果 wasSet 为 false，说明在60秒内已经发送过了
+	return !wasSet, nil
+}
+
+// ------------------会话------------------------------
+
+// SessionInfo 是保存在 Redis 中的一个登录会话（一台设备对应一个会话）
+type SessionInfo struct {
+	ID          string
+	UserID      uint
+	RefreshHash string // 当前有效刷新令牌的 SHA-256，令牌原文不落库
+	UserAgent   string
+	IP          string
+	CreatedAt   time.Time
+	LastSeenAt  time.Time
+}
+
+// rotateRefreshScript 原子地比较并替换会话中的刷新令牌哈希
+// 返回 1 表示轮换成功，0 表示哈希不匹配（旧令牌被重放），-1 表示会话不存在
+var rotateRefreshScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'refresh_hash')
+if not current then
+	return -1
+end
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'refresh_hash', ARGV[2], 'ip', ARGV[3], 'user_agent', ARGV[4], 'last_seen_at', ARGV[5])
+redis.call('EXPIRE', KEYS[1], ARGV[6])
+return 1
+`)
+
+// CreateSession 保存一个新会话，并把会话 ID 记入用户的会话集合
+func (r *RedisClient) CreateSession(ctx context.Context, session *SessionInfo, ttl time.Duration) error {
+	key := fmt.Sprintf(PrefixSession, session.ID)
+	setKey := fmt.Sprintf(PrefixUserSessions, session.UserID)
+
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, key, map[string]any{
+		"user_id":      session.UserID,
+		"refresh_hash": session.RefreshHash,
+		"user_agent":   session.UserAgent,
+		"ip":           session.IP,
+		"created_at":   session.CreatedAt.Unix(),
+		"last_seen_at": session.LastSeenAt.Unix(),
+	})
+	pipe.Expire(ctx, key, ttl)
+	pipe.SAdd(ctx, setKey, session.ID)
+	// 集合的过期时间跟随最新的会话，避免长期不登录的用户残留集合
+	pipe.Expire(ctx, setKey, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetSession 读取会话，会话不存在（已过期或被撤销）时返回 nil, nil
+func (r *RedisClient) GetSession(ctx context.Context, sessionID string) (*SessionInfo, error) {
+	key := fmt.Sprintf(PrefixSession, sessionID)
+	values, err := r.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return parseSession(sessionID, values), nil
+}
+
+// SessionState 一次往返同时检查会话是否有效、用户是否被封禁，JWTAuth 每次请求都会调用
+func (r *RedisClient) SessionState(ctx context.Context, sessionID string, userID uint) (alive bool, banned bool, err error) {
+	pipe := r.client.Pipeline()
+	sessionCmd := pipe.Exists(ctx, fmt.Sprintf(PrefixSession, sessionID))
+	bannedCmd := pipe.Exists(ctx, fmt.Sprintf(PrefixUserBanned, userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, false, err
+	}
+	return sessionCmd.Val() > 0, bannedCmd.Val() > 0, nil
+}
+
+// RotateSessionRefresh 用新的刷新令牌哈希替换旧的，返回值含义见 rotateRefreshScript
+func (r *RedisClient) RotateSessionRefresh(ctx context.Context, sessionID, oldHash, newHash, ip, userAgent string, ttl time.Duration) (int64, error) {
+	key := fmt.Sprintf(PrefixSession, sessionID)
+	return rotateRefreshScript.Run(ctx, r.client, []string{key},
+		oldHash, newHash, ip, userAgent, time.Now().Unix(), int64(ttl/time.Second)).Int64()
+}
+
+// ListUserSessions 列出用户的所有有效会话，顺便清理集合中已过期的会话 ID
+func (r *RedisClient) ListUserSessions(ctx context.Context, userID uint) ([]*SessionInfo, error) {
+	setKey := fmt.Sprintf(PrefixUserSessions, userID)
+	ids, err := r.client.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, 0, len(ids))
+	for _, id := range ids {
+		cmds = append(cmds, pipe.HGetAll(ctx, fmt.Sprintf(PrefixSession, id)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*SessionInfo, 0, len(ids))
+	var stale []any
+	for i, cmd := range cmds {
+		values := cmd.Val()
+		if len(values) == 0 {
+			stale = append(stale, ids[i])
+			continue
+		}
+		sessions = append(sessions, parseSession(ids[i], values))
+	}
+	if len(stale) > 0 {
+		r.client.SRem(ctx, setKey, stale...)
+	}
+	return sessions, nil
+}
+
+// DeleteSession 撤销用户的某个会话
+func (r *RedisClient) DeleteSession(ctx context.Context, userID uint, sessionID string) error {
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, fmt.Sprintf(PrefixSession, sessionID))
+	pipe.SRem(ctx, fmt.Sprintf(PrefixUserSessions, userID), sessionID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// DeleteUserSessions 撤销用户的所有会话，用于修改密码等场景
+func (r *RedisClient) DeleteUserSessions(ctx context.Context, userID uint) error {
+	setKey := fmt.Sprintf(PrefixUserSessions, userID)
+	ids, err := r.client.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, fmt.Sprintf(PrefixSession, id))
+	}
+	keys = append(keys, setKey)
+	return r.client.Del(ctx, keys...).Err()
+}
+
+func parseSession(sessionID string, values map[string]string) *SessionInfo {
+	userID, _ := strconv.ParseUint(values["user_id"], 10, 64)
+	createdAt, _ := strconv.ParseInt(values["created_at"], 10, 64)
+	lastSeenAt, _ := strconv.ParseInt(values["last_seen_at"], 10, 64)
+	return &SessionInfo{
+		ID:          sessionID,
+		UserID:      uint(userID),
+		RefreshHash: values["refresh_hash"],
+		UserAgent:   values["user_agent"],
+		IP:          values["ip"],
+		CreatedAt:   time.Unix(createdAt, 0),
+		LastSeenAt:  time.Unix(lastSeenAt, 0),
+	}
+}
+
+// ------------------封禁------------------------------
+
+// SetUserBanned 写入封禁标记，ttl 为 0 表示永久封禁
+func (r *RedisClient) SetUserBanned(ctx context.Context, userID uint, reason string, ttl time.Duration) error {
+	return r.client.Set(ctx, fmt.Sprintf(PrefixUserBanned, userID), reason, ttl).Err()
+}
+
+// DelUserBanned 移除封禁标记
+func (r *RedisClient) DelUserBanned(ctx context.Context, userID uint) error {
+	return r.client.Del(ctx, fmt.Sprintf(PrefixUserBanned, userID)).Err()
+}
+
+// ------------------举报------------------------------
+const (
+	PrefixReportDedup = "nexus:report:dedup:%d:%s:%d" // 用户 ID、目标类型、目标 ID，防止重复举报
+	PrefixReportRate  = "nexus:report:rate:%d"        // %d 是用户 ID，举报频率计数
 )
 
-// 封装需要的方法
-func (r *RedisClient) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
-	return r.client.Scan(ctx, cursor, match, count).Result()
+// MarkReported 标记用户已举报过某个目标，返回 false 表示窗口期内已经举报过
+func (r *RedisClient) MarkReported(ctx context.Context, userID uint, targetType string, targetID uint, window time.Duration) (bool, error) {
+	key := fmt.Sprintf(PrefixReportDedup, userID, targetType, targetID)
+	return r.client.SetNX(ctx, key, 1, window).Result()
+}
+
+// UnmarkReported 撤销举报标记，用于举报写库失败时回滚
+func (r *RedisClient) UnmarkReported(ctx context.Context, userID uint, targetType string, targetID uint) error {
+	return r.client.Del(ctx, fmt.Sprintf(PrefixReportDedup, userID, targetType, targetID)).Err()
+}
+
+// IncrReportCount 增加用户在当前窗口内的举报次数，返回增加后的次数
+func (r *RedisClient) IncrReportCount(ctx context.Context, userID uint, window time.Duration) (int64, error) {
+	key := fmt.Sprintf(PrefixReportRate, userID)
+	count, err := r.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// 第一次计数时设置窗口
+	if count == 1 {
+		r.client.Expire(ctx, key, window)
+	}
+	return count, nil
 }
 
-func (r *RedisClient) GetSet(ctx context.Context, key string, value interface{}) (string, error) {
-	return r.client.GetSet(ctx, key, value).Result()
+// ------------------通知------------------------------
+const (
+	PrefixNotifyUnread = "nexus:notify:unread:%d" // %d 是用户 ID，未读通知数缓存
+)
+
+// GetUnreadCount 读取缓存的未读通知数，缓存不存在时 ok 为 false
+func (r *RedisClient) GetUnreadCount(ctx context.Context, userID uint) (int64, bool, error) {
+	count, err := r.client.Get(ctx, fmt.Sprintf(PrefixNotifyUnread, userID)).Int64()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return count, true, nil
 }
 
-func (r *RedisClient) IncrementPostViewCount(postId uint) error {
-	key := fmt.Sprintf(PrefixPostViewCount, postId)
-	// INCR 命令：如果 key 不存在，会先创建为 0 再加 1。
-	// 所以无需担心初始化问题。
-	return r.client.Incr(Ctx, key).Err()
+func (r *RedisClient) SetUnreadCount(ctx context.Context, userID uint, count int64, ttl time.Duration) error {
+	return r.client.Set(ctx, fmt.Sprintf(PrefixNotifyUnread, userID), count, ttl).Err()
 }
 
-// 更新帖子在热门榜单上的分数
-func (r *RedisClient) IncrementPostRank(postID uint, increment float64) error {
-	key := KeyPopularPosts
-	// ZINCRBY 命令：为一个 member 增加指定的分数
-	return r.client.ZIncrBy(Ctx, key, increment, fmt.Sprint(postID)).Err()
+// DelUnreadCount 未读数发生变化时删除缓存，下次读取时重新统计
+func (r *RedisClient) DelUnreadCount(ctx context.Context, userID uint) error {
+	return r.client.Del(ctx, fmt.Sprintf(PrefixNotifyUnread, userID)).Err()
 }
 
-// GetPopularPostIDs 从榜单获取 Top N 的帖子 ID
-func (r *RedisClient) GetPopularPostIDs(limit int64) ([]string, error) {
-	key := KeyPopularPosts
+// ------------------正文渲染------------------------------
+const (
+	PrefixPostHTML = "nexus:post:html:%d:%d" // 帖子 ID、版本号，渲染后的正文（JSON）
+)
+
+// GetRenderedPost 读取缓存的渲染结果，缓存不存在时 ok 为 false
+func (r *RedisClient) GetRenderedPost(ctx context.Context, postID uint, revision int) ([]byte, bool, error) {
+	data, err := r.client.Get(ctx, fmt.Sprintf(PrefixPostHTML, postID, revision)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// SetRenderedPost 缓存渲染结果；版本号是键的一部分，编辑后自然失效，不需要主动删除
+func (r *RedisClient) SetRenderedPost(ctx context.Context, postID uint, revision int, data []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, fmt.Sprintf(PrefixPostHTML, postID, revision), data, ttl).Err()
+}
+
+// ------------------时间线------------------------------
+const (
+	PrefixFeedInbox = "nexus:feed:inbox:%d" // %d 是用户 ID，ZSet 结构，成员为帖子 ID，分数为发布时间（毫秒）
+)
+
+// FeedEntry 收件箱中的一条帖子
+type FeedEntry struct {
+	PostID uint
+	Score  int64 // 发布时间（毫秒）
+}
+
+// PushToInboxes 把帖子推送到多个用户的收件箱，并把收件箱裁剪到 maxLen 条
+func (r *RedisClient) PushToInboxes(ctx context.Context, userIDs []uint, entry FeedEntry, maxLen int64) error {
+	pipe := r.client.Pipeline()
+	for _, userID := range userIDs {
+		key := fmt.Sprintf(PrefixFeedInbox, userID)
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(entry.Score), Member: entry.PostID})
+		pipe.ZRemRangeByRank(ctx, key, 0, -maxLen-1)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// AddInboxEntries 向某个用户的收件箱批量添加帖子，用于关注后回填或重建收件箱
+func (r *RedisClient) AddInboxEntries(ctx context.Context, userID uint, entries []FeedEntry, maxLen int64) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	members := make([]redis.Z, 0, len(entries))
+	for _, entry := range entries {
+		members = append(members, redis.Z{Score: float64(entry.Score), Member: entry.PostID})
+	}
+	key := fmt.Sprintf(PrefixFeedInbox, userID)
+	pipe := r.client.Pipeline()
+	pipe.ZAdd(ctx, key, members...)
+	pipe.ZRemRangeByRank(ctx, key, 0, -maxLen-1)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RemoveInboxEntries 从收件箱中移除帖子，用于取消关注
+func (r *RedisClient) RemoveInboxEntries(ctx context.Context, userID uint, postIDs []uint) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	members := make([]any, 0, len(postIDs))
+	for _, id := range postIDs {
+		members = append(members, id)
+	}
+	return r.client.ZRem(ctx, fmt.Sprintf(PrefixFeedInbox, userID), members...).Err()
+}
+
+// ReadInbox 按发布时间倒序读取分数不大于 maxScore 的最多 limit 条帖子
+func (r *RedisClient) ReadInbox(ctx context.Context, userID uint, maxScore int64, limit int64) ([]FeedEntry, error) {
+	results, err := r.client.ZRevRangeByScoreWithScores(ctx, fmt.Sprintf(PrefixFeedInbox, userID), &redis.ZRangeBy{
+		Max:   strconv.FormatInt(maxScore, 10),
+		Min:   "-inf",
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]FeedEntry, 0, len(results))
+	for _, z := range results {
+		id, err := strconv.ParseUint(z.Member.(string), 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, FeedEntry{PostID: uint(id), Score: int64(z.Score)})
+	}
+	return entries, nil
+}
+
+func (r *RedisClient) InboxExists(ctx context.Context, userID uint) (bool, error) {
+	n, err := r.client.Exists(ctx, fmt.Sprintf(PrefixFeedInbox, userID)).Result()
+	return n > 0, err
+}
+
+// ------------------邮件队列------------------------------
+const (
+	KeyMailQueue = "nexus:mail:queue" // List 结构，待发送的邮件，LPUSH 入队、BRPOP 出队
+	KeyMailRetry = "nexus:mail:retry" // ZSet 结构，发送失败等待重试的邮件，分数为下次尝试的时间（毫秒）
+	KeyMailDead  = "nexus:mail:dead"  // List 结构，超过重试次数的邮件，最新的在前
+
+	mailDeadLimit = 1000 // 死信列表最多保留的条数
+)
+
+// requeueMailScript 把到期的重试邮件原子地移回发送队列，返回移动的条数
+var requeueMailScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, job in ipairs(due) do
+	redis.call('ZREM', KEYS[1], job)
+	redis.call('LPUSH', KEYS[2], job)
+end
+return #due
+`)
+
+func (r *RedisClient) PushMailJob(ctx context.Context, job string) error {
+	return r.client.LPush(ctx, KeyMailQueue, job).Err()
+}
+
+// PopMailJob 阻塞等待最多 timeout 取出一封待发送的邮件，超时返回空字符串
+func (r *RedisClient) PopMailJob(ctx context.Context, timeout time.Duration) (string, error) {
+	result, err := r.client.BRPop(ctx, timeout, KeyMailQueue).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	// BRPOP 返回 [key, value]
+	return result[1], nil
+}
+
+// ScheduleMailRetry 把发送失败的邮件放入重试集合，到 at 之后再移回发送队列
+func (r *RedisClient) ScheduleMailRetry(ctx context.Context, job string, at time.Time) error {
+	return r.client.ZAdd(ctx, KeyMailRetry, redis.Z{Score: float64(at.UnixMilli()), Member: job}).Err()
+}
+
+// RequeueDueMails 把最多 limit 封到期的重试邮件移回发送队列
+func (r *RedisClient) RequeueDueMails(ctx context.Context, now time.Time, limit int) (int, error) {
+	return requeueMailScript.Run(ctx, r.client, []string{KeyMailRetry, KeyMailQueue}, now.UnixMilli(), limit).Int()
+}
+
+// PushDeadMail 记录超过重试次数的邮件，只保留最近的 mailDeadLimit 条
+func (r *RedisClient) PushDeadMail(ctx context.Context, job string) error {
+	pipe := r.client.TxPipeline()
+	pipe.LPush(ctx, KeyMailDead, job)
+	pipe.LTrim(ctx, KeyMailDead, 0, mailDeadLimit-1)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ------------------防暴力破解------------------------------
+// 失败计数和锁定按范围区分，同一次登录失败会同时计入账号和 IP
+const (
+	AuthScopeAccount = "account" // 对象是小写的用户名或邮箱
+	AuthScopeIP      = "ip"
+
+	PrefixAuthFailures  = "nexus:auth:fail:%s:%s"       // 范围、对象，窗口期内的失败次数
+	PrefixAuthLock      = "nexus:auth:lock:%s:%s"       // 范围、对象，存在即表示锁定中，过期时间即解锁时间
+	PrefixAuthLockLevel = "nexus:auth:lock_level:%s:%s" // 范围、对象，近期的锁定次数，决定下次锁定的时长
+
+	authLockLevelTTL = 24 * time.Hour
+)
+
+// recordAuthFailureScript 失败次数加一，达到阈值时按锁定次数递增锁定时长并清零失败次数
+// 返回本次触发的锁定时长（毫秒），没有触发锁定返回 0
+var recordAuthFailureScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if n < tonumber(ARGV[1]) then
+	return 0
+end
+local level = redis.call('INCR', KEYS[3])
+redis.call('PEXPIRE', KEYS[3], ARGV[5])
+local lock = math.floor(math.min(tonumber(ARGV[3]) * 2 ^ (level - 1), tonumber(ARGV[4])))
+redis.call('SET', KEYS[2], 1, 'PX', lock)
+redis.call('DEL', KEYS[1])
+return lock
+`)
+
+// GetAuthLockTTL 返回锁定的剩余时间，未锁定返回 0
+func (r *RedisClient) GetAuthLockTTL(ctx context.Context, scope, subject string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, fmt.Sprintf(PrefixAuthLock, scope, subject)).Result()
+	if err != nil || ttl < 0 {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+// RecordAuthFailure 记录一次失败，窗口期内达到 threshold 次时锁定，返回触发的锁定时长
+// 第 n 次锁定持续 baseLock * 2^(n-1)，最长 maxLock
+func (r *RedisClient) RecordAuthFailure(ctx context.Context, scope, subject string, threshold int, window, baseLock, maxLock time.Duration) (time.Duration, error) {
+	keys := []string{
+		fmt.Sprintf(PrefixAuthFailures, scope, subject),
+		fmt.Sprintf(PrefixAuthLock, scope, subject),
+		fmt.Sprintf(PrefixAuthLockLevel, scope, subject),
+	}
+	ms, err := recordAuthFailureScript.Run(ctx, r.client, keys,
+		threshold, window.Milliseconds(), baseLock.Milliseconds(), maxLock.Milliseconds(), authLockLevelTTL.Milliseconds()).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// ClearAuthFailures 成功后清除失败次数和锁定次数
+func (r *RedisClient) ClearAuthFailures(ctx context.Context, scope, subject string) error {
+	return r.client.Del(ctx, fmt.Sprintf(PrefixAuthFailures, scope, subject), fmt.Sprintf(PrefixAuthLockLevel, scope, subject)).Err()
+}
+
+// ------------------限流------------------------------
+const (
+	PrefixRateLimit = "nexus:ratelimit:%s:%s" // 规则名、身份（用户 ID 或 IP），Hash 结构，令牌桶的剩余令牌数和更新时间
+)
+
+// takeTokenScript 令牌桶：按经过的时间补充令牌后尝试取走一个，时间取 Redis 服务器时间，多个实例之间不受时钟偏差影响
+// ARGV[1] 每毫秒补充的令牌数，ARGV[2] 桶容量；返回 {是否取到, 剩余令牌数}
+var takeTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+-- 桶补满之后就和不存在一样，不必再保留
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate))
+return {allowed, tostring(tokens)}
+`)
+
+// TakeToken 从令牌桶中取一个令牌，rate 是每秒补充的令牌数，返回是否取到和剩余的令牌数
+func (r *RedisClient) TakeToken(ctx context.Context, rule, identity string, rate float64, burst int) (bool, float64, error) {
+	key := fmt.Sprintf(PrefixRateLimit, rule, identity)
+	result, err := takeTokenScript.Run(ctx, r.client, []string{key}, rate/1000, burst).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", result)
+	}
+	allowed, _ := result[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprint(result[1]), 64)
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed == 1, tokens, nil
+}
+
+// ------------------浏览量------------------------------
+const (
+	KeyPostViewsPending    = "nexus:post:views:pending"    // Hash 结构，帖子 ID -> 还没写入数据库的浏览量
+	KeyPostViewsProcessing = "nexus:post:views:processing" // Hash 结构，正在写入数据库的浏览量，写入成功后才删除
+	KeyPostViewsFlushLock  = "nexus:post:views:flush_lock" // 同一时间只允许一个实例写入
+	PrefixPostViewed       = "nexus:post:viewed:%d:%s"     // %d 是帖子 ID，%s 是访客（user:<ID> 或 ip:<IP>），存在表示去重窗口内已经计过
+)
+
+// recordViewScript 去重窗口内第一次浏览时计入待写入的浏览量，返回 1 表示计入
+var recordViewScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], 1, 'NX', 'EX', ARGV[2]) then
+	redis.call('HINCRBY', KEYS[2], ARGV[1], 1)
+	return 1
+end
+return 0
+`)
+
+// movePendingViewsScript 把待写入的浏览量合并到 processing 中并清空 pending，
+// 上次没有写入成功的浏览量还留在 processing 中，会和这次的一起写入。返回 processing 中的帖子数
+var movePendingViewsScript = redis.NewScript(`
+local pending = redis.call('HGETALL', KEYS[1])
+for i = 1, #pending, 2 do
+	redis.call('HINCRBY', KEYS[2], pending[i], pending[i + 1])
+end
+redis.call('DEL', KEYS[1])
+return redis.call('HLEN', KEYS[2])
+`)
+
+// moveLegacyViewScript 把旧版本按帖子存放的浏览量移到 pending 中
+var moveLegacyViewScript = redis.NewScript(`
+local count = tonumber(redis.call('GET', KEYS[1]) or '0')
+redis.call('DEL', KEYS[1])
+if count and count > 0 then
+	redis.call('HINCRBY', KEYS[2], ARGV[1], count)
+end
+return count
+`)
+
+// releaseLockScript 只释放自己持有的锁
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RecordPostView 记录一次浏览，同一访客在 window 内重复浏览同一帖子只计一次，返回是否计入
+func (r *RedisClient) RecordPostView(ctx context.Context, postID uint, viewer string, window time.Duration) (bool, error) {
+	keys := []string{fmt.Sprintf(PrefixPostViewed, postID, viewer), KeyPostViewsPending}
+	n, err := recordViewScript.Run(ctx, r.client, keys, postID, int64(window/time.Second)).Int()
+	return n == 1, err
+}
+
+// AcquireViewFlushLock 获取写入浏览量的锁，ttl 要大于一次写入的耗时
+func (r *RedisClient) AcquireViewFlushLock(ctx context.Context, token string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, KeyPostViewsFlushLock, token, ttl).Result()
+}
+
+func (r *RedisClient) ReleaseViewFlushLock(ctx context.Context, token string) error {
+	return releaseLockScript.Run(ctx, r.client, []string{KeyPostViewsFlushLock}, token).Err()
+}
+
+// MovePendingViews 把待写入的浏览量原子地移到 processing 中，返回需要写入的帖子数
+func (r *RedisClient) MovePendingViews(ctx context.Context) (int, error) {
+	return movePendingViewsScript.Run(ctx, r.client, []string{KeyPostViewsPending, KeyPostViewsProcessing}).Int()
+}
+
+// GetProcessingViews 读取正在写入的浏览量，字段是帖子 ID
+func (r *RedisClient) GetProcessingViews(ctx context.Context) (map[string]string, error) {
+	return r.client.HGetAll(ctx, KeyPostViewsProcessing).Result()
+}
+
+// DelProcessingViews 浏览量写入数据库后删除
+func (r *RedisClient) DelProcessingViews(ctx context.Context) error {
+	return r.client.Del(ctx, KeyPostViewsProcessing).Err()
+}
+
+// MigrateLegacyViewCounts 把旧版本 nexus:post:view:<ID> 中的浏览量移到 pending 中，返回移动的 key 数
+func (r *RedisClient) MigrateLegacyViewCounts(ctx context.Context) (int, error) {
+	match := fmt.Sprintf(PrefixPostViewCount, "*")
+	prefix := fmt.Sprintf(PrefixPostViewCount, "")
+	moved := 0
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, match, 100).Result()
+		if err != nil {
+			return moved, err
+		}
+		for _, key := range keys {
+			postID := key[len(prefix):]
+			if _, err := strconv.ParseUint(postID, 10, 64); err != nil {
+				continue
+			}
+			if err := moveLegacyViewScript.Run(ctx, r.client, []string{key, KeyPostViewsPending}, postID).Err(); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+		if next == 0 {
+			return moved, nil
+		}
+		cursor = next
+	}
+}
+
+// ------------------热门榜单------------------------------
+const (
+	KeyRankAll        = "nexus:rank:all"           // ZSET，帖子累计的互动积分，由定时任务按数据库中的互动计数重建
+	PrefixRankDay     = "nexus:rank:day:%s"        // %s 是日期 20060102，ZSET，帖子当天获得的互动积分
+	KeyRankWeek       = "nexus:rank:week"          // ZSET，最近 7 天的互动积分，由定时任务汇总
+	PrefixTagRankAll  = "nexus:rank:tag:%d:all"    // %d 是标签 ID
+	PrefixTagRankDay  = "nexus:rank:tag:%d:day:%s" // %d 是标签 ID，%s 是日期
+	PrefixTagRankWeek = "nexus:rank:tag:%d:week"   // %d 是标签 ID
+	PrefixRankTags    = "nexus:rank:tags:%s"       // %s 是日期，Set 结构，当天有互动的标签 ID
+	KeyRankTagBoards  = "nexus:rank:tag-boards"    // Set 结构，有累计榜的标签 ID，重建时删除不再需要的榜单
+	keyHotPostsTmp    = "nexus:posts:popular:tmp"  // 计算热度时的临时 key，写完后替换 KeyPopularPosts
+)
+
+// 榜单的周期
+const (
+	RankPeriodHot  = "hot"  // 按时间衰减的热度
+	RankPeriodDay  = "day"  // 当天的互动积分
+	RankPeriodWeek = "week" // 最近 7 天的互动积分
+	RankPeriodAll  = "all"  // 累计的互动积分
+)
+
+// rankKey 返回榜单的 key，tagID 为 0 时是全站榜单，标签没有热度榜
+func rankKey(period string, tagID uint, day string) string {
+	switch {
+	case period == RankPeriodHot:
+		return KeyPopularPosts
+	case period == RankPeriodDay && tagID == 0:
+		return fmt.Sprintf(PrefixRankDay, day)
+	case period == RankPeriodDay:
+		return fmt.Sprintf(PrefixTagRankDay, tagID, day)
+	case period == RankPeriodWeek && tagID == 0:
+		return KeyRankWeek
+	case period == RankPeriodWeek:
+		return fmt.Sprintf(PrefixTagRankWeek, tagID)
+	case tagID == 0:
+		return KeyRankAll
+	default:
+		return fmt.Sprintf(PrefixTagRankAll, tagID)
+	}
+}
+
+// AddPostScore 给帖子加上当天的互动积分（取消互动时为负数），同时计入帖子所属标签的按天榜单，
+// 按天的榜单在 ttl 后过期。累计榜不在这里累加，由定时任务重建
+func (r *RedisClient) AddPostScore(ctx context.Context, postID uint, tagIDs []uint, score float64, day string, ttl time.Duration) error {
+	member := fmt.Sprint(postID)
+	pipe := r.client.Pipeline()
+	dayKey := rankKey(RankPeriodDay, 0, day)
+	pipe.ZIncrBy(ctx, dayKey, score, member)
+	pipe.Expire(ctx, dayKey, ttl)
+	if len(tagIDs) > 0 {
+		tagsKey := fmt.Sprintf(PrefixRankTags, day)
+		for _, tagID := range tagIDs {
+			tagDayKey := rankKey(RankPeriodDay, tagID, day)
+			pipe.ZIncrBy(ctx, tagDayKey, score, member)
+			pipe.Expire(ctx, tagDayKey, ttl)
+			pipe.SAdd(ctx, tagsKey, tagID)
+		}
+		pipe.Expire(ctx, tagsKey, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RemovePostFromRanks 从所有榜单中移除帖子，days 是还没过期的按天榜单的日期
+func (r *RedisClient) RemovePostFromRanks(ctx context.Context, postID uint, tagIDs []uint, days []string) error {
+	member := fmt.Sprint(postID)
+	tagIDs = append([]uint{0}, tagIDs...)
+	pipe := r.client.Pipeline()
+	pipe.ZRem(ctx, KeyPopularPosts, member)
+	for _, tagID := range tagIDs {
+		pipe.ZRem(ctx, rankKey(RankPeriodAll, tagID, ""), member)
+		pipe.ZRem(ctx, rankKey(RankPeriodWeek, tagID, ""), member)
+		for _, day := range days {
+			pipe.ZRem(ctx, rankKey(RankPeriodDay, tagID, day), member)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetRankedPostIDs 按分数从高到低获取榜单上前 limit 个帖子的 ID，day 只对按天的榜单有意义
+func (r *RedisClient) GetRankedPostIDs(ctx context.Context, period string, tagID uint, day string, limit int64) ([]string, error) {
 	// ZREVRANGE 命令：按分数从高到低返回指定区间的成员
-	return r.client.ZRevRange(Ctx, key, 0, limit-1).Result()
+	return r.client.ZRevRange(ctx, rankKey(period, tagID, day), 0, limit-1).Result()
 }
-func (r *RedisClient) GetPostViewCount(postId string) (int64, error) {
-	key := fmt.Sprintf(PrefixPostViewCount, postId)
-	// 如果 key 不存在，GET 会返回错误，但 Int() 会处理成 0。
-	return r.client.Get(Ctx, key).Int64()
+
+// RankEntry 榜单中的一个帖子
+type RankEntry struct {
+	PostID uint
+	Score  float64
 }
 
-func (r *RedisClient) SetVerifyCode(req_email, code string, duration time.Duration) error {
-	key := fmt.Sprintf(PrefixVerifyCode, req_email)
-	return r.client.Set(Ctx, key, code, duration).Err()
+// SumDailyScores 汇总 days 这几天帖子获得的互动积分
+func (r *RedisClient) SumDailyScores(ctx context.Context, days []string) ([]RankEntry, error) {
+	keys := make([]string, 0, len(days))
+	for _, day := range days {
+		keys = append(keys, rankKey(RankPeriodDay, 0, day))
+	}
+	members, err := r.client.ZUnionWithScores(ctx, redis.ZStore{Keys: keys}).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]RankEntry, 0, len(members))
+	for _, z := range members {
+		member, _ := z.Member.(string)
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil && id > 0 {
+			entries = append(entries, RankEntry{PostID: uint(id), Score: z.Score})
+		}
+	}
+	return entries, nil
 }
 
-func (r *RedisClient) GetVerificationCode(email string) (string, error) {
-	key := fmt.Sprintf(PrefixVerifyCode, email)
-	return r.client.Get(Ctx, key).Result()
+// ReplaceHotPosts 用新计算的热度整体替换热门榜单，读取的请求不会看到写了一半的榜单
+func (r *RedisClient) ReplaceHotPosts(ctx context.Context, entries []RankEntry) error {
+	if len(entries) == 0 {
+		return r.client.Del(ctx, KeyPopularPosts).Err()
+	}
+	members := make([]redis.Z, 0, len(entries))
+	for _, entry := range entries {
+		members = append(members, redis.Z{Score: entry.Score, Member: entry.PostID})
+	}
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, keyHotPostsTmp)
+	pipe.ZAdd(ctx, keyHotPostsTmp, members...)
+	pipe.Rename(ctx, keyHotPostsTmp, KeyPopularPosts)
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
-// DelVerificationCode 从 Redis 中删除验证码
-func (r *RedisClient) DelVerificationCode(email string) error {
-	key := fmt.Sprintf(PrefixVerifyCode, email)
-	return r.client.Del(Ctx, key).Err()
+// RebuildRankBoards 用最近 7 天（weekDays）的按天榜单汇总出周榜，去掉积分不大于 0 的帖子。
+// tagDays 要比 weekDays 多一天，这样刚刚没有互动的标签的周榜也会被清空
+func (r *RedisClient) RebuildRankBoards(ctx context.Context, weekDays, tagDays []string) error {
+	tagsKeys := make([]string, 0, len(tagDays))
+	for _, day := range tagDays {
+		tagsKeys = append(tagsKeys, fmt.Sprintf(PrefixRankTags, day))
+	}
+	members, err := r.client.SUnion(ctx, tagsKeys...).Result()
+	if err != nil {
+		return err
+	}
+	tagIDs := []uint{0}
+	for _, member := range members {
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil && id > 0 {
+			tagIDs = append(tagIDs, uint(id))
+		}
+	}
+
+	pipe := r.client.Pipeline()
+	for _, tagID := range tagIDs {
+		dayKeys := make([]string, 0, len(weekDays))
+		for _, day := range weekDays {
+			dayKeys = append(dayKeys, rankKey(RankPeriodDay, tagID, day))
+		}
+		// 所有按天的榜单都为空时 ZUNIONSTORE 会删除周榜
+		weekKey := rankKey(RankPeriodWeek, tagID, "")
+		pipe.ZUnionStore(ctx, weekKey, &redis.ZStore{Keys: dayKeys})
+		pipe.ZRemRangeByScore(ctx, weekKey, "-inf", "0")
+	}
+	_, err = pipe.Exec(ctx)
+	return err
 }
 
-// CheckSendCooldown 检查发送冷却时间
-func (r *RedisClient) CheckSendCooldown(email string) (bool, error) {
-	key := fmt.Sprintf(PrefixSendCooldown, email)
-	// 使用 SetNX，如果 key 不存在则设置并返回 true，如果 key 已存在则不操作并返回 false
-	// 这利用了原子操作来避免竞态条件
-	wasSet, err := r.client.SetNX(Ctx, key, 1, 60*time.Second).Result()
+// ReplaceAllTimeBoards 用重新计算的积分整体替换全站和各个标签的累计榜，
+// 不在 tags 中的标签的累计榜会被删除；读取的请求不会看到写了一半的榜单
+func (r *RedisClient) ReplaceAllTimeBoards(ctx context.Context, site []RankEntry, tags map[uint][]RankEntry) error {
+	oldTags, err := r.client.SMembers(ctx, KeyRankTagBoards).Result()
 	if err != nil {
-		return false, err
+		return err
 	}
-	// 如果 wasSet 为 false，说明在60秒内已经发送过了
-	return !wasSet, nil
+
+	pipe := r.client.TxPipeline()
+	replace := func(key string, entries []RankEntry) {
+		pipe.Del(ctx, key)
+		if len(entries) == 0 {
+			return
+		}
+		members := make([]redis.Z, 0, len(entries))
+		for _, entry := range entries {
+			members = append(members, redis.Z{Score: entry.Score, Member: entry.PostID})
+		}
+		pipe.ZAdd(ctx, key, members...)
+	}
+	replace(KeyRankAll, site)
+	for _, member := range oldTags {
+		id, err := strconv.ParseUint(member, 10, 64)
+		if err != nil || id == 0 {
+			continue
+		}
+		if _, ok := tags[uint(id)]; !ok {
+			pipe.Del(ctx, rankKey(RankPeriodAll, uint(id), ""))
+		}
+	}
+	pipe.Del(ctx, KeyRankTagBoards)
+	for tagID, entries := range tags {
+		replace(rankKey(RankPeriodAll, tagID, ""), entries)
+		pipe.SAdd(ctx, KeyRankTagBoards, tagID)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// ------------------帖子缓存------------------------------
+// 缓存的 key 带有版本号，失效时只需递增版本号：正在从数据库加载的旧数据会写到旧版本的 key 上，不会被读到
+const (
+	PrefixPostCacheVersion  = "nexus:cache:post:%d:version" // %d 是帖子 ID，帖子详情缓存的版本号
+	PrefixPostCache         = "nexus:cache:post:%d:v"       // %d 是帖子 ID，后面接版本号
+	KeyPostListCacheVersion = "nexus:cache:posts:version"   // 所有列表缓存共用的版本号
+	PrefixPostListCache     = "nexus:cache:posts:%s:v"      // %s 是查询条件，后面接版本号
+	cacheVersionTTL         = 24 * time.Hour                // 要大于缓存数据的有效期，版本号过期后才不会读到旧数据
+)
+
+// getVersionedCacheScript 读取版本号和对应版本的数据，返回 {版本号, 数据或 false}
+var getVersionedCacheScript = redis.NewScript(`
+local version = redis.call('GET', KEYS[1]) or '0'
+return {version, redis.call('GET', ARGV[1] .. version)}
+`)
+
+// PostCacheKeys 返回帖子详情缓存的版本号 key 和数据 key 的前缀
+func PostCacheKeys(postID uint) (string, string) {
+	return fmt.Sprintf(PrefixPostCacheVersion, postID), fmt.Sprintf(PrefixPostCache, postID)
+}
+
+// PostListCacheKeys 返回列表缓存的版本号 key 和数据 key 的前缀，query 是区分列表的查询条件
+func PostListCacheKeys(query string) (string, string) {
+	return KeyPostListCacheVersion, fmt.Sprintf(PrefixPostListCache, query)
+}
+
+// GetVersionedCache 读取当前版本的缓存，缓存不存在时 ok 为 false，写入缓存时要使用返回的版本号
+func (r *RedisClient) GetVersionedCache(ctx context.Context, versionKey, dataPrefix string) (version int64, data []byte, ok bool, err error) {
+	result, err := getVersionedCacheScript.Run(ctx, r.client, []string{versionKey}, dataPrefix).Slice()
+	if err != nil {
+		return 0, nil, false, err
+	}
+	version, _ = strconv.ParseInt(fmt.Sprint(result[0]), 10, 64)
+	value, ok := result[1].(string)
+	return version, []byte(value), ok, nil
+}
+
+// SetVersionedCache 写入指定版本的缓存
+func (r *RedisClient) SetVersionedCache(ctx context.Context, dataPrefix string, version int64, data []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, dataPrefix+strconv.FormatInt(version, 10), data, ttl).Err()
+}
+
+// BumpCacheVersions 递增版本号，使这些版本号下的缓存全部失效
+func (r *RedisClient) BumpCacheVersions(ctx context.Context, versionKeys ...string) error {
+	pipe := r.client.Pipeline()
+	for _, key := range versionKeys {
+		pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, cacheVersionTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
 }
@@ -0,0 +1,91 @@
+package dao
+
+import (
+	"Nuxus/internal/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReportDAO struct {
+	db *gorm.DB
+}
+
+func NewReportDAO(db *gorm.DB) *ReportDAO {
+	return &ReportDAO{db: db}
+}
+
+func (r *ReportDAO) CreateReport(ctx context.Context, report *models.Report) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+func (r *ReportDAO) GetReportById(ctx context.Context, id uint) (*models.Report, error) {
+	var report models.Report
+	err := r.db.WithContext(ctx).Where("id = ?", id).Preload("Reporter").First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports 分页查询举报，status、targetType 为空时不过滤
+// 待处理的举报按时间正序排列，先来先处理
+func (r *ReportDAO) ListReports(ctx context.Context, status, targetType string, page, size int) ([]*models.Report, int64, error) {
+	var reports []*models.Report
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Report{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "id DESC"
+	if status == models.ReportStatusPending {
+		order = "id ASC"
+	}
+	offset := (page - 1) * size
+	err := query.Preload("Reporter").Order(order).Offset(offset).Limit(size).Find(&reports).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return reports, total, nil
+}
+
+// HasPendingReport 判断用户对同一目标是否还有未处理的举报
+func (r *ReportDAO) HasPendingReport(ctx context.Context, reporterID uint, targetType string, targetID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Report{}).
+		Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?",
+			reporterID, targetType, targetID, models.ReportStatusPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListPendingByTarget 查询同一目标下所有待处理的举报
+func (r *ReportDAO) ListPendingByTarget(ctx context.Context, targetType string, targetID uint) ([]*models.Report, error) {
+	var reports []*models.Report
+	err := r.db.WithContext(ctx).Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportStatusPending).
+		Preload("Reporter").
+		Find(&reports).Error
+	return reports, err
+}
+
+// HandleReports 批量更新举报的处理结果，只会更新仍处于待处理状态的记录
+func (r *ReportDAO) HandleReports(ctx context.Context, ids []uint, status string, handlerID uint, note string, targetHidden bool) error {
+	return r.db.WithContext(ctx).Model(&models.Report{}).
+		Where("id IN ? AND status = ?", ids, models.ReportStatusPending).
+		Updates(map[string]any{
+			"status":        status,
+			"handler_id":    handlerID,
+			"handled_at":    time.Now(),
+			"handle_note":   note,
+			"target_hidden": targetHidden,
+		}).Error
+}
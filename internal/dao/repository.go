@@ -2,8 +2,13 @@ package dao
 
 import (
 	"Nuxus/configs"
+	"Nuxus/internal/logger"
+	"Nuxus/internal/metrics"
 	"Nuxus/internal/models"
-	"log"
+	"Nuxus/pkg/markdown"
+	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"gorm.io/driver/mysql"
@@ -22,35 +27,127 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func NewDB(config *configs.Config) *gorm.DB {
+// NewDB 连接数据库并执行迁移，返回的 cleanup 在退出时关闭连接池
+func NewDB(config *configs.Config, l *slog.Logger, m *metrics.Metrics) (*gorm.DB, func(), error) {
 	// 读取配置
 	dsn := config.MySQL.DSN
 
 	// 连接数据库
-	var err error
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.NewGormLogger(l)})
 	if err != nil {
-		log.Fatalf("Connect to mysql err: %v", err)
+		return nil, nil, fmt.Errorf("connect to mysql: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	// 后面的步骤出错时也要关闭已经建立的连接
+	fail := func(step string, err error) (*gorm.DB, func(), error) {
+		sqlDB.Close()
+		return nil, nil, fmt.Errorf("%s: %w", step, err)
+	}
+
+	if err := db.Use(metrics.NewGormPlugin(m)); err != nil {
+		return fail("register metrics plugin", err)
 	}
 
 	// 自动迁移
-	err = db.AutoMigrate(&models.User{}, &models.Post{}, &models.Tag{}, &models.Comment{})
+	err = db.AutoMigrate(&models.User{}, &models.Post{}, &models.Tag{}, &models.Comment{}, &models.AuditLog{}, &models.Report{},
+		&models.Notification{}, &models.NotificationActor{}, &models.Mention{},
+		&models.Follow{}, &models.TagSubscription{}, &models.PostRevision{}, &models.Attachment{})
 	if err != nil {
-		log.Fatalf("Failed to auto migrate err: %v", err)
+		return fail("auto migrate", err)
+	}
+
+	// 旧的隐藏标记迁移到帖子状态
+	if err := migratePostHidden(db); err != nil {
+		return fail("migrate posts.is_hidden", err)
+	}
+
+	// 为引入摘要之前的帖子生成摘要
+	if err := backfillPostExcerpts(db); err != nil {
+		return fail("backfill post excerpts", err)
+	}
+
+	// 补齐旧评论数据的楼层 ID
+	if err := backfillCommentRoots(db); err != nil {
+		return fail("backfill comment root_id", err)
 	}
 
 	// 配置数据库连接池
 	// 连接池可以提高数据库访问性能并控制资源使用
-	sqlDB, err := db.DB()
-	if err != nil {
-		panic(err)
-	}
 	sqlDB.SetMaxIdleConns(10)           // 设置空闲连接池中连接的最大数量
 	sqlDB.SetMaxOpenConns(100)          // 设置打开数据库连接的最大数量
 	sqlDB.SetConnMaxLifetime(time.Hour) // 设置连接可复用的最大时间
 
-	log.Println("Database connection and migration successful!")
-	return db
+	l.Info("Database connection and migration successful!")
+	cleanup := func() {
+		if err := sqlDB.Close(); err != nil {
+			l.Error("关闭数据库连接池失败", "err", err)
+			return
+		}
+		l.Info("数据库连接池已关闭")
+	}
+	return db, cleanup, nil
+}
+
+// migratePostHidden 把引入帖子状态之前的 is_hidden 标记迁移到 status 列，迁移完成后删除旧列
+func migratePostHidden(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Post{}, "is_hidden") {
+		return nil
+	}
+	err := db.Model(&models.Post{}).Where("is_hidden = ?", true).
+		Update("status", models.PostStatusHidden).Error
+	if err != nil {
+		return err
+	}
+	return db.Migrator().DropColumn(&models.Post{}, "is_hidden")
+}
+
+// backfillPostExcerpts 为摘要为空的帖子生成摘要，正文本身为空的帖子每次启动都会被重新检查，开销可以忽略
+func backfillPostExcerpts(db *gorm.DB) error {
+	var posts []*models.Post
+	res := db.Select("id", "content").Where("excerpt = ?", "").
+		FindInBatches(&posts, 200, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				excerpt := markdown.Excerpt(post.Content, models.PostExcerptLen)
+				if excerpt == "" {
+					continue
+				}
+				err := db.Model(&models.Post{}).Where("id = ?", post.ID).UpdateColumn("excerpt", excerpt).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	return res.Error
+}
+
+// backfillCommentRoots 为引入 root_id 之前的回复补齐楼层 ID
+// 每一轮只能补齐父评论已经有楼层的回复，所以循环到没有可更新的行为止，重复执行是安全的
+func backfillCommentRoots(db *gorm.DB) error {
+	for range 10 {
+		res := db.Exec(`UPDATE comments c JOIN comments p ON c.parent_id = p.id
+			SET c.root_id = IF(p.root_id = 0, p.id, p.root_id)
+			WHERE c.parent_id <> 0 AND c.root_id = 0 AND (p.parent_id = 0 OR p.root_id <> 0)`)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Ping 检查数据库是否可用
+func (r *Repository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
 }
 
 // DB 获取数据库连接实例
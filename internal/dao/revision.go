@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"Nuxus/internal/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type RevisionDAO struct {
+	db *gorm.DB
+}
+
+func NewRevisionDAO(db *gorm.DB) *RevisionDAO {
+	return &RevisionDAO{db: db}
+}
+
+func (r *RevisionDAO) CreateRevision(tx *gorm.DB, revision *models.PostRevision) error {
+	return tx.Create(revision).Error
+}
+
+func (r *RevisionDAO) CountRevisions(tx *gorm.DB, postID uint) (int64, error) {
+	var count int64
+	err := tx.Model(&models.PostRevision{}).Where("post_id = ?", postID).Count(&count).Error
+	return count, err
+}
+
+// ListRevisions 查询帖子的所有版本，最新的在前；列表不需要正文，不查 content
+func (r *RevisionDAO) ListRevisions(ctx context.Context, postID uint) ([]*models.PostRevision, error) {
+	var revisions []*models.PostRevision
+	err := r.db.WithContext(ctx).Omit("content").
+		Where("post_id = ?", postID).
+		Order("version DESC").
+		Preload("Editor").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *RevisionDAO) GetRevision(ctx context.Context, postID uint, version int) (*models.PostRevision, error) {
+	var revision models.PostRevision
+	err := r.db.WithContext(ctx).Where("post_id = ? AND version = ?", postID, version).Preload("Editor").First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
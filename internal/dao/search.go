@@ -0,0 +1,100 @@
+package dao
+
+import (
+	"Nuxus/internal/dto"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/utils"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 搜索摘要的长度（字符数）
+const searchSnippetWidth = 120
+
+// SearchHit 一条搜索结果
+type SearchHit struct {
+	Post           *models.Post
+	TitleHighlight string // 高亮后的标题，已做 HTML 转义
+	Snippet        string // 正文中命中位置附近的高亮摘要，已做 HTML 转义
+}
+
+// SearchBackend 帖子搜索后端
+// 目前基于 MySQL 全文索引实现，以后可以替换为 Elasticsearch 等外部搜索引擎
+type SearchBackend interface {
+	SearchPosts(ctx context.Context, reqDto *dto.SearchPostsReqDTO) ([]*SearchHit, int64, error)
+}
+
+// MySQLSearchBackend 基于 MySQL FULLTEXT 索引（ngram 分词）的搜索实现
+type MySQLSearchBackend struct {
+	db *gorm.DB
+}
+
+func NewMySQLSearchBackend(db *gorm.DB) *MySQLSearchBackend {
+	return &MySQLSearchBackend{db: db}
+}
+
+const matchAgainst = "MATCH(posts.title, posts.content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+func (m *MySQLSearchBackend) SearchPosts(ctx context.Context, reqDto *dto.SearchPostsReqDTO) ([]*SearchHit, int64, error) {
+	var posts []*models.Post
+	var total int64
+
+	query := m.db.WithContext(ctx).Model(&models.Post{}).
+		Where(matchAgainst, reqDto.Query).
+		Where("posts.status = ?", models.PostStatusPublished)
+
+	// 标签过滤：必须同时包含所有指定的标签
+	if len(reqDto.Tags) > 0 {
+		tagged := m.db.WithContext(ctx).Table("post_tags").
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name IN ?", reqDto.Tags).
+			Group("post_tags.post_id").
+			Having("COUNT(DISTINCT tags.id) = ?", len(reqDto.Tags))
+		query = query.Where("posts.id IN (?)", tagged)
+	}
+	if reqDto.AuthorID != 0 {
+		query = query.Where("posts.user_id = ?", reqDto.AuthorID)
+	}
+	if !reqDto.From.IsZero() {
+		query = query.Where("posts.created_at >= ?", reqDto.From)
+	}
+	if !reqDto.To.IsZero() {
+		// 截止日期包含当天
+		query = query.Where("posts.created_at < ?", reqDto.To.AddDate(0, 0, 1))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	switch reqDto.Sort {
+	case dto.SearchSortNewest:
+		query = query.Order("posts.created_at DESC")
+	case dto.SearchSortLikes:
+		query = query.Order("posts.like_count DESC, posts.created_at DESC")
+	default:
+		query = query.Order(clause.Expr{SQL: matchAgainst + " DESC", Vars: []any{reqDto.Query}})
+	}
+
+	offset := (reqDto.Page - 1) * reqDto.Size
+	err := query.Offset(offset).Limit(reqDto.Size).
+		Preload("Tags").Preload("User").
+		Find(&posts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keywords := utils.SplitKeywords(reqDto.Query)
+	hits := make([]*SearchHit, 0, len(posts))
+	for _, post := range posts {
+		hits = append(hits, &SearchHit{
+			Post:           post,
+			TitleHighlight: utils.Highlight(post.Title, keywords),
+			Snippet:        utils.Snippet(post.Content, keywords, searchSnippetWidth),
+		})
+	}
+	return hits, total, nil
+}
@@ -3,6 +3,7 @@ package dao
 import (
 	"Nuxus/internal/dto"
 	"Nuxus/internal/models"
+	"context"
 
 	"gorm.io/gorm"
 )
@@ -15,17 +16,19 @@ func NewTagDAO(db *gorm.DB) *TagDAO {
 	return &TagDAO{db: db}
 }
 
-func (t *TagDAO) ListTags(sortedBy string) ([]*dto.ListTagsResDTO, error) {
+func (t *TagDAO) ListTags(ctx context.Context, sortedBy string) ([]*dto.ListTagsResDTO, error) {
 	var results []*dto.ListTagsResDTO
 
-	query := t.db.Model(&models.Tag{}).
-		Select("tags.id, tags.name, count(post_tags.tag_id) as post_count").
+	query := t.db.WithContext(ctx).Model(&models.Tag{}).
+		Select("tags.id, tags.name, count(post_tags.tag_id) as post_count, " + subscriberCountColumn).
 		Joins("LEFT JOIN post_tags ON tags.id = post_tags.tag_id").
 		Group("tags.id, tags.name")
 
 	switch sortedBy {
 	case "post_count":
 		query = query.Order("post_count DESC")
+	case "subscriber_count":
+		query = query.Order("subscriber_count DESC")
 	case "name":
 		query = query.Order("tags.name ASC")
 	default:
@@ -39,18 +42,34 @@ func (t *TagDAO) ListTags(sortedBy string) ([]*dto.ListTagsResDTO, error) {
 	return results, nil
 }
 
-func (t *TagDAO) FindOrCreateTagByName(name string) (*models.Tag, error) {
+// 标签关注人数，使用相关子查询避免和帖子数的 JOIN 互相放大
+const subscriberCountColumn = "(SELECT COUNT(*) FROM tag_subscriptions WHERE tag_subscriptions.tag_id = tags.id) AS subscriber_count"
+
+// ListSubscribedTags 查询用户关注的标签，最近关注的在前
+func (t *TagDAO) ListSubscribedTags(ctx context.Context, userID uint) ([]*dto.ListTagsResDTO, error) {
+	var results []*dto.ListTagsResDTO
+	err := t.db.WithContext(ctx).Model(&models.Tag{}).
+		Select("tags.id, tags.name, "+
+			"(SELECT COUNT(*) FROM post_tags WHERE post_tags.tag_id = tags.id) AS post_count, "+
+			subscriberCountColumn).
+		Joins("JOIN tag_subscriptions AS ts ON ts.tag_id = tags.id AND ts.user_id = ?", userID).
+		Order("ts.created_at DESC").
+		Scan(&results).Error
+	return results, err
+}
+
+func (t *TagDAO) FindOrCreateTagByName(ctx context.Context, name string) (*models.Tag, error) {
 	var tag models.Tag
-	if err := t.db.Where(models.Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+	if err := t.db.WithContext(ctx).Where(models.Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
 		return nil, err
 	}
 	return &tag, nil
 }
 
-func (t *TagDAO) FindOrCreateTagsByNames(names []string) ([]*models.Tag, error) {
+func (t *TagDAO) FindOrCreateTagsByNames(ctx context.Context, names []string) ([]*models.Tag, error) {
 	tags := make([]*models.Tag, 0, len(names))
 	for _, name := range names {
-		tag, err := t.FindOrCreateTagByName(name)
+		tag, err := t.FindOrCreateTagByName(ctx, name)
 		if err != nil {
 			return nil, err
 		}
@@ -58,3 +77,49 @@ func (t *TagDAO) FindOrCreateTagsByNames(names []string) ([]*models.Tag, error)
 	}
 	return tags, nil
 }
+
+func (t *TagDAO) GetTagById(ctx context.Context, id uint) (*models.Tag, error) {
+	var tag models.Tag
+	if err := t.db.WithContext(ctx).Where("id = ?", id).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (t *TagDAO) GetTagByName(ctx context.Context, name string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := t.db.WithContext(ctx).Where("name = ?", name).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (t *TagDAO) RenameTag(ctx context.Context, id uint, name string) error {
+	return t.db.WithContext(ctx).Model(&models.Tag{}).Where("id = ?", id).Update("name", name).Error
+}
+
+// MergeTags 把 sourceID 标签下的帖子全部并入 targetID，然后删除 sourceID
+func (t *TagDAO) MergeTags(ctx context.Context, sourceID, targetID uint) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 已经同时拥有两个标签的帖子会与主键冲突，IGNORE 掉即可
+		err := tx.Exec("INSERT IGNORE INTO post_tags (post_id, tag_id) SELECT post_id, ? FROM post_tags WHERE tag_id = ?",
+			targetID, sourceID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM post_tags WHERE tag_id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		// 关注了源标签的用户转为关注目标标签
+		err = tx.Exec("INSERT IGNORE INTO tag_subscriptions (user_id, tag_id, created_at) SELECT user_id, ?, created_at FROM tag_subscriptions WHERE tag_id = ?",
+			targetID, sourceID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM tag_subscriptions WHERE tag_id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		// 标签名有唯一索引，软删除会占住名字，这里必须物理删除
+		return tx.Unscoped().Delete(&models.Tag{}, sourceID).Error
+	})
+}
@@ -2,7 +2,9 @@ package dao
 
 import (
 	"Nuxus/internal/models"
+	"context"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,59 +17,133 @@ func NewUserDAO(db *gorm.DB) *UserDAO {
 	return &UserDAO{db: db}
 }
 
-func (u *UserDAO) GetUserByEmail(email string) (*models.User, error) {
+func (u *UserDAO) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := u.db.Where("email=?", email).First(&user).Error
+	err := u.db.WithContext(ctx).Where("email=?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (u *UserDAO) GetUserById(userId uint) (*models.User, error) {
+func (u *UserDAO) GetUserById(ctx context.Context, userId uint) (*models.User, error) {
 	var user models.User
-	err := u.db.Where("id=?", userId).First(&user).Error
+	err := u.db.WithContext(ctx).Where("id=?", userId).First(&user).Error
 	return &user, err
 }
 
-func (u *UserDAO) CreateUser(user *models.User) (*models.User, error) {
-	err := u.db.Create(user).Error
+func (u *UserDAO) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	err := u.db.WithContext(ctx).Create(user).Error
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (u *UserDAO) GetUserByIdentifier(identifier string) (*models.User, error) {
+func (u *UserDAO) GetUserByIdentifier(ctx context.Context, identifier string) (*models.User, error) {
 	var user models.User
 	var err error
 
 	// 施展探查法术：检查是否包含“@”符文
 	if strings.Contains(identifier, "@") {
-		err = u.db.Where("email = ?", identifier).First(&user).Error
+		err = u.db.WithContext(ctx).Where("email = ?", identifier).First(&user).Error
 	} else {
-		err = u.db.Where("username = ?", identifier).First(&user).Error
+		err = u.db.WithContext(ctx).Where("username = ?", identifier).First(&user).Error
 	}
 
 	return &user, err
 }
 
-func (u *UserDAO) UpdateUserPassword(id uint, password string) error {
-	return u.db.Model(&models.User{}).Where("id=?", id).Update("password", password).Error
+// UpdateUserPassword 更新密码，同时清除管理员设置的强制重置标记
+func (u *UserDAO) UpdateUserPassword(ctx context.Context, id uint, password string) error {
+	return u.db.WithContext(ctx).Model(&models.User{}).Where("id=?", id).Updates(map[string]any{
+		"password":            password,
+		"must_reset_password": false,
+	}).Error
 }
 
-func (u *UserDAO) UpdateProfile(user *models.User) (*models.User, error) {
-	err := u.db.Model(user).Where("id=?", user.ID).Updates(user).Error
+func (u *UserDAO) UpdateProfile(ctx context.Context, user *models.User) (*models.User, error) {
+	err := u.db.WithContext(ctx).Model(user).Where("id=?", user.ID).Updates(user).Error
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
+// GetUsersByUsernames 批量按用户名查询用户
+func (u *UserDAO) GetUsersByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	var users []*models.User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := u.db.WithContext(ctx).Where("username IN ?", usernames).Find(&users).Error
+	return users, err
+}
+
+// UpdateMutedNotifications 更新用户屏蔽的通知类型，muted 为逗号分隔的类型列表
+func (u *UserDAO) UpdateMutedNotifications(ctx context.Context, userID uint, muted string) error {
+	return u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("muted_notifications", muted).Error
+}
+
 // ------------------头像--------------------------------
 // UpdateUserAvatar 更新指定用户的头像 URL
-func (u *UserDAO) UpdateUserAvatar(userID uint, avatarURL string) error {
+func (u *UserDAO) UpdateUserAvatar(ctx context.Context, userID uint, avatarURL string) error {
 	// 使用 Model 和 Where 来定位用户，并用 Update 更新单个字段
 	// 这是最高效的方式
-	return u.db.Model(&models.User{}).Where("id = ?", userID).Update("avatar", avatarURL).Error
+	return u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("avatar", avatarURL).Error
+}
+
+// ------------------管理--------------------------------
+// ListUsers 按条件分页查询用户，keyword 同时匹配用户名和邮箱
+func (u *UserDAO) ListUsers(ctx context.Context, keyword, role string, bannedOnly bool, page, size int) ([]*models.User, int64, error) {
+	var users []*models.User
+	var total int64
+
+	query := u.db.WithContext(ctx).Model(&models.User{})
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("username LIKE ? OR email LIKE ?", like, like)
+	}
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if bannedOnly {
+		query = query.Where("banned_at IS NOT NULL AND (ban_expires_at IS NULL OR ban_expires_at > ?)", time.Now())
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := query.Order("id DESC").Offset(offset).Limit(size).Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (u *UserDAO) UpdateUserRole(ctx context.Context, userID uint, role string) error {
+	return u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error
+}
+
+// BanUser 封禁用户，expiresAt 为 nil 表示永久封禁
+func (u *UserDAO) BanUser(ctx context.Context, userID uint, reason string, expiresAt *time.Time) error {
+	return u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"banned_at":      time.Now(),
+		"ban_expires_at": expiresAt,
+		"ban_reason":     reason,
+	}).Error
+}
+
+func (u *UserDAO) UnbanUser(ctx context.Context, userID uint) error {
+	return u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Updates(map[string]any{
+		"banned_at":      nil,
+		"ban_expires_at": nil,
+		"ban_reason":     "",
+	}).Error
+}
+
+func (u *UserDAO) SetMustResetPassword(ctx context.Context, userID uint, must bool) error {
+	return u.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("must_reset_password", must).Error
 }
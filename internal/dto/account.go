@@ -25,6 +25,11 @@ type ProfileResDTO struct {
 
 	Privacy PrivacyInfo `json:"privacy"`
 
+	FollowerCount  int `json:"follower_count"`
+	FollowingCount int `json:"following_count"`
+
+	MutedNotifications []string `json:"muted_notifications"` // 屏蔽的通知类型
+
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -37,4 +42,7 @@ type ProfileReqDTO struct {
 	Bio    string `json:"bio" binding:"omitempty,max=200"`
 
 	Privacy PrivacyInfo `json:"privacy" binding:"required"`
+
+	// 不传表示不修改，传空数组表示取消所有屏蔽
+	MutedNotifications []string `json:"muted_notifications" binding:"omitempty,dive,oneof=comment reply like favorite mention tag_post"`
 }
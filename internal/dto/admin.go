@@ -0,0 +1,67 @@
+package dto
+
+import "time"
+
+// -------------------用户管理--------------------------------
+type AdminListUsersReqDTO struct {
+	Keyword string `form:"keyword"` // 模糊匹配用户名或邮箱
+	Role    string `form:"role" binding:"omitempty,oneof=user moderator admin"`
+	Banned  bool   `form:"banned"` // 只看封禁中的用户
+	Page    int    `form:"page,default=1"`
+	Size    int    `form:"size,default=20"`
+}
+
+type AdminUserInfoDTO struct {
+	ID                uint       `json:"id"`
+	Username          string     `json:"username"`
+	Email             string     `json:"email"`
+	Role              string     `json:"role"`
+	Avatar            string     `json:"avatar"`
+	Banned            bool       `json:"banned"`
+	BanReason         string     `json:"ban_reason"`
+	BanExpiresAt      *time.Time `json:"ban_expires_at"`
+	MustResetPassword bool       `json:"must_reset_password"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type AdminListUsersResDTO struct {
+	Total int64              `json:"total"`
+	Users []AdminUserInfoDTO `json:"users"`
+}
+
+type UpdateUserRoleReqDTO struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+type BanUserReqDTO struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+	// 为空表示永久封禁
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// -------------------标签管理--------------------------------
+type RenameTagReqDTO struct {
+	Name string `json:"name" binding:"required,min=1,max=50"`
+}
+
+type MergeTagReqDTO struct {
+	TargetID uint `json:"target_id" binding:"required"`
+}
+
+// -------------------审计日志--------------------------------
+type AuditLogDTO struct {
+	ID            uint      `json:"id"`
+	ActorID       uint      `json:"actor_id"`
+	ActorRole     string    `json:"actor_role"`
+	Action        string    `json:"action"`
+	TargetType    string    `json:"target_type"`
+	TargetID      uint      `json:"target_id"`
+	TargetOwnerID uint      `json:"target_owner_id"`
+	Detail        string    `json:"detail"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ListAuditLogsResDTO struct {
+	Total int64         `json:"total"`
+	Logs  []AuditLogDTO `json:"logs"`
+}
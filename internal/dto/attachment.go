@@ -0,0 +1,21 @@
+package dto
+
+// ImageVariantDTO 图片的一个缩放版本
+type ImageVariantDTO struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// UploadImageResDTO 定义了上传配图成功后的响应格式
+// 正文中引用 url（或任一 variants 的 url）即可，保存帖子时会自动关联
+type UploadImageResDTO struct {
+	ID          uint               `json:"id"`
+	URL         string             `json:"url"`
+	ContentType string             `json:"content_type"`
+	Size        int64              `json:"size"`
+	Width       int                `json:"width"`
+	Height      int                `json:"height"`
+	Variants    []*ImageVariantDTO `json:"variants"`
+}
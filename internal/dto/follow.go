@@ -0,0 +1,12 @@
+package dto
+
+type ListFollowUsersResDTO struct {
+	Total int64         `json:"total"`
+	Users []UserInfoDTO `json:"users"`
+}
+
+type FeedResDTO struct {
+	Posts      []PostInfoResDTO `json:"posts"`
+	NextCursor string           `json:"next_cursor"` // 为空表示没有更多
+	HasMore    bool             `json:"has_more"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+// HealthResDTO 健康检查结果，Checks 中每个依赖的值为 ok 或 fail
+type HealthResDTO struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+type ListNotificationsReqDTO struct {
+	UnreadOnly bool `form:"unread_only"`
+	Page       int  `form:"page,default=1"`
+	Size       int  `form:"size,default=20"`
+}
+
+type NotificationDTO struct {
+	ID         uint        `json:"id"`
+	Type       string      `json:"type"` // comment / reply / like / favorite / mention / tag_post
+	Actor      UserInfoDTO `json:"actor"`
+	ActorCount int         `json:"actor_count"` // 聚合的用户数
+	EventCount int         `json:"event_count"` // 聚合的事件数
+	Summary    string      `json:"summary"`     // 例如“张三等 13 人赞了你的帖子”
+	PostID     uint        `json:"post_id"`
+	CommentID  uint        `json:"comment_id"`
+	TagID      uint        `json:"tag_id"`
+	Content    string      `json:"content"`
+	IsRead     bool        `json:"is_read"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type ListNotificationsResDTO struct {
+	Total         int64             `json:"total"`
+	Unread        int64             `json:"unread"`
+	Notifications []NotificationDTO `json:"notifications"`
+}
+
+type UnreadCountResDTO struct {
+	Unread int64 `json:"unread"`
+}
@@ -10,13 +10,21 @@ type ListPostsReqDTO struct {
 	Size int    `form:"size,default=10"`
 }
 
+// ListPopularPostsReqDTO 热门榜单，period 为 hot（按时间衰减的热度）、day、week、all，
+// 指定 tag 时只看该标签的榜单，标签没有 hot 榜单，默认看 week
+type ListPopularPostsReqDTO struct {
+	Period string `form:"period" binding:"omitempty,oneof=hot day week all"`
+	Tag    string `form:"tag"`
+	Limit  int    `form:"limit,default=10"`
+}
+
 type ListPostsResDTO struct {
 	Total int64            `json:"total"`
-	Post  []PostInfoResDTO `json:posts`
+	Post  []PostInfoResDTO `json:"posts"`
 }
 
 type PostInfoResDTO struct {
-	ID            uint         `json:id`
+	ID            uint         `json:"id"`
 	Title         string       `json:"title"`
 	Author        UserInfoDTO  `json:"author"` // 关联作者信息
 	Tags          []TagInfoDTO `json:"tags"`
@@ -24,27 +32,46 @@ type PostInfoResDTO struct {
 	LikeCount     int          `json:"like_count"`
 	CommentCount  int          `json:"comment_count"`
 	FavoriteCount int          `json:"favorite_count"`
+	Excerpt       string       `json:"excerpt"` // 正文开头的纯文本
 	CreatedAt     time.Time    `json:"created_at"`
+
+	Highlight *SearchHighlightDTO `json:"highlight,omitempty"` // 仅搜索结果返回
 }
 
 type PostDetailResDTO struct {
-	ID            uint         `json:id`
+	ID            uint         `json:"id"`
 	Title         string       `json:"title"`
-	Content       string       `json:"content"`
-	Author        UserInfoDTO  `json:"author"` // 关联作者信息
+	Content       string       `json:"content"` // 原始 Markdown
+	HTML          string       `json:"html"`    // 渲染并过滤后的 HTML
+	TOC           []TOCItemDTO `json:"toc"`     // 按标题生成的目录
+	Author        UserInfoDTO  `json:"author"`  // 关联作者信息
 	Tags          []TagInfoDTO `json:"tags"`
 	ViewCount     int          `json:"view_count"`
 	LikeCount     int          `json:"like_count"`
 	CommentCount  int          `json:"comment_count"`
 	FavoriteCount int          `json:"favorite_count"`
+	Mentions      []MentionDTO `json:"mentions"`
+	Status        string       `json:"status"`
+	PublishAt     *time.Time   `json:"publish_at,omitempty"` // 定时发布的时间
+	Revision      int          `json:"revision"`             // 当前版本号
+	Edited        bool         `json:"edited"`               // 发布后是否编辑过
+	EditedAt      *time.Time   `json:"edited_at,omitempty"`  // 最后一次编辑的时间
 	CreatedAt     time.Time    `json:"created_at"`
 	UpdatedAt     time.Time    `json:"updated_at"`
 }
 
+// TOCItemDTO 目录项，ID 对应 HTML 中标题的 id 属性
+type TOCItemDTO struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
 type CreatePostReqDTO struct {
-	Title   string   `json:"title" binding:"required,min=3"`
-	Content string   `json:"content" binding:"required,min=5"`
-	Tags    []string `json:"tags"`
+	Title     string     `json:"title" binding:"required,min=3"`
+	Content   string     `json:"content" binding:"required,min=5"`
+	Tags      []string   `json:"tags"`
+	PublishAt *time.Time `json:"publish_at"` // 晚于当前时间时定时发布，否则立即发布
 }
 
 type UpdatePostReqDTO struct {
@@ -53,6 +80,94 @@ type UpdatePostReqDTO struct {
 	Tags    []string `json:"tags"`
 }
 
+// -------------------草稿--------------------------------
+// SaveDraftReqDTO 自动保存草稿，内容可以不完整，发布时再校验
+type SaveDraftReqDTO struct {
+	Title   string   `json:"title" binding:"max=100"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+type PublishDraftReqDTO struct {
+	PublishAt *time.Time `json:"publish_at"` // 晚于当前时间时定时发布，否则立即发布
+}
+
+type ListDraftsReqDTO struct {
+	Page int `form:"page,default=1"`
+	Size int `form:"size,default=10"`
+}
+
+type DraftInfoDTO struct {
+	ID        uint         `json:"id"`
+	Title     string       `json:"title"`
+	Tags      []TagInfoDTO `json:"tags"`
+	Status    string       `json:"status"`
+	PublishAt *time.Time   `json:"publish_at,omitempty"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type ListDraftsResDTO struct {
+	Total  int64          `json:"total"`
+	Drafts []DraftInfoDTO `json:"drafts"`
+}
+
+// -------------------编辑历史--------------------------------
+// ListRevisionsReqDTO from、to 都不为 0 时，在版本列表之外返回两个版本的差异
+type ListRevisionsReqDTO struct {
+	From int `form:"from" binding:"omitempty,min=1"`
+	To   int `form:"to" binding:"omitempty,min=1"`
+}
+
+type PostRevisionDTO struct {
+	Version      int         `json:"version"`
+	Title        string      `json:"title"`
+	Content      string      `json:"content,omitempty"` // 版本列表中不返回正文
+	Tags         []string    `json:"tags"`
+	Editor       UserInfoDTO `json:"editor"`
+	RollbackFrom int         `json:"rollback_from,omitempty"` // 由回滚产生时，回滚到的版本号
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// RevisionDiffDTO 两个版本之间的差异，标题和正文为 unified diff 格式，没有变化时为空字符串
+type RevisionDiffDTO struct {
+	From        int      `json:"from"`
+	To          int      `json:"to"`
+	Title       string   `json:"title"`
+	Content     string   `json:"content"`
+	TagsAdded   []string `json:"tags_added"`
+	TagsRemoved []string `json:"tags_removed"`
+}
+
+type ListRevisionsResDTO struct {
+	Current   int               `json:"current"`
+	Revisions []PostRevisionDTO `json:"revisions"`
+	Diff      *RevisionDiffDTO  `json:"diff,omitempty"`
+}
+
+// -------------------搜索--------------------------------
+const (
+	SearchSortRelevance = "relevance"
+	SearchSortNewest    = "newest"
+	SearchSortLikes     = "likes"
+)
+
+type SearchPostsReqDTO struct {
+	Query    string    `form:"q" binding:"required,min=1,max=100"`
+	Tags     []string  `form:"tags"`      // 需要同时包含的标签
+	AuthorID uint      `form:"author_id"` // 作者 ID
+	From     time.Time `form:"from" time_format:"2006-01-02"`
+	To       time.Time `form:"to" time_format:"2006-01-02"`
+	Sort     string    `form:"sort" binding:"omitempty,oneof=relevance newest likes"`
+	Page     int       `form:"page,default=1"`
+	Size     int       `form:"size,default=10"`
+}
+
+// SearchHighlightDTO 搜索结果的高亮片段，命中的关键词用 <em> 包裹，其余内容已做 HTML 转义
+type SearchHighlightDTO struct {
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"`
+}
+
 // -------------------评论--------------------------------
 type ListCommentResDto struct {
 	Total    int64         `json:"total"`
@@ -60,11 +175,31 @@ type ListCommentResDto struct {
 }
 
 type CommentInfo struct {
-	Id        uint        `json:"id"`
-	Content   string      `json:"content"`
-	Author    UserInfoDTO `json:"author"`
-	ParentId  uint        `json:"parent_id"`
-	CreatedAt time.Time   `json:"created_at"`
+	Id        uint         `json:"id"`
+	Content   string       `json:"content"`
+	Author    UserInfoDTO  `json:"author"`
+	ParentId  uint         `json:"parent_id"`
+	RootId    uint         `json:"root_id"`
+	Mentions  []MentionDTO `json:"mentions"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// CommentThreadDTO 楼层：顶级评论及其前几条回复
+type CommentThreadDTO struct {
+	CommentInfo
+	ReplyCount int64         `json:"reply_count"`
+	Replies    []CommentInfo `json:"replies"`
+}
+
+type ListCommentTreeResDto struct {
+	Total    int64              `json:"total"`
+	Comments []CommentThreadDTO `json:"comments"`
+}
+
+type ListRepliesResDto struct {
+	Replies    []CommentInfo `json:"replies"`
+	NextCursor uint          `json:"next_cursor"`
+	HasMore    bool          `json:"has_more"`
 }
 
 type CreateCommentReqDTO struct {
@@ -72,6 +207,35 @@ type CreateCommentReqDTO struct {
 	ParentId uint   `json:"parent_id"`
 }
 
+type UpdateCommentReqDTO struct {
+	Content string `json:"content" binding:"required,min=1,max=500"`
+}
+
+// -------------------提及--------------------------------
+// MentionDTO 内容中的一处 @提及，Start、End 按字符（Unicode 码点）计，用于前端渲染链接
+type MentionDTO struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// UserMentionDTO 我被提及的记录
+type UserMentionDTO struct {
+	ID        uint        `json:"id"`
+	Actor     UserInfoDTO `json:"actor"`
+	PostID    uint        `json:"post_id"`
+	PostTitle string      `json:"post_title"`
+	CommentID uint        `json:"comment_id"` // 为 0 表示在帖子正文中
+	Excerpt   string      `json:"excerpt"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type ListMentionsResDTO struct {
+	Total    int64            `json:"total"`
+	Mentions []UserMentionDTO `json:"mentions"`
+}
+
 // ---------------------点赞、收藏---------------------------------
 // ToggleActionResDTO 用于点赞/收藏操作的统一响应
 type ToggleActionResDTO struct {
@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+type CreateReportReqDTO struct {
+	TargetType string `json:"target_type" binding:"required,oneof=post comment user"`
+	TargetID   uint   `json:"target_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required,oneof=spam abuse illegal other"`
+	Detail     string `json:"detail" binding:"omitempty,max=500"`
+}
+
+type ListReportsReqDTO struct {
+	Status     string `form:"status,default=pending" binding:"omitempty,oneof=pending resolved dismissed"`
+	TargetType string `form:"target_type" binding:"omitempty,oneof=post comment user"`
+	Page       int    `form:"page,default=1"`
+	Size       int    `form:"size,default=20"`
+}
+
+type ReportInfoDTO struct {
+	ID           uint        `json:"id"`
+	Reporter     UserInfoDTO `json:"reporter"`
+	TargetType   string      `json:"target_type"`
+	TargetID     uint        `json:"target_id"`
+	Reason       string      `json:"reason"`
+	Detail       string      `json:"detail"`
+	Status       string      `json:"status"`
+	HandlerID    uint        `json:"handler_id"`
+	HandledAt    *time.Time  `json:"handled_at"`
+	HandleNote   string      `json:"handle_note"`
+	TargetHidden bool        `json:"target_hidden"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+type ListReportsResDTO struct {
+	Total   int64           `json:"total"`
+	Reports []ReportInfoDTO `json:"reports"`
+}
+
+type HandleReportReqDTO struct {
+	// 只在确认举报时有效：是否隐藏被举报的帖子或评论
+	HideTarget bool   `json:"hide_target"`
+	Note       string `json:"note" binding:"omitempty,max=500"`
+}
@@ -6,7 +6,8 @@ type TagInfoDTO struct {
 }
 
 type ListTagsResDTO struct {
-	ID        uint   `json:"id"`
-	Name      string `json:"name"`
-	PostCount int64  `json:"post_count"`
+	ID              uint   `json:"id"`
+	Name            string `json:"name"`
+	PostCount       int64  `json:"post_count"`
+	SubscriberCount int64  `json:"subscriber_count"`
 }
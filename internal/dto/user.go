@@ -1,15 +1,18 @@
 package dto
 
+import "time"
+
 type RegisterReqDTO struct {
 	Email string `json:"email" binding:"required,email"`
 	// Password string `json:"password" binding:"required,min=6,max=15"`
+	Lang string `json:"-"` // 邮件语言，取自 Accept-Language 请求头
 }
 
 type VerifyRegisterReqDTO struct {
 	Email    string `json:"email" binding:"required,email"`
 	UserName string `json:"username" binding:"required,min=1,max=20"`
 	Password string `json:"password" binding:"required,min=6,max=15"`
-	Code     string `json:code binding:"required,len=6"`
+	Code     string `json:"code" binding:"required,len=6"`
 }
 
 type LoginReqDTO struct {
@@ -17,6 +20,12 @@ type LoginReqDTO struct {
 	// Email    string `json:"email" binding:"required,email"`
 	// UserName string `json:"username" binding:"required,min=1,max=20"`
 	Password string `json:"password" binding:"required,min=6,max=15"`
+	ClientIP string `json:"-"` // 用于按 IP 统计失败次数
+}
+
+// RetryAfterDTO 因尝试次数过多被暂时锁定时返回，retry_after 是需要等待的秒数
+type RetryAfterDTO struct {
+	RetryAfter int `json:"retry_after"`
 }
 
 // RegisterResponseDTO 定义了注册成功后返回的数据结构（不含密码）
@@ -28,23 +37,57 @@ type UserInfoDTO struct {
 }
 
 type LoginResponseDTO struct {
-	User  UserInfoDTO `json:"user"`
-	Token string      `json:"token"`
+	User         UserInfoDTO `json:"user"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresIn    int64       `json:"expires_in"` // 访问令牌剩余有效秒数
+}
+
+// -------------------令牌、会话-----------------------------
+type RefreshTokenReqDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type RefreshTokenResDTO struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type SessionInfoDTO struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"` // 是否为发起请求的会话
 }
 
 type RequestResetReqDTO struct {
 	Email string `json:"email" binding:"required,email"`
+	Lang  string `json:"-"` // 邮件语言，取自 Accept-Language 请求头
 }
 
 type VerifyResetReqDTO struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6,max=15"`
-	Code     string `json:code binding:"required,len=6"`
+	Code     string `json:"code" binding:"required,len=6"`
 }
 
 //	--------------------头像----------------------
 //
+// UpdateAvatarReqDTO 头像上传表单中的裁剪框，坐标相对于图片左上角（按 EXIF 方向旋转后）
+// 不传时取整张图片；裁剪后的区域不是正方形时再从中间裁成正方形
+type UpdateAvatarReqDTO struct {
+	CropX      *int `form:"crop_x" binding:"omitempty,min=0"`
+	CropY      *int `form:"crop_y" binding:"omitempty,min=0"`
+	CropWidth  *int `form:"crop_width" binding:"omitempty,min=1"`
+	CropHeight *int `form:"crop_height" binding:"omitempty,min=1"`
+}
+
 // UpdateAvatarResDTO 定义了更新头像成功后的响应格式
+// avatar_url 是保存到资料中的最大尺寸，sizes 是各尺寸（边长，像素）的地址
 type UpdateAvatarResDTO struct {
-	AvatarURL string `json:"avatar_url"`
+	AvatarURL string         `json:"avatar_url"`
+	Sizes     map[int]string `json:"sizes"`
 }
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold 超过这个耗时的 SQL 记为慢查询
+const slowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger 把 GORM 的日志转到 slog，DAO 通过 db.WithContext(ctx) 执行的 SQL 会带上请求 ID
+// 出错的 SQL 记为 error，慢查询记为 warn，其余只在 debug 级别输出
+type GormLogger struct {
+	logger *slog.Logger
+}
+
+func NewGormLogger(l *slog.Logger) *GormLogger {
+	return &GormLogger{logger: l}
+}
+
+// LogMode 日志级别由 slog 控制，这里不做处理
+func (g *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return g
+}
+
+func (g *GormLogger) Info(ctx context.Context, msg string, data ...any) {
+	g.logger.InfoContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (g *GormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	g.logger.WarnContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (g *GormLogger) Error(ctx context.Context, msg string, data ...any) {
+	g.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	// 查不到记录是正常的业务分支，由调用方处理
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		g.logger.ErrorContext(ctx, "SQL 执行失败", "sql", sql, "rows", rows, "elapsed", elapsed, "err", err)
+	case elapsed > slowQueryThreshold:
+		sql, rows := fc()
+		g.logger.WarnContext(ctx, "慢查询", "sql", sql, "rows", rows, "elapsed", elapsed)
+	case g.logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		g.logger.DebugContext(ctx, "SQL", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
@@ -0,0 +1,86 @@
+// Package logger 基于 slog 的结构化日志，请求 ID 和用户 ID 通过 context 传递，
+// 使用 slog.*Context 系列方法记录的日志会自动带上这两个字段
+package logger
+
+import (
+	"Nuxus/configs"
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// 日志格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	userIDKey
+)
+
+// New 根据配置创建日志，同时设为 slog 和标准库 log 的默认输出，
+// 还在使用 log.Printf 的地方也会以同样的格式输出
+func New(config *configs.Config) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Log.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if strings.EqualFold(config.Log.Format, FormatJSON) {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	l := slog.New(&contextHandler{Handler: handler})
+	slog.SetDefault(l)
+	return l
+}
+
+// WithRequestID 把请求 ID 放入 context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID 取出 context 中的请求 ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUserID 把当前登录的用户 ID 放入 context
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// contextHandler 从 context 中取出请求 ID 和用户 ID 加到每条日志上
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if userID, ok := ctx.Value(userIDKey).(uint); ok {
+			r.AddAttrs(slog.Uint64("user_id", uint64(userID)))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
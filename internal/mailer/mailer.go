@@ -0,0 +1,43 @@
+// Package mailer 邮件的发送方式和模板，发送方式可以是 SMTP、写入本地文件或只打印日志
+package mailer
+
+import (
+	"Nuxus/configs"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// 发送方式
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+	DriverLog  = "log"
+)
+
+// Message 一封渲染好的邮件，同时带有 HTML 和纯文本两种正文
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// Mailer 发送邮件
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewMailer 根据配置创建 Mailer
+func NewMailer(config *configs.Config, logger *slog.Logger) (Mailer, error) {
+	switch config.Mail.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(&config.SMTP), nil
+	case DriverFile:
+		return NewFileMailer(config.Mail.OutboxDir, &config.SMTP)
+	case DriverLog:
+		return &LogMailer{logger: logger}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", config.Mail.Driver)
+	}
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"Nuxus/configs"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer 把邮件写成 .eml 文件，不真正发送，用于开发和测试环境查看邮件内容
+type FileMailer struct {
+	dir  string
+	conf *configs.SMTPConfig
+}
+
+func NewFileMailer(dir string, conf *configs.SMTPConfig) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir, conf: conf}, nil
+}
+
+func (f *FileMailer) Send(ctx context.Context, msg *Message) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), uuid.New().String()[:8])
+	file, err := os.Create(filepath.Join(f.dir, name))
+	if err != nil {
+		return err
+	}
+	if _, err := newMessage(f.conf, msg).WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LogMailer 只把邮件的纯文本内容打印到日志
+type LogMailer struct {
+	logger *slog.Logger
+}
+
+func (l *LogMailer) Send(ctx context.Context, msg *Message) error {
+	l.logger.InfoContext(ctx, "邮件", "to", msg.To, "subject", msg.Subject, "text", msg.Text)
+	return nil
+}
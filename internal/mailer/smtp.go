@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"Nuxus/configs"
+	"context"
+
+	"gopkg.in/gomail.v2"
+)
+
+// SMTPMailer 通过 SMTP 服务器发送邮件
+type SMTPMailer struct {
+	conf   *configs.SMTPConfig
+	dialer *gomail.Dialer
+}
+
+func NewSMTPMailer(conf *configs.SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{
+		conf:   conf,
+		dialer: gomail.NewDialer(conf.Host, conf.Port, conf.Username, conf.Password),
+	}
+}
+
+// Send 每次发送都重新建立连接，发送量不大，不维持长连接
+func (s *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.dialer.DialAndSend(newMessage(s.conf, msg))
+}
+
+// newMessage 构造 MIME 邮件，纯文本在前、HTML 在后，客户端会优先显示 HTML
+func newMessage(conf *configs.SMTPConfig, msg *Message) *gomail.Message {
+	m := gomail.NewMessage()
+	// FormatAddress 可以同时设置邮箱地址和发件人名称，避免乱码
+	m.SetHeader("From", m.FormatAddress(conf.Username, conf.FromName))
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetBody("text/plain", msg.Text)
+	m.AddAlternative("text/html", msg.HTML)
+	return m
+}
@@ -0,0 +1,135 @@
+package mailer
+
+import (
+	"Nuxus/configs"
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates 从磁盘加载的邮件模板
+// 目录下每种语言一个子目录（如 zh-CN、en），每封邮件由同名的两个文件组成：
+// <name>.html 是 HTML 正文（html/template，会自动转义），<name>.txt 是纯文本正文（text/template），
+// 主题写在 .txt 的 {{define "subject"}}...{{end}} 中
+type Templates struct {
+	defaultLang string
+	langs       map[string]*langTemplates // 语言标签统一转成小写
+}
+
+type langTemplates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// LoadTemplates 加载 mail.templateDir 下的所有模板，默认语言的目录必须存在
+func LoadTemplates(config *configs.Config) (*Templates, error) {
+	dir := config.Mail.TemplateDir
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read mail templates: %w", err)
+	}
+
+	t := &Templates{
+		defaultLang: strings.ToLower(config.Mail.DefaultLang),
+		langs:       make(map[string]*langTemplates),
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		lt, err := loadLang(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		t.langs[strings.ToLower(entry.Name())] = lt
+	}
+	if t.langs[t.defaultLang] == nil {
+		return nil, fmt.Errorf("mail templates for default language %q not found in %s", config.Mail.DefaultLang, dir)
+	}
+	return t, nil
+}
+
+func loadLang(dir string) (*langTemplates, error) {
+	htmlFiles, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	lt := &langTemplates{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+	for _, htmlFile := range htmlFiles {
+		name := strings.TrimSuffix(filepath.Base(htmlFile), ".html")
+		textFile := strings.TrimSuffix(htmlFile, ".html") + ".txt"
+
+		html, err := htmltemplate.ParseFiles(htmlFile)
+		if err != nil {
+			return nil, err
+		}
+		text, err := texttemplate.ParseFiles(textFile)
+		if err != nil {
+			return nil, err
+		}
+		if text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("%s: missing {{define \"subject\"}}", textFile)
+		}
+		lt.html[name] = html
+		lt.text[name] = text
+	}
+	return lt, nil
+}
+
+// Render 用 lang 对应语言的模板渲染邮件，lang 可以直接传 Accept-Language 请求头
+// 找不到该语言时依次尝试同一主语言的其他地区（如 zh-TW 找不到时用 zh-CN），最后使用默认语言
+func (t *Templates) Render(name, lang string, data any) (*Message, error) {
+	lt := t.resolve(lang, name)
+	if lt == nil {
+		return nil, fmt.Errorf("mail template %q not found", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := lt.text[name].ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := lt.text[name].Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := lt.html[name].Execute(&html, data); err != nil {
+		return nil, err
+	}
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    html.String(),
+	}, nil
+}
+
+func (t *Templates) resolve(lang, name string) *langTemplates {
+	tag := preferredLang(lang)
+	if lt := t.langs[tag]; lt != nil && lt.html[name] != nil {
+		return lt
+	}
+	primary, _, _ := strings.Cut(tag, "-")
+	for _, key := range slices.Sorted(maps.Keys(t.langs)) {
+		if p, _, _ := strings.Cut(key, "-"); p == primary && t.langs[key].html[name] != nil {
+			return t.langs[key]
+		}
+	}
+	if lt := t.langs[t.defaultLang]; lt.html[name] != nil {
+		return lt
+	}
+	return nil
+}
+
+// preferredLang 取 Accept-Language 中的第一个语言标签并转成小写，如 "en-US,en;q=0.9" 得到 "en-us"
+func preferredLang(lang string) string {
+	first, _, _ := strings.Cut(lang, ",")
+	first, _, _ = strings.Cut(first, ";")
+	return strings.ToLower(strings.TrimSpace(first))
+}
@@ -0,0 +1,129 @@
+// Package metrics Prometheus 指标，所有指标注册到同一个 Registry，由 /metrics 暴露
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "nuxus"
+
+// Metrics 汇总 HTTP、数据库、Redis 和后台任务的指标
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	// HTTP 请求，code 是响应体中的业务码，没有业务码的响应（如静态文件）记为 none
+	HTTPRequests *prometheus.CounterVec   // method, route, status, code
+	HTTPDuration *prometheus.HistogramVec // method, route, code
+
+	// 数据库查询，operation 是 GORM 的回调类型（create、query、update、delete、row、raw）
+	DBQueries  *prometheus.CounterVec   // operation, table, result
+	DBDuration *prometheus.HistogramVec // operation, table
+
+	// Redis 命令，管道中的命令合在一起记为 pipeline
+	RedisCommands *prometheus.CounterVec   // command, result
+	RedisDuration *prometheus.HistogramVec // command
+
+	// 浏览量同步任务
+	SyncRuns              *prometheus.CounterVec // result
+	SyncPendingPosts      prometheus.Gauge
+	SyncIncrementsFlushed prometheus.Counter
+	SyncFailures          *prometheus.CounterVec // stage
+	SyncLastSuccess       prometheus.Gauge
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP 请求数",
+		}, []string{"method", "route", "status", "code"}),
+		HTTPDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP 请求耗时",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "code"}),
+
+		DBQueries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "queries_total",
+			Help:      "数据库查询次数，result 为 ok 或 error，查不到记录不算错误",
+		}, []string{"operation", "table", "result"}),
+		DBDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "数据库查询耗时",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+
+		RedisCommands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "commands_total",
+			Help:      "Redis 命令数，result 为 ok 或 error，key 不存在不算错误",
+		}, []string{"command", "result"}),
+		RedisDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "command_duration_seconds",
+			Help:      "Redis 命令耗时",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+		}, []string{"command"}),
+
+		SyncRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sync_task",
+			Name:      "runs_total",
+			Help:      "浏览量同步任务的执行次数，result 为 success 或 failure",
+		}, []string{"result"}),
+		SyncPendingPosts: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "sync_task",
+			Name:      "pending_posts",
+			Help:      "最近一次同步需要写入浏览量的帖子数，包括上次没有写入成功的",
+		}),
+		SyncIncrementsFlushed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sync_task",
+			Name:      "increments_flushed_total",
+			Help:      "写入数据库的浏览量增量之和",
+		}),
+		SyncFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sync_task",
+			Name:      "failures_total",
+			Help:      "浏览量同步失败次数，stage 为 lock、migrate、move、read、parse、update 或 delete",
+		}, []string{"stage"}),
+		SyncLastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "sync_task",
+			Name:      "last_success_timestamp_seconds",
+			Help:      "最近一次同步成功完成的时间",
+		}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests, m.HTTPDuration,
+		m.DBQueries, m.DBDuration,
+		m.RedisCommands, m.RedisDuration,
+		m.SyncRuns, m.SyncPendingPosts, m.SyncIncrementsFlushed, m.SyncFailures, m.SyncLastSuccess,
+	)
+	return m
+}
+
+func result(failed bool) string {
+	if failed {
+		return "error"
+	}
+	return "ok"
+}
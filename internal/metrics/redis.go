@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHook 统计 Redis 命令的次数和耗时
+type RedisHook struct {
+	metrics *Metrics
+}
+
+func NewRedisHook(m *Metrics) *RedisHook {
+	return &RedisHook{metrics: m}
+}
+
+func (h *RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (h *RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", start, err)
+		return err
+	}
+}
+
+func (h *RedisHook) observe(command string, start time.Time, err error) {
+	failed := err != nil && !errors.Is(err, redis.Nil)
+	h.metrics.RedisCommands.WithLabelValues(command, result(failed)).Inc()
+	h.metrics.RedisDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+}
@@ -4,13 +4,13 @@ import (
 	"Nuxus/internal/res"
 	"Nuxus/pkg/erru"
 	"errors"
-	"log"
+	"log/slog"
 
 	"github.com/gin-gonic/gin"
 )
 
 // ErrorHandler 是一个中央错误处理中间件
-func ErrorHandler() gin.HandlerFunc {
+func ErrorHandler(l *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next() // 先执行后续的 handler
 
@@ -25,15 +25,15 @@ func ErrorHandler() gin.HandlerFunc {
 			if errors.As(err, &appErr) {
 				// 如果是 AppError，我们知道如何处理它
 				// 记录包含完整上下文的错误日志
-				log.Printf("Application error: %v\n", appErr)
+				l.WarnContext(c.Request.Context(), "Application error", "code", appErr.Code, "err", appErr)
 				// 使用 response 包返回格式化的 JSON
-				res.Fail(c, appErr.Code, nil, appErr.Msg)
+				res.Fail(c, appErr.Code, appErr.Data, appErr.Msg)
 				return
 			}
 
 			// 如果不是我们定义的 AppError，说明是未知的内部错误
 			// 记录详细错误
-			log.Printf("Internal server error: %v\n", err)
+			l.ErrorContext(c.Request.Context(), "Internal server error", "err", err)
 			// 向用户返回一个通用的服务器内部错误，隐藏实现细节
 			res.Fail(c, erru.InternalServerError, nil, erru.ErrInternalServer.Msg)
 		}
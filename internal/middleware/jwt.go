@@ -2,8 +2,12 @@ package middleware
 
 import (
 	"Nuxus/configs"
+	"Nuxus/internal/dao"
+	"Nuxus/internal/logger"
 	"Nuxus/internal/res"
 	"Nuxus/pkg/erru"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -12,19 +16,24 @@ import (
 )
 
 type JWTMiddleware struct {
-	config *configs.Config
+	config      *configs.Config
+	redisClient *dao.RedisClient
 }
 
-func NewJWTMiddleware(config *configs.Config) *JWTMiddleware {
+func NewJWTMiddleware(config *configs.Config, redisClient *dao.RedisClient) *JWTMiddleware {
 	return &JWTMiddleware{
-		config: config,
+		config:      config,
+		redisClient: redisClient,
 	}
 }
 
 // var duration = configs.Conf.JWT.ExpireHours
 
 type MyClaims struct {
-	UserID uint `json:"user_id"`
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
+	// SessionID 对应 Redis 中的会话，会话被撤销后即使 token 未过期也无法使用
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -32,58 +41,104 @@ type MyClaims struct {
 // JWTAuth 中间件方法 - 完全使用注入的配置
 func (jm *JWTMiddleware) JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.Request.Header.Get("Authorization")
-		if authHeader == "" {
-			res.FailWithAppErr(c, erru.ErrInvalidRequestHeader)
+		claims, appErr := jm.authenticate(c)
+		if appErr != nil {
+			res.FailWithAppErr(c, appErr)
 			c.Abort()
 			return
 		}
+		setClaims(c, claims)
+		c.Next()
+	}
+}
 
-		// 按空格分割，格式应为 "Bearer <token>"
-		parts := strings.SplitN(authHeader, " ", 2)
-		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			res.FailWithAppErr(c, erru.ErrInvalidRequestHeader)
-			c.Abort()
-			return
+// OptionalAuth 用于公开接口：带有有效令牌时和 JWTAuth 一样设置用户信息，
+// 没有令牌或令牌无效时按未登录处理，不拒绝请求
+func (jm *JWTMiddleware) OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			if claims, appErr := jm.authenticate(c); appErr == nil {
+				setClaims(c, claims)
+			}
 		}
+		c.Next()
+	}
+}
 
-		// 提取 token 字符串部分
-		tokenString := parts[1]
+// authenticate 校验请求头中的访问令牌以及会话状态
+func (jm *JWTMiddleware) authenticate(c *gin.Context) (*MyClaims, *erru.AppError) {
+	authHeader := c.Request.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, erru.ErrInvalidRequestHeader
+	}
 
-		// 使用注入的配置中的密钥
-		jwtSecret := []byte(jm.config.JWT.Secret)
+	// 按空格分割，格式应为 "Bearer <token>"
+	parts := strings.SplitN(authHeader, " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		return nil, erru.ErrInvalidRequestHeader
+	}
 
-		// 使用 ParseWithClaims 解析 JWT
-		token, err := jwt.ParseWithClaims(tokenString, &MyClaims{}, func(token *jwt.Token) (any, error) {
-			return jwtSecret, nil
-		})
-		if err != nil {
-			res.FailWithAppErr(c, erru.ErrTokenInvalid.Wrap(err))
-			c.Abort()
-			return
-		}
+	// 提取 token 字符串部分
+	tokenString := parts[1]
 
-		// 校验通过后，取出 claims 中的数据
-		if claims, ok := token.Claims.(*MyClaims); ok && token.Valid {
-			c.Set("userID", claims.UserID)
-			c.Next()
-		} else {
-			res.FailWithAppErr(c, erru.ErrTokenInvalid)
-			c.Abort()
-			return
+	// 使用注入的配置中的密钥
+	jwtSecret := []byte(jm.config.JWT.Secret)
+
+	// 使用 ParseWithClaims 解析 JWT
+	token, err := jwt.ParseWithClaims(tokenString, &MyClaims{}, func(token *jwt.Token) (any, error) {
+		// 只接受 HMAC 签名，防止 alg 被篡改
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return jwtSecret, nil
+	})
+	if err != nil {
+		// 过期单独返回，方便前端据此使用刷新令牌
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, erru.ErrTokenExpired
+		}
+		return nil, erru.ErrTokenInvalid.Wrap(err)
+	}
+
+	claims, ok := token.Claims.(*MyClaims)
+	if !ok || !token.Valid || claims.SessionID == "" {
+		return nil, erru.ErrTokenInvalid
+	}
+
+	// 检查会话是否已被撤销、用户是否已被封禁
+	alive, banned, err := jm.redisClient.SessionState(c.Request.Context(), claims.SessionID, claims.UserID)
+	if err != nil {
+		return nil, erru.ErrInternalServer
 	}
+	if banned {
+		return nil, erru.ErrUserBanned
+	}
+	if !alive {
+		return nil, erru.ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// setClaims 校验通过后，取出 claims 中的数据
+func setClaims(c *gin.Context, claims *MyClaims) {
+	c.Set("userID", claims.UserID)
+	c.Set("sessionID", claims.SessionID)
+	c.Set("role", claims.Role)
+	c.Request = c.Request.WithContext(logger.WithUserID(c.Request.Context(), claims.UserID))
 }
 
-// GenerateToken 生成JWT token
-func (jm *JWTMiddleware) GenerateToken(userID uint) (string, error) {
-	expireHours := time.Duration(jm.config.JWT.ExpireHours)
+// GenerateToken 为指定会话生成短期有效的访问令牌
+// 角色写入 token，角色变更在下一次刷新令牌时生效
+func (jm *JWTMiddleware) GenerateToken(userID uint, role string, sessionID string) (string, error) {
 	jwtSecret := []byte(jm.config.JWT.Secret)
 
 	claims := MyClaims{
-		UserID: userID,
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expireHours * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(jm.AccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "Nexus",
 		},
 	}
@@ -91,3 +146,8 @@ func (jm *JWTMiddleware) GenerateToken(userID uint) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtSecret)
 }
+
+// AccessTokenTTL 访问令牌的有效期
+func (jm *JWTMiddleware) AccessTokenTTL() time.Duration {
+	return time.Duration(jm.config.JWT.AccessExpireMinutes) * time.Minute
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"Nuxus/internal/logger"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID 请求 ID 的请求头和响应头
+const HeaderRequestID = "X-Request-ID"
+
+// requestIDPattern 只接受由字母、数字和 -_.: 组成、不超过 64 个字符的请求 ID，避免日志注入
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9\-_.:]{1,64}$`)
+
+// RequestID 沿用上游传来的 X-Request-ID，没有或不合法时生成一个，
+// 写入响应头和请求的 context，之后的日志都会带上它
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(HeaderRequestID)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Header(HeaderRequestID, requestID)
+		c.Set("requestID", requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+// AccessLog 每个请求结束后记录一条访问日志，5xx 记为 error，4xx 记为 warn
+func AccessLog(l *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		if raw := c.Request.URL.RawQuery; raw != "" {
+			path += "?" + raw
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		l.LogAttrs(c.Request.Context(), level, "请求完成",
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("ip", c.ClientIP()),
+			slog.Int("size", max(c.Writer.Size(), 0)),
+		)
+	}
+}
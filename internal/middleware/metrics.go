@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"Nuxus/internal/metrics"
+	"Nuxus/internal/res"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按路由模板和业务码统计请求数与耗时
+// 没有匹配到路由的请求统一记为 unmatched，避免任意路径撑爆标签数量
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		code := "none"
+		if v, ok := c.Get(res.CodeKey); ok {
+			code = strconv.Itoa(v.(int))
+		}
+		method := c.Request.Method
+		m.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status()), code).Inc()
+		m.HTTPDuration.WithLabelValues(method, route, code).Observe(time.Since(start).Seconds())
+	}
+}
@@ -2,19 +2,31 @@ package middleware
 
 import (
 	"Nuxus/configs"
+	"Nuxus/internal/dao"
+	"Nuxus/internal/metrics"
+	"Nuxus/pkg/rbac"
+	"log/slog"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // MiddlewareManager 管理所有中间件
 type MiddlewareManager struct {
 	jwtMiddleware *JWTMiddleware
+	rateLimiter   *RateLimiter
+	logger        *slog.Logger
+	metrics       *metrics.Metrics
 	config        *configs.Config
 }
 
-func NewMiddlewareManager(config *configs.Config) *MiddlewareManager {
+func NewMiddlewareManager(config *configs.Config, redisClient *dao.RedisClient, logger *slog.Logger, m *metrics.Metrics) *MiddlewareManager {
 	return &MiddlewareManager{
-		jwtMiddleware: NewJWTMiddleware(config),
+		jwtMiddleware: NewJWTMiddleware(config, redisClient),
+		rateLimiter:   NewRateLimiter(config, redisClient, logger),
+		logger:        logger,
+		metrics:       m,
 		config:        config,
 	}
 }
@@ -29,15 +41,59 @@ func (mm *MiddlewareManager) JWTAuth() gin.HandlerFunc {
 	return mm.jwtMiddleware.JWTAuth()
 }
 
+// OptionalAuth 可选的JWT认证中间件，未登录也放行
+func (mm *MiddlewareManager) OptionalAuth() gin.HandlerFunc {
+	return mm.jwtMiddleware.OptionalAuth()
+}
+
 // GenerateToken 生成JWT token
-func (mm *MiddlewareManager) GenerateToken(userID uint) (string, error) {
+func (mm *MiddlewareManager) GenerateToken(userID uint, role string, sessionID string) (string, error) {
+	return mm.jwtMiddleware.GenerateToken(userID, role, sessionID)
+}
+
+// AccessTokenTTL 访问令牌的有效期
+func (mm *MiddlewareManager) AccessTokenTTL() time.Duration {
+	return mm.jwtMiddleware.AccessTokenTTL()
+}
 
-	return mm.jwtMiddleware.GenerateToken(userID)
+// RequireRole 角色校验中间件
+func (mm *MiddlewareManager) RequireRole(roles ...string) gin.HandlerFunc {
+	return RequireRole(roles...)
 }
 
-// ErrorHandler 错误处理中间件（保持不变）
+// RequirePermission 权限校验中间件
+func (mm *MiddlewareManager) RequirePermission(perm rbac.Permission) gin.HandlerFunc {
+	return RequirePermission(perm)
+}
+
+// RateLimit 按配置中的规则限流
+func (mm *MiddlewareManager) RateLimit(rule string) gin.HandlerFunc {
+	return mm.rateLimiter.Limit(rule)
+}
+
+// RequestID 请求 ID 中间件
+func (mm *MiddlewareManager) RequestID() gin.HandlerFunc {
+	return RequestID()
+}
+
+// AccessLog 访问日志中间件
+func (mm *MiddlewareManager) AccessLog() gin.HandlerFunc {
+	return AccessLog(mm.logger)
+}
+
+// Metrics 请求指标中间件
+func (mm *MiddlewareManager) Metrics() gin.HandlerFunc {
+	return Metrics(mm.metrics)
+}
+
+// MetricsHandler 输出所有指标的 Prometheus 接口
+func (mm *MiddlewareManager) MetricsHandler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(mm.metrics.Registry, promhttp.HandlerOpts{Registry: mm.metrics.Registry}))
+}
+
+// ErrorHandler 错误处理中间件
 func (mm *MiddlewareManager) ErrorHandler() gin.HandlerFunc {
-	return ErrorHandler()
+	return ErrorHandler(mm.logger)
 }
 
 // CORSMiddleware CORS中间件（保持不变）
@@ -45,12 +101,7 @@ func (mm *MiddlewareManager) CORSMiddleware() gin.HandlerFunc {
 	return CORSMiddleware()
 }
 
-// Recovery 恢复中间件（保持不变）
+// Recovery 恢复中间件
 func (mm *MiddlewareManager) Recovery() gin.HandlerFunc {
-	return Recovery()
-}
-
-// Logger 日志中间件（保持不变）
-func (mm *MiddlewareManager) Logger() gin.HandlerFunc {
-	return Logger()
+	return Recovery(mm.logger)
 }
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"Nuxus/configs"
+	"Nuxus/internal/dao"
+	"Nuxus/internal/dto"
+	"Nuxus/internal/res"
+	"Nuxus/pkg/erru"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	rateLimitTimeout    = 50 * time.Millisecond // Redis 超过这个时间没有响应就改用本地令牌桶
+	redisRetryInterval  = 5 * time.Second       // 改用本地令牌桶后，隔多久再尝试 Redis
+	memoryBucketsMaxLen = 10000                 // 本地令牌桶超过这个数量时清理已经补满的桶
+)
+
+// errRedisSkipped 退化期间还没到重试时间，直接使用本地令牌桶
+var errRedisSkipped = errors.New("redis skipped")
+
+// RateLimiter 基于令牌桶的接口限流，令牌桶保存在 Redis 中，多个实例共享
+// Redis 不可用时退化为每个实例各自在内存中限流
+type RateLimiter struct {
+	redisClient *dao.RedisClient
+	config      *configs.RateLimitConfig
+	fallback    *memoryLimiter
+	logger      *slog.Logger
+	degraded    atomic.Bool  // 正在使用本地令牌桶，只在状态切换时记录日志
+	retryAt     atomic.Int64 // 退化期间下次尝试 Redis 的时间（UnixNano），避免每个请求都等待超时
+}
+
+func NewRateLimiter(config *configs.Config, redisClient *dao.RedisClient, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{
+		redisClient: redisClient,
+		config:      &config.RateLimit,
+		fallback:    newMemoryLimiter(),
+		logger:      logger,
+	}
+}
+
+// limitResult 一次取令牌的结果
+type limitResult struct {
+	allowed    bool
+	remaining  float64
+	retryAfter time.Duration // 下一个令牌补充到的时间，只在被拒绝时有意义
+	reset      time.Duration // 令牌桶补满的时间
+}
+
+// Limit 按规则限流，身份优先取 JWTAuth 设置的用户 ID，未登录时取客户端 IP（只有 server.trustedProxies 转发的请求才读取 X-Forwarded-For）
+// 放在 JWTAuth 之前时总是按 IP 限流；规则不存在或限流关闭时不做任何处理
+func (rl *RateLimiter) Limit(ruleName string) gin.HandlerFunc {
+	rule, ok := rl.config.Rules[ruleName]
+	if !rl.config.Enabled || !ok || rule.Limit <= 0 || rule.PeriodSeconds <= 0 {
+		if rl.config.Enabled {
+			rl.logger.Warn("限流规则未配置，不限流", "rule", ruleName)
+		}
+		return func(c *gin.Context) { c.Next() }
+	}
+	burst := rule.Burst
+	if burst <= 0 {
+		burst = rule.Limit
+	}
+	rate := float64(rule.Limit) / float64(rule.PeriodSeconds) // 每秒补充的令牌数
+
+	return func(c *gin.Context) {
+		identity := "ip:" + c.ClientIP()
+		if userID, exists := c.Get("userID"); exists {
+			identity = fmt.Sprintf("user:%v", userID)
+		}
+
+		result := rl.take(c.Request.Context(), ruleName, identity, rate, burst)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(int(result.remaining)))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.reset)))
+		if !result.allowed {
+			retryAfter := ceilSeconds(result.retryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			res.FailWithStatus(c, http.StatusTooManyRequests,
+				erru.ErrTooManyRequests.WithData(dto.RetryAfterDTO{RetryAfter: retryAfter}))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func (rl *RateLimiter) take(ctx context.Context, rule, identity string, rate float64, burst int) limitResult {
+	allowed, tokens, err := false, 0.0, errRedisSkipped
+	if !rl.degraded.Load() || time.Now().UnixNano() >= rl.retryAt.Load() {
+		ctx, cancel := context.WithTimeout(ctx, rateLimitTimeout)
+		allowed, tokens, err = rl.redisClient.TakeToken(ctx, rule, identity, rate, burst)
+		cancel()
+	}
+	if err != nil {
+		if err != errRedisSkipped {
+			rl.retryAt.Store(time.Now().Add(redisRetryInterval).UnixNano())
+			if !rl.degraded.Swap(true) {
+				rl.logger.WarnContext(ctx, "限流改用本地令牌桶", "err", err)
+			}
+		}
+		allowed, tokens = rl.fallback.take(rule+":"+identity, rate, burst)
+	} else if rl.degraded.Swap(false) {
+		rl.logger.InfoContext(ctx, "限流恢复使用 Redis")
+	}
+
+	return limitResult{
+		allowed:    allowed,
+		remaining:  tokens,
+		retryAfter: secondsToDuration((1 - tokens) / rate),
+		reset:      secondsToDuration((float64(burst) - tokens) / rate),
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Max(seconds, 0) * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// memoryLimiter 进程内的令牌桶，算法和 Redis 脚本相同
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+type memoryBucket struct {
+	tokens float64
+	ts     time.Time
+	full   time.Time // 到这个时间桶会补满，之后可以丢弃
+}
+
+func newMemoryLimiter() *memoryLimiter {
+	return &memoryLimiter{buckets: make(map[string]*memoryBucket)}
+}
+
+func (m *memoryLimiter) take(key string, rate float64, burst int) (bool, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if len(m.buckets) >= memoryBucketsMaxLen {
+		for k, b := range m.buckets {
+			if now.After(b.full) {
+				delete(m.buckets, k)
+			}
+		}
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(burst), ts: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.ts).Seconds()*rate)
+	b.ts = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(secondsToDuration((float64(burst) - b.tokens) / rate))
+	return allowed, b.tokens
+}
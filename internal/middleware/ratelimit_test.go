@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"Nuxus/configs"
+	"Nuxus/internal/dao"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestEngine 按 SetupRouter 的方式设置可信代理，挂上每个身份只有一个令牌的限流规则。
+// Redis 指向一个连不上的地址，限流退化为本地令牌桶
+func newTestEngine(t *testing.T, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	config := &configs.Config{RateLimit: configs.RateLimitConfig{
+		Enabled: true,
+		Rules:   map[string]configs.RateLimitRule{"test": {Limit: 1, PeriodSeconds: 3600}},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limiter := NewRateLimiter(config, dao.NewRedisClient(client), logger)
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatal(err)
+	}
+	r.GET("/", limiter.Limit("test"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func doRequest(r *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimitIgnoresForgedForwardedFor(t *testing.T) {
+	r := newTestEngine(t, nil)
+
+	if code := doRequest(r, "203.0.113.7:40000", "1.1.1.1"); code != http.StatusOK {
+		t.Fatalf("first request: got status %d, want %d", code, http.StatusOK)
+	}
+	// 换一个伪造的 X-Forwarded-For 仍然是同一个身份，令牌已经用完
+	if code := doRequest(r, "203.0.113.7:40001", "2.2.2.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("forged X-Forwarded-For: got status %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimitUsesForwardedForFromTrustedProxy(t *testing.T) {
+	r := newTestEngine(t, []string{"10.0.0.0/8"})
+
+	if code := doRequest(r, "10.0.0.2:40000", "1.1.1.1"); code != http.StatusOK {
+		t.Fatalf("first client: got status %d, want %d", code, http.StatusOK)
+	}
+	// 可信代理转发的不同客户端各自限流
+	if code := doRequest(r, "10.0.0.2:40001", "2.2.2.2"); code != http.StatusOK {
+		t.Fatalf("second client: got status %d, want %d", code, http.StatusOK)
+	}
+	if code := doRequest(r, "10.0.0.2:40002", "1.1.1.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("first client again: got status %d, want %d", code, http.StatusTooManyRequests)
+	}
+	// 不可信的来源伪造的 X-Forwarded-For 不会被采用
+	if code := doRequest(r, "203.0.113.7:40000", "2.2.2.2"); code != http.StatusOK {
+		t.Fatalf("untrusted source: got status %d, want %d", code, http.StatusOK)
+	}
+	if code := doRequest(r, "203.0.113.7:40001", "3.3.3.3"); code != http.StatusTooManyRequests {
+		t.Fatalf("untrusted source forged header: got status %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
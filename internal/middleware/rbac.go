@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"Nuxus/internal/res"
+	"Nuxus/pkg/erru"
+	"Nuxus/pkg/rbac"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole 要求当前用户属于给定角色之一，必须放在 JWTAuth 之后
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, c.GetString("role")) {
+			res.FailWithAppErr(c, erru.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission 要求当前用户的角色拥有给定权限，必须放在 JWTAuth 之后
+func RequirePermission(perm rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rbac.HasPermission(c.GetString("role"), perm) {
+			res.FailWithAppErr(c, erru.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
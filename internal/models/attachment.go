@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Attachment 用户上传的帖子配图
+// 上传后在帖子或草稿正文中首次引用时记录 PostID；编辑后正文不再引用或帖子被删除时取消关联并记录 UnlinkedAt
+// 上传或取消关联后超过宽限期仍未被引用的图片由定时任务清理，宽限期内回滚到旧版本会重新关联
+type Attachment struct {
+	ID   uint   `gorm:"primarykey"`
+	UUID string `gorm:"size:36;not null;uniqueIndex"` // 出现在对象键中，用于从正文里识别引用
+
+	UserID     uint       `gorm:"not null;index"`
+	PostID     uint       `gorm:"not null;default:0;index"` // 0 表示还没有被引用
+	UnlinkedAt *time.Time // 最近一次取消关联的时间，从未关联过时为空
+
+	// --- 原图 (Original) ---
+	Key         string `gorm:"size:255;not null"`
+	URL         string `gorm:"size:512;not null"`
+	ContentType string `gorm:"size:50;not null"`
+	Size        int64  `gorm:"not null"`
+	Width       int    `gorm:"not null"`
+	Height      int    `gorm:"not null"`
+
+	// 缩略图和 WebP 版本
+	Variants []*AttachmentVariant `gorm:"type:text;serializer:json"`
+
+	CreatedAt time.Time
+}
+
+// AttachmentVariant 图片的一个缩放版本
+type AttachmentVariant struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
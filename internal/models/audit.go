@@ -0,0 +1,21 @@
+package models
+
+import "gorm.io/gorm"
+
+// AuditLog 记录版主、管理员对他人内容或账号的操作，便于事后追溯
+type AuditLog struct {
+	gorm.Model
+
+	// --- 操作者 (Actor) ---
+	ActorID   uint   `gorm:"not null;index"`
+	ActorRole string `gorm:"size:20;not null"`
+
+	// --- 操作内容 (Action) ---
+	// Action 形如 "post.update"、"comment.delete"
+	Action     string `gorm:"size:50;not null;index"`
+	TargetType string `gorm:"size:20;not null;index:idx_audit_target"`
+	TargetID   uint   `gorm:"not null;index:idx_audit_target"`
+	// 被操作内容的所有者
+	TargetOwnerID uint   `gorm:"default:0"`
+	Detail        string `gorm:"type:text"` // 补充说明，例如修改前的标题
+}
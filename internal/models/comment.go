@@ -10,10 +10,18 @@ type Comment struct {
 	UserID uint `gorm:"not null"`
 	User   User `gorm:"foreignKey:UserID"`
 
-	PostID uint `gorm:"not null"`
+	PostID uint `gorm:"not null;index"`
 
 	// --- 回复机制 (Reply Mechanism) ---
 	// ParentID 指向它所回复的另一条评论的 ID。
 	// 如果是顶级评论，ParentID 为 0。
 	ParentID uint `gorm:"default:0"`
+	// RootID 指向回复所在楼层的顶级评论，楼中楼无论嵌套多深都归到同一个楼层。
+	// 顶级评论的 RootID 为 0。
+	RootID uint `gorm:"default:0;index"`
+
+	// 被版主隐藏的评论不再出现在评论列表中
+	IsHidden bool `gorm:"default:false"`
+
+	Mentions []*Mention `gorm:"foreignKey:CommentID;constraint:-"` // 评论中的 @提及
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Follow 用户之间的关注关系
+type Follow struct {
+	FollowerID uint `gorm:"primaryKey"`                           // 粉丝
+	FolloweeID uint `gorm:"primaryKey;index:idx_follow_followee"` // 被关注的用户
+	CreatedAt  time.Time
+}
+
+// TagSubscription 用户关注的标签
+type TagSubscription struct {
+	UserID    uint `gorm:"primaryKey"`
+	TagID     uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Mention 帖子或评论中的一次 @提及
+// 帖子正文中的提及 CommentID 为 0，编辑内容时整体替换，所以不需要软删除
+type Mention struct {
+	ID uint `gorm:"primarykey"`
+
+	// --- 被提及的用户 (Mentioned User) ---
+	UserID   uint   `gorm:"not null;index"`
+	Username string `gorm:"size:50;not null"`
+
+	// --- 出处 (Source) ---
+	ActorID   uint `gorm:"not null"` // 内容作者
+	Actor     User `gorm:"foreignKey:ActorID"`
+	PostID    uint `gorm:"not null;index:idx_mention_source"`
+	Post      Post `gorm:"foreignKey:PostID"`
+	CommentID uint `gorm:"default:0;index:idx_mention_source"`
+
+	// --- 位置 (Position) ---
+	Start   int    // 在内容中的起始位置（按字符计，指向 @）
+	End     int    // 结束位置（不含）
+	Excerpt string `gorm:"size:255"` // 提及处附近的内容摘要
+
+	CreatedAt time.Time
+}
@@ -0,0 +1,47 @@
+package models
+
+import "gorm.io/gorm"
+
+// 通知类型
+const (
+	NotifyTypeComment  = "comment"  // 帖子被评论
+	NotifyTypeReply    = "reply"    // 评论被回复
+	NotifyTypeLike     = "like"     // 帖子被点赞
+	NotifyTypeFavorite = "favorite" // 帖子被收藏
+	NotifyTypeMention  = "mention"  // 被 @ 提及
+	NotifyTypeTagPost  = "tag_post" // 关注的标签有新帖子
+)
+
+// NotifyTypes 所有可以被屏蔽的通知类型
+var NotifyTypes = []string{NotifyTypeComment, NotifyTypeReply, NotifyTypeLike, NotifyTypeFavorite, NotifyTypeMention, NotifyTypeTagPost}
+
+type Notification struct {
+	gorm.Model
+
+	// --- 接收者 (Recipient) ---
+	UserID uint `gorm:"not null;index:idx_notification_user_read"`
+	IsRead bool `gorm:"default:false;index:idx_notification_user_read"`
+
+	// --- 通知内容 (Content) ---
+	Type      string `gorm:"size:20;not null"`
+	PostID    uint   `gorm:"default:0"`
+	CommentID uint   `gorm:"default:0"`
+	TagID     uint   `gorm:"default:0"`
+	Tag       Tag    `gorm:"foreignKey:TagID;constraint:-"`
+	Content   string `gorm:"size:255"` // 评论内容或帖子标题的摘要
+
+	// --- 聚合 (Aggregation) ---
+	// 同一个帖子的点赞、收藏在未读期间合并成一条，例如“张三等 13 人赞了你的帖子”；
+	// 关注标签的新帖子同理，合并成“#Go 有 5 篇新帖子”的摘要
+	GroupKey   string `gorm:"size:64;index"` // 为空表示不聚合
+	ActorID    uint   `gorm:"not null"`      // 最近一次触发通知的用户
+	Actor      User   `gorm:"foreignKey:ActorID"`
+	ActorCount int    `gorm:"default:1"` // 合并的不同用户数
+	EventCount int    `gorm:"default:1"` // 合并的事件数
+}
+
+// NotificationActor 记录聚合通知中出现过的用户，保证同一用户只计数一次
+type NotificationActor struct {
+	NotificationID uint `gorm:"primaryKey"`
+	UserID         uint `gorm:"primaryKey"`
+}
@@ -1,15 +1,38 @@
 // alan-nexus/internal/models/post.go
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 帖子状态
+const (
+	PostStatusDraft     = "draft"     // 草稿，只有作者可见
+	PostStatusScheduled = "scheduled" // 定时发布，到 PublishAt 后由定时任务发布
+	PostStatusPublished = "published" // 已发布
+	PostStatusHidden    = "hidden"    // 被管理员隐藏，除管理员外不可见
+	PostStatusArchived  = "archived"  // 作者归档，不出现在列表和搜索中，仍可通过链接查看，但不能再互动
+)
+
+// PostExcerptLen 列表中展示的纯文本摘要的最大长度（字符数）
+const PostExcerptLen = 150
 
 type Post struct {
 	gorm.Model
 
 	// --- 核心内容 (Core Content) ---
-	Title string `gorm:"not null;size:100"`
+	// 标题和正文共用一个 ngram 全文索引，用于中文搜索
+	Title string `gorm:"not null;size:100;index:idx_posts_fulltext,class:FULLTEXT,option:WITH PARSER ngram"`
 	// 存储原始 Markdown 文本，前端直接用此内容进行渲染
-	Content string `gorm:"type:text;not null"`
+	Content string `gorm:"type:text;not null;index:idx_posts_fulltext,class:FULLTEXT,option:WITH PARSER ngram"`
+	// 正文开头的纯文本，保存时生成，列表页不用再解析 Markdown
+	Excerpt string `gorm:"size:255;not null;default:''"`
+
+	// --- 编辑历史 (Revisions) ---
+	Revision int        `gorm:"default:1"` // 当前版本号，对应 PostRevision.Version
+	EditedAt *time.Time // 最后一次编辑的时间，从未编辑过为 NULL
 
 	// --- 关联外键 (Foreign Keys) ---
 	UserID uint `gorm:"not null"`
@@ -23,9 +46,31 @@ type Post struct {
 	FavoriteCount int `gorm:"default:0"`
 	CommentCount  int `gorm:"default:0"`
 
+	// --- 生命周期 (Lifecycle) ---
+	// 发布时 CreatedAt 会被更新为发布时间，列表和时间线都按它排序
+	Status    string     `gorm:"size:20;not null;default:published;index"`
+	PublishAt *time.Time `gorm:"index"` // 定时发布的时间，只对 scheduled 状态有意义
+
+	// --- 管理状态 (Moderation Flags) ---
+	IsPinned bool `gorm:"default:false;index"` // 置顶，列表中排在最前
+	IsLocked bool `gorm:"default:false"`       // 锁定，不能再发表评论
+	// 被隐藏前的状态（已发布或已归档），取消隐藏时恢复
+	StatusBeforeHidden string `gorm:"size:20;not null;default:''"`
+
 	// --- 关联关系 (Associations) ---
 	Comments         []*Comment `gorm:"foreignKey:PostID"` // 帖子的所有评论
 	Tags             []*Tag     `gorm:"many2many:post_tags;"`
 	LikedByUser      []*User    `gorm:"many2many:user_post_likes;"`     // 用户点赞的帖子
 	FavoritedByUsers []*User    `gorm:"many2many:user_post_favorites;"` // 用户收藏的帖子
+	Mentions         []*Mention `gorm:"foreignKey:PostID;constraint:-"` // 正文中的 @提及，不含评论中的
+}
+
+// IsVisible 帖子是否对所有人可见（已发布或已归档）
+func (p *Post) IsVisible() bool {
+	return p.Status == PostStatusPublished || p.Status == PostStatusArchived
+}
+
+// IsDraft 帖子是否还没有发布（草稿或等待定时发布）
+func (p *Post) IsDraft() bool {
+	return p.Status == PostStatusDraft || p.Status == PostStatusScheduled
 }
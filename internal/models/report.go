@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 举报目标类型
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+)
+
+// 举报处理状态
+const (
+	ReportStatusPending   = "pending"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+type Report struct {
+	gorm.Model
+
+	// --- 举报人 (Reporter) ---
+	ReporterID uint `gorm:"not null;index"`
+	Reporter   User `gorm:"foreignKey:ReporterID"`
+
+	// --- 举报内容 (Target) ---
+	TargetType string `gorm:"size:20;not null;index:idx_report_target"` // post / comment / user
+	TargetID   uint   `gorm:"not null;index:idx_report_target"`
+	Reason     string `gorm:"size:20;not null"` // spam / abuse / illegal / other
+	Detail     string `gorm:"size:500"`         // 举报人补充说明
+
+	// --- 处理结果 (Handling) ---
+	Status       string `gorm:"size:20;default:'pending';index"`
+	HandlerID    uint   `gorm:"default:0"`
+	HandledAt    *time.Time
+	HandleNote   string `gorm:"size:500"`
+	TargetHidden bool   `gorm:"default:false"` // 处理时是否隐藏了被举报的内容
+}
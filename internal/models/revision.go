@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PostRevision 帖子的一个历史版本，每次编辑或回滚都追加一条，记录编辑后的完整内容
+type PostRevision struct {
+	ID uint `gorm:"primarykey"`
+
+	PostID  uint `gorm:"not null;uniqueIndex:idx_revision_post_version"`
+	Version int  `gorm:"not null;uniqueIndex:idx_revision_post_version"` // 从 1 开始，1 是发布时的内容
+
+	// --- 版本内容 (Snapshot) ---
+	Title   string   `gorm:"size:100;not null"`
+	Content string   `gorm:"type:text;not null"`
+	Tags    []string `gorm:"type:text;serializer:json"` // 标签名，标签之后被重命名或合并也不影响历史
+
+	// --- 编辑信息 (Editor) ---
+	EditorID     uint `gorm:"not null"`
+	Editor       User `gorm:"foreignKey:EditorID"`
+	RollbackFrom int  `gorm:"default:0"` // 由回滚产生时，记录回滚到的版本号
+
+	CreatedAt time.Time
+}
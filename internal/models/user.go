@@ -1,6 +1,12 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
@@ -26,9 +32,38 @@ type User struct {
 	IsWechatPublic bool `gorm:"default:false"`
 	IsGenderPublic bool `gorm:"default:true"`
 
+	// --- 社交关系 (Social Graph) ---
+	FollowerCount  int `gorm:"default:0"` // 粉丝数
+	FollowingCount int `gorm:"default:0"` // 关注数
+
+	// --- 通知设置 (Notification Settings) ---
+	MutedNotifications string `gorm:"size:255"` // 屏蔽的通知类型，逗号分隔
+
+	// --- 账号状态 (Account Status) ---
+	BannedAt          *time.Time // 非空表示已被封禁
+	BanExpiresAt      *time.Time // 封禁到期时间，为空表示永久封禁
+	BanReason         string     `gorm:"size:255"`
+	MustResetPassword bool       `gorm:"default:false"` // 管理员要求用户重置密码后才能登录
+
 	// --- 关联关系 (Associations) ---
 	Posts     []*Post    `gorm:"foreignKey:UserID"`              // 用户发表的帖子
 	Comments  []*Comment `gorm:"foreignKey:UserID"`              // 用户发表的评论
 	Likes     []*Post    `gorm:"many2many:user_post_likes;"`     // 用户点赞的帖子
 	Favorites []*Post    `gorm:"many2many:user_post_favorites;"` // 用户收藏的帖子
 }
+
+// IsBanned 判断用户在给定时间点是否处于封禁状态
+func (u *User) IsBanned(now time.Time) bool {
+	if u.BannedAt == nil {
+		return false
+	}
+	return u.BanExpiresAt == nil || u.BanExpiresAt.After(now)
+}
+
+// IsNotificationMuted 判断用户是否屏蔽了某类通知
+func (u *User) IsNotificationMuted(notifyType string) bool {
+	if u.MutedNotifications == "" {
+		return false
+	}
+	return slices.Contains(strings.Split(u.MutedNotifications, ","), notifyType)
+}
@@ -6,6 +6,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// CodeKey 响应的业务码在 gin.Context 中的键，供监控按业务码统计
+const CodeKey = "resCode"
+
 type Response struct {
 	Code int    `json:"code"`
 	Msg  string `json:"msg"`
@@ -13,6 +16,7 @@ type Response struct {
 }
 
 func response(ctx *gin.Context, code int, data any, msg string) {
+	ctx.Set(CodeKey, code)
 	ctx.JSON(200, Response{
 		Code: code,
 		Msg:  msg,
@@ -40,6 +44,16 @@ func FailWithAppErr(ctx *gin.Context, err *erru.AppError) {
 	response(ctx, err.Code, err.Err, err.Msg)
 }
 
+// FailWithStatus 使用指定的 HTTP 状态码返回错误，用于需要客户端按状态码处理的场景（如 429）
+func FailWithStatus(ctx *gin.Context, status int, err *erru.AppError) {
+	ctx.Set(CodeKey, err.Code)
+	ctx.JSON(status, Response{
+		Code: err.Code,
+		Msg:  err.Msg,
+		Data: err.Data,
+	})
+}
+
 func FailWithMsg(ctx *gin.Context, msg string) {
 	Fail(ctx, 1003, nil, msg)
 }
@@ -1,8 +1,11 @@
 package routers
 
 import (
+	"Nuxus/configs"
 	"Nuxus/internal/controller"
 	"Nuxus/internal/middleware"
+	"Nuxus/internal/storage"
+	"Nuxus/pkg/rbac"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,46 +14,93 @@ type Router struct {
 	userController    *controller.UserController
 	postController    *controller.PostController
 	tagController     *controller.TagController
+	adminController   *controller.AdminController
+	reportController  *controller.ReportController
+	notifyController  *controller.NotificationController
+	mentionController *controller.MentionController
+	followController  *controller.FollowController
+	fileController    *controller.FileController
+	healthController  *controller.HealthController
 	middlewareManager *middleware.MiddlewareManager
+	config            *configs.Config
 }
 
 func NewRouter(
 	userController *controller.UserController,
 	postController *controller.PostController,
 	tagController *controller.TagController,
+	adminController *controller.AdminController,
+	reportController *controller.ReportController,
+	notifyController *controller.NotificationController,
+	mentionController *controller.MentionController,
+	followController *controller.FollowController,
+	fileController *controller.FileController,
+	healthController *controller.HealthController,
 	middlewareManager *middleware.MiddlewareManager,
+	config *configs.Config,
 ) *Router {
 	return &Router{
 		userController:    userController,
 		postController:    postController,
 		tagController:     tagController,
+		adminController:   adminController,
+		reportController:  reportController,
+		notifyController:  notifyController,
+		mentionController: mentionController,
+		followController:  followController,
+		fileController:    fileController,
+		healthController:  healthController,
 		middlewareManager: middlewareManager,
+		config:            config,
 	}
 }
 
-func (router *Router) SetupRouter() *gin.Engine {
-	r := gin.Default()
-	r.Use(router.middlewareManager.ErrorHandler(),
+func (router *Router) SetupRouter() (*gin.Engine, error) {
+	r := gin.New()
+	// gin 默认信任所有代理，客户端可以通过伪造 X-Forwarded-For 冒充任意 IP
+	if err := r.SetTrustedProxies(router.config.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
+	// 探针和指标接口在注册全局中间件之前注册，不计入请求指标和访问日志，也不限流
+	r.GET("/healthz", router.healthController.Healthz)
+	r.GET("/readyz", router.healthController.Readyz)
+	if router.config.Metrics.Enabled {
+		r.GET(router.config.Metrics.Path, router.middlewareManager.MetricsHandler())
+	}
+	r.Use(router.middlewareManager.RequestID(),
+		router.middlewareManager.Metrics(),
+		router.middlewareManager.AccessLog(),
+		router.middlewareManager.ErrorHandler(),
 		router.middlewareManager.CORSMiddleware(),
 		router.middlewareManager.Recovery())
 
+	// 本地存储的文件
+	r.GET(storage.LocalRoute+"*key", router.fileController.ServeLocal)
+
 	v1 := r.Group("/api/v1")
+	v1.Use(router.middlewareManager.RateLimit("global"))
 	{
 		// 普通路由
 		user := v1.Group("/users")
 		{
-			user.POST("/register", router.userController.Register)
-			user.POST("/verify-register", router.userController.VerifyRegister)
-			user.POST("/login", router.userController.Login)
-			user.POST("/password/reset", router.userController.RequestReset)
-			user.POST("/password/verify-reset", router.userController.VerifyReset)
+			authLimit := router.middlewareManager.RateLimit("auth")
+			user.POST("/register", authLimit, router.userController.Register)
+			user.POST("/verify-register", authLimit, router.userController.VerifyRegister)
+			user.POST("/login", authLimit, router.userController.Login)
+			user.POST("/password/reset", authLimit, router.userController.RequestReset)
+			user.POST("/password/verify-reset", authLimit, router.userController.VerifyReset)
+			user.POST("/token/refresh", router.userController.RefreshToken)
+
+			user.GET("/:id/followers", router.followController.ListFollowers)
+			user.GET("/:id/following", router.followController.ListFollowing)
 		}
 
 		post := v1.Group("/posts")
 		{
 			post.GET("/", router.postController.ListPosts)
 			post.GET("/popular", router.postController.ListPopularPosts)
-			post.GET("/:id", router.postController.GetPost)
+			post.GET("/search", router.postController.SearchPosts)
+			post.GET("/:id", router.middlewareManager.OptionalAuth(), router.postController.GetPost)
 
 			comment := post.Group("/:id/comments")
 			{
@@ -58,6 +108,10 @@ func (router *Router) SetupRouter() *gin.Engine {
 			}
 		}
 
+		v1.GET("/comments/:commentId/replies", router.postController.ListReplies)
+		// 正文和头像中的 <img> 不会带上令牌，公开的对象不需要登录，未公开的配图只有登录的上传者能访问
+		v1.GET("/files/*key", router.middlewareManager.OptionalAuth(), router.fileController.Redirect)
+
 		tag := v1.Group("/tags")
 		{
 			tag.GET("/", router.tagController.ListTags)
@@ -67,38 +121,119 @@ func (router *Router) SetupRouter() *gin.Engine {
 		auth := v1.Group("")
 		auth.Use(router.middlewareManager.JWTAuth())
 		{
+			// 写操作和互动操作按用户限流
+			writeLimit := router.middlewareManager.RateLimit("write")
+			interactLimit := router.middlewareManager.RateLimit("interact")
+
 			me := auth.Group("me")
 			{
 				me.GET("/", router.userController.GetProfile)
 				me.PUT("/", router.userController.UpdateProfile)
-				me.POST("/avatar", router.userController.UpdateAvatar)
+				me.POST("/avatar", writeLimit, router.userController.UpdateAvatar)
+
+				me.GET("/sessions", router.userController.ListSessions)
+				me.DELETE("/sessions", router.userController.RevokeOtherSessions)
+				me.DELETE("/sessions/:sessionId", router.userController.RevokeSession)
+
+				me.GET("/notifications", router.notifyController.ListNotifications)
+				me.GET("/notifications/unread-count", router.notifyController.UnreadCount)
+				me.POST("/notifications/read-all", router.notifyController.MarkAllRead)
+				me.POST("/notifications/:id/read", router.notifyController.MarkRead)
+
+				me.GET("/mentions", router.mentionController.ListMentions)
+				me.GET("/feed", router.followController.Feed)
+				me.GET("/tags", router.tagController.ListSubscribedTags)
+				me.GET("/tags/feed", router.followController.TagFeed)
+
+				me.GET("/drafts", router.postController.ListDrafts)
+				me.POST("/drafts", writeLimit, router.postController.SaveDraft)
+				me.GET("/drafts/:id", router.postController.GetDraft)
+				me.PUT("/drafts/:id", writeLimit, router.postController.SaveDraft)
+				me.DELETE("/drafts/:id", router.postController.DeleteDraft)
+				me.POST("/drafts/:id/publish", router.postController.PublishDraft)
 			}
 
+			auth.POST("/users/:id/follow", interactLimit, router.followController.FollowUser)
+			auth.DELETE("/users/:id/follow", interactLimit, router.followController.UnfollowUser)
+			auth.POST("/tags/:id/follow", interactLimit, router.followController.FollowTag)
+			auth.DELETE("/tags/:id/follow", interactLimit, router.followController.UnfollowTag)
+
 			post := auth.Group("/posts")
 			{
-				post.POST("/", router.postController.CreatePost)
-				post.PUT("/:id", router.postController.UpdatePost)
+				post.POST("/", writeLimit, router.postController.CreatePost)
+				post.PUT("/:id", writeLimit, router.postController.UpdatePost)
 				post.DELETE("/:id", router.postController.DeletePost)
+				post.POST("/:id/archive", router.postController.ArchivePost(true))
+				post.DELETE("/:id/archive", router.postController.ArchivePost(false))
+				post.GET("/:id/revisions", router.postController.ListRevisions)
+				post.GET("/:id/revisions/:version", router.postController.GetRevision)
+				post.POST("/:id/revisions/:version/rollback", router.postController.RollbackPost)
 				post.GET("/:id/user-status", router.postController.GetUserStatus)
 
 				comment := post.Group("/:id/comments")
 				{
-					comment.POST("/", router.postController.CreateComment)
+					comment.POST("/", writeLimit, router.postController.CreateComment)
 				}
 
 				like := post.Group("/:id/like")
 				{
-					like.POST("/", router.postController.LikePost)
+					like.POST("/", interactLimit, router.postController.LikePost)
 				}
 				favorite := post.Group("/:id/favorite")
 				{
-					favorite.POST("/", router.postController.FavoritePost)
+					favorite.POST("/", interactLimit, router.postController.FavoritePost)
 				}
 			}
+			auth.PUT("/comments/:commentId", writeLimit, router.postController.UpdateComment)
 			auth.DELETE("/comments/:commentId", router.postController.DeleteComment)
 
+			auth.POST("/reports", writeLimit, router.reportController.CreateReport)
+			auth.POST("/uploads/images", writeLimit, router.fileController.UploadImage)
+		}
+
+		// 举报处理队列，版主及以上可访问
+		moderation := v1.Group("/moderation")
+		moderation.Use(router.middlewareManager.JWTAuth(), router.middlewareManager.RequirePermission(rbac.PermReportHandle))
+		{
+			moderation.GET("/reports", router.reportController.ListReports)
+			moderation.POST("/reports/:id/resolve", router.reportController.ResolveReport)
+			moderation.POST("/reports/:id/dismiss", router.reportController.DismissReport)
+		}
+
+		// 管理路由，仅管理员可访问
+		admin := v1.Group("/admin")
+		admin.Use(router.middlewareManager.JWTAuth(), router.middlewareManager.RequireRole(rbac.RoleAdmin))
+		{
+			users := admin.Group("/users")
+			{
+				users.GET("/", router.adminController.ListUsers)
+				users.PUT("/:id/role", router.adminController.UpdateUserRole)
+				users.POST("/:id/ban", router.adminController.BanUser)
+				users.DELETE("/:id/ban", router.adminController.UnbanUser)
+				users.POST("/:id/force-reset", router.adminController.ForcePasswordReset)
+			}
+
+			posts := admin.Group("/posts")
+			{
+				posts.POST("/:id/pin", router.adminController.SetPostFlag("pin", true))
+				posts.DELETE("/:id/pin", router.adminController.SetPostFlag("pin", false))
+				posts.POST("/:id/lock", router.adminController.SetPostFlag("lock", true))
+				posts.DELETE("/:id/lock", router.adminController.SetPostFlag("lock", false))
+				posts.POST("/:id/hide", router.adminController.SetPostFlag("hide", true))
+				posts.DELETE("/:id/hide", router.adminController.SetPostFlag("hide", false))
+			}
+
+			admin.DELETE("/comments/:commentId", router.adminController.RemoveComment)
+
+			tags := admin.Group("/tags")
+			{
+				tags.PUT("/:id", router.adminController.RenameTag)
+				tags.POST("/:id/merge", router.adminController.MergeTags)
+			}
+
+			admin.GET("/audit-logs", router.adminController.ListAuditLogs)
 		}
 	}
 
-	return r
+	return r, nil
 }
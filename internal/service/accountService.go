@@ -5,33 +5,44 @@ import (
 	"Nuxus/internal/dao"
 	"Nuxus/internal/dto"
 	"Nuxus/internal/models"
+	"Nuxus/internal/storage"
 	"Nuxus/pkg/erru"
+	"Nuxus/pkg/identicon"
+	"Nuxus/pkg/imageutil"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"image"
+	"io"
+	"log/slog"
 	"mime/multipart"
-	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
-	"github.com/qiniu/go-sdk/v7/auth/qbox"
-	"github.com/qiniu/go-sdk/v7/storage"
 	"gorm.io/gorm"
 )
 
 type AccountService struct {
 	userDAO *dao.UserDAO
+	objectStore storage.ObjectStore
 	config *configs.Config
+	logger *slog.Logger
 }
 
-func NewAccountService(userDAO *dao.UserDAO,config *configs.Config) *AccountService {
+func NewAccountService(userDAO *dao.UserDAO,objectStore storage.ObjectStore,config *configs.Config, logger *slog.Logger) *AccountService {
 	return &AccountService{
 		userDAO: userDAO,
+		objectStore: objectStore,
 		config: config,
+		logger: logger,
 	}
 }
 
-func (a *AccountService) GetProfile(userId uint) (*models.User, error) {
-	user, err := a.userDAO.GetUserById(userId)
+func (a *AccountService) GetProfile(ctx context.Context, userId uint) (*models.User, error) {
+	user, err := a.userDAO.GetUserById(ctx, userId)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, erru.ErrResourceNotFound
@@ -41,8 +52,8 @@ func (a *AccountService) GetProfile(userId uint) (*models.User, error) {
 	return user, nil
 }
 
-func (a *AccountService) UpdateProfile(userId uint, reqDto dto.ProfileReqDTO) (*models.User, error) {
-	user, err := a.userDAO.GetUserById(userId)
+func (a *AccountService) UpdateProfile(ctx context.Context, userId uint, reqDto dto.ProfileReqDTO) (*models.User, error) {
+	user, err := a.userDAO.GetUserById(ctx, userId)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, erru.ErrResourceNotFound
@@ -50,10 +61,16 @@ func (a *AccountService) UpdateProfile(userId uint, reqDto dto.ProfileReqDTO) (*
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
 	a.updateUser(user, &reqDto)
-	user, err = a.userDAO.UpdateProfile(user)
+	user, err = a.userDAO.UpdateProfile(ctx, user)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
+	// Updates 会忽略空字符串，通知屏蔽设置需要单独更新才能清空
+	if reqDto.MutedNotifications != nil {
+		if err := a.userDAO.UpdateMutedNotifications(ctx, userId, user.MutedNotifications); err != nil {
+			return nil, erru.ErrInternalServer.Wrap(err)
+		}
+	}
 	return user, nil
 }
 
@@ -69,72 +86,133 @@ func (a *AccountService) updateUser(user *models.User, reqDto *dto.ProfileReqDTO
 	user.IsQQPublic = reqDto.Privacy.IsQQPublic
 	user.IsWechatPublic = reqDto.Privacy.IsWechatPublic
 	user.IsGenderPublic = reqDto.Privacy.IsGenderPublic
-}
 
-// ---------------------头像------------------------------
-// getQiniuZone 根据配置字符串返回七牛云的 Zone 对象
-func getQiniuZone(zoneStr string) *storage.Zone {
-	switch zoneStr {
-	case "ZoneHuadong":
-		return &storage.ZoneHuadong
-	case "ZoneHuabei":
-		return &storage.ZoneHuabei
-	case "ZoneHuanan":
-		return &storage.ZoneHuanan
-	case "ZoneBeimei":
-		return &storage.ZoneBeimei
-	case "ZoneXinjiapo":
-		return &storage.ZoneXinjiapo
-	default:
-		return &storage.ZoneHuadong // 默认华东
+	if reqDto.MutedNotifications != nil {
+		user.MutedNotifications = strings.Join(reqDto.MutedNotifications, ",")
 	}
 }
 
-// UpdateAvatar 使用七牛云 Kodo 处理头像上传
-func (a *AccountService) UpdateAvatar(userID uint, file *multipart.FileHeader) (string, error) {
-	qiniuConf := a.config.Qiniu
-
-	// 1. 生成上传凭证
-	putPolicy := storage.PutPolicy{
-		Scope: qiniuConf.Bucket,
-	}
-	mac := qbox.NewMac(qiniuConf.AccessKey, qiniuConf.SecretKey)
-	uploadToken := putPolicy.UploadToken(mac)
+// ---------------------头像------------------------------
+// AvatarSize 保存到用户资料中的头像尺寸（边长，像素）
+const AvatarSize = 256
 
-	// 2. 配置存储区域
-	cfg := storage.Config{
-		Zone:     getQiniuZone(qiniuConf.Zone),
-		UseHTTPS: false, // 根据你的域名是否支持 HTTPS 修改
-	}
+// 每次上传生成的头像尺寸
+var avatarSizes = []int{AvatarSize, 128, 64}
 
-	// 3. 构建表单上传的对象
-	formUploader := storage.NewFormUploader(&cfg)
-	ret := storage.PutRet{}
+const avatarMaxBytes = 8 << 20
 
-	// 4. 生成唯一的对象键 (文件名)
-	ext := filepath.Ext(file.Filename)
-	key := fmt.Sprintf("avatars/%d/%s%s", userID, uuid.New().String(), ext)
+// avatarKeyPattern 从头像地址中取出对象键，处理后的头像形如 avatars/<用户ID>/<UUID>_256.jpg，
+// 旧版本直接保存的原图没有尺寸后缀
+var avatarKeyPattern = regexp.MustCompile(`avatars/(\d+)/([0-9a-f-]{36})(_\d+)?(\.\w+)`)
 
-	// 5. 打开上传的文件流
+// UpdateAvatar 处理上传的头像：按裁剪框裁剪、从中间裁成正方形、缩放为各个尺寸后重新编码上传，
+// 资料更新成功后删除旧头像
+func (a *AccountService) UpdateAvatar(ctx context.Context, userID uint, file *multipart.FileHeader, reqDto *dto.UpdateAvatarReqDTO) (map[int]string, error) {
 	src, err := file.Open()
 	if err != nil {
-		return "", erru.ErrInternalServer.Wrap(err)
+		return nil, erru.ErrInternalServer.Wrap(err)
 	}
 	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, avatarMaxBytes+1))
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	if len(data) > avatarMaxBytes {
+		return nil, erru.New("图片大小不能超过8MB")
+	}
 
-	// 6. 执行上传
-	err = formUploader.Put(context.Background(), &ret, uploadToken, key, src, file.Size, nil)
+	contentType, err := imageutil.Sniff(data)
 	if err != nil {
-		return "", erru.ErrInternalServer.Wrap(err)
+		return nil, erru.New("只支持上传 JPEG、PNG、GIF、WebP 格式的图片")
+	}
+	img, err := imageutil.Decode(data, imageutil.Limits{
+		MaxWidth:  a.config.Upload.MaxImageWidth,
+		MaxHeight: a.config.Upload.MaxImageHeight,
+		MaxPixels: a.config.Upload.MaxImageMegapixels * 1000 * 1000,
+	})
+	if errors.Is(err, imageutil.ErrTooLarge) {
+		return nil, erru.New("图片尺寸过大")
+	}
+	if err != nil {
+		return nil, erru.New("图片已损坏或无法识别").Wrap(err)
 	}
 
-	// 7. 构建公开访问的 URL
-	avatarURL := fmt.Sprintf("http://%s/%s", qiniuConf.Domain, ret.Key)
+	if reqDto.CropX != nil || reqDto.CropY != nil || reqDto.CropWidth != nil || reqDto.CropHeight != nil {
+		if reqDto.CropX == nil || reqDto.CropY == nil || reqDto.CropWidth == nil || reqDto.CropHeight == nil {
+			return nil, erru.ErrInvalidParams.WithMsg("裁剪框需要同时指定 crop_x、crop_y、crop_width、crop_height")
+		}
+		box := image.Rect(*reqDto.CropX, *reqDto.CropY, *reqDto.CropX+*reqDto.CropWidth, *reqDto.CropY+*reqDto.CropHeight)
+		if img, err = imageutil.Crop(img, box); err != nil {
+			return nil, erru.ErrInvalidParams.WithMsg("裁剪框超出了图片范围")
+		}
+	}
 
-	// 8. 将新的 URL 更新到数据库
-	if err := a.userDAO.UpdateUserAvatar(userID, avatarURL); err != nil {
-		return "", erru.ErrInternalServer.Wrap(err)
+	// 只有 JPEG 保持 JPEG，其他格式可能带透明通道，统一存为 PNG
+	if contentType != imageutil.TypeJPEG {
+		contentType = imageutil.TypePNG
+	}
+	return a.saveAvatar(ctx, userID, img, contentType)
+}
+
+// GenerateDefaultAvatar 为新用户生成由用户名决定的默认头像，失败只记录日志
+func (a *AccountService) GenerateDefaultAvatar(ctx context.Context, user *models.User) {
+	img := identicon.New(user.Username, AvatarSize)
+	if _, err := a.saveAvatar(ctx, user.ID, img, imageutil.TypePNG); err != nil {
+		a.logger.ErrorContext(ctx, "生成默认头像失败", "user_id", user.ID, "err", err)
+	}
+}
+
+// saveAvatar 把图像缩放为各个尺寸上传，更新用户资料后删除旧头像的对象
+func (a *AccountService) saveAvatar(ctx context.Context, userID uint, img image.Image, contentType string) (map[int]string, error) {
+	user, err := a.userDAO.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+
+	base := fmt.Sprintf("avatars/%d/%s", userID, uuid.New().String())
+	urls := make(map[int]string, len(avatarSizes))
+	uploaded := make([]string, 0, len(avatarSizes))
+	err = func() error {
+		for _, size := range avatarSizes {
+			resized, err := imageutil.Encode(imageutil.Square(img, size), contentType)
+			if err != nil {
+				return err
+			}
+			key := fmt.Sprintf("%s_%d%s", base, size, imageutil.Ext(contentType))
+			err = a.objectStore.Put(ctx, key, bytes.NewReader(resized.Data), int64(len(resized.Data)), contentType)
+			if err != nil {
+				return err
+			}
+			uploaded = append(uploaded, key)
+			// 私有空间存的是跳转接口的地址，由跳转接口在每次访问时重新签名
+			if urls[size], err = storage.StableURL(a.objectStore, key); err != nil {
+				return err
+			}
+		}
+		return a.userDAO.UpdateUserAvatar(ctx, userID, urls[AvatarSize])
+	}()
+	if err != nil {
+		deleteObjects(ctx, a.logger, a.objectStore, uploaded)
+		return nil, erru.ErrInternalServer.Wrap(err)
 	}
 
-	return avatarURL, nil
+	deleteObjects(ctx, a.logger, a.objectStore, avatarKeys(userID, user.Avatar))
+	return urls, nil
+}
+
+// avatarKeys 从头像地址推出它在对象存储中的所有对象，不是该用户上传的头像（如外部地址）返回空
+func avatarKeys(userID uint, avatarURL string) []string {
+	match := avatarKeyPattern.FindStringSubmatch(avatarURL)
+	if match == nil || match[1] != strconv.FormatUint(uint64(userID), 10) {
+		return nil
+	}
+	base, ext := fmt.Sprintf("avatars/%s/%s", match[1], match[2]), match[4]
+	if match[3] == "" {
+		return []string{base + ext}
+	}
+	keys := make([]string, 0, len(avatarSizes))
+	for _, size := range avatarSizes {
+		keys = append(keys, fmt.Sprintf("%s_%d%s", base, size, ext))
+	}
+	return keys
 }
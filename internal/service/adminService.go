@@ -0,0 +1,304 @@
+package service
+
+import (
+	"Nuxus/internal/dao"
+	"Nuxus/internal/dto"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/erru"
+	"Nuxus/pkg/rbac"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 帖子管理标记与数据库列的对应关系
+var postFlagColumns = map[string]string{
+	"pin":  "is_pinned",
+	"lock": "is_locked",
+}
+
+// postFlagHide 隐藏不是单独的列，而是把帖子状态在已发布和隐藏之间切换
+const postFlagHide = "hide"
+
+type AdminService struct {
+	userDAO        *dao.UserDAO
+	postDAO        *dao.PostDAO
+	tagDAO         *dao.TagDAO
+	redisClient    *dao.RedisClient
+	auditService   *AuditService
+	mentionService *MentionService
+	postCache      *PostCache
+}
+
+func NewAdminService(userDAO *dao.UserDAO, postDAO *dao.PostDAO, tagDAO *dao.TagDAO, redisClient *dao.RedisClient, auditService *AuditService, mentionService *MentionService, postCache *PostCache) *AdminService {
+	return &AdminService{
+		userDAO:        userDAO,
+		postDAO:        postDAO,
+		tagDAO:         tagDAO,
+		redisClient:    redisClient,
+		auditService:   auditService,
+		mentionService: mentionService,
+		postCache:      postCache,
+	}
+}
+
+// -------------------用户管理--------------------------------
+func (a *AdminService) ListUsers(ctx context.Context, reqDto *dto.AdminListUsersReqDTO) ([]*models.User, int64, error) {
+	users, total, err := a.userDAO.ListUsers(ctx, reqDto.Keyword, reqDto.Role, reqDto.Banned, reqDto.Page, reqDto.Size)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+	return users, total, nil
+}
+
+// getTargetUser 获取被管理的用户，不允许管理员对自己操作
+func (a *AdminService) getTargetUser(ctx context.Context, adminID, userID uint) (*models.User, error) {
+	if adminID == userID {
+		return nil, erru.New("不能对自己执行此操作")
+	}
+	user, err := a.userDAO.GetUserById(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erru.ErrUserNotFound
+		}
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	return user, nil
+}
+
+func (a *AdminService) UpdateUserRole(ctx context.Context, adminID uint, adminRole string, userID uint, role string) error {
+	user, err := a.getTargetUser(ctx, adminID, userID)
+	if err != nil {
+		return err
+	}
+	if !rbac.IsValidRole(role) {
+		return erru.ErrInvalidParams
+	}
+
+	if err := a.userDAO.UpdateUserRole(ctx, userID, role); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	// 角色写在 token 里，注销旧会话让新角色立即生效
+	if err := a.redisClient.DeleteUserSessions(ctx, userID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	a.auditService.Record(ctx, adminID, adminRole, "user.role", AuditTargetUser, userID, userID,
+		fmt.Sprintf("%s -> %s", user.Role, role))
+	return nil
+}
+
+func (a *AdminService) BanUser(ctx context.Context, adminID uint, adminRole string, userID uint, reqDto *dto.BanUserReqDTO) error {
+	user, err := a.getTargetUser(ctx, adminID, userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == rbac.RoleAdmin {
+		return erru.New("不能封禁管理员")
+	}
+
+	// 计算封禁标记在 Redis 中的存活时间，0 表示永久
+	var ttl time.Duration
+	if reqDto.ExpiresAt != nil {
+		ttl = time.Until(*reqDto.ExpiresAt)
+		if ttl <= 0 {
+			return erru.ErrInvalidParams.WithMsg("封禁到期时间必须晚于当前时间")
+		}
+	}
+
+	if err := a.userDAO.BanUser(ctx, userID, reqDto.Reason, reqDto.ExpiresAt); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if err := a.redisClient.SetUserBanned(ctx, userID, reqDto.Reason, ttl); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if err := a.redisClient.DeleteUserSessions(ctx, userID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	detail := "永久封禁，原因: " + reqDto.Reason
+	if reqDto.ExpiresAt != nil {
+		detail = fmt.Sprintf("封禁至 %s，原因: %s", reqDto.ExpiresAt.Format(time.RFC3339), reqDto.Reason)
+	}
+	a.auditService.Record(ctx, adminID, adminRole, "user.ban", AuditTargetUser, userID, userID, detail)
+	return nil
+}
+
+func (a *AdminService) UnbanUser(ctx context.Context, adminID uint, adminRole string, userID uint) error {
+	if _, err := a.getTargetUser(ctx, adminID, userID); err != nil {
+		return err
+	}
+
+	if err := a.userDAO.UnbanUser(ctx, userID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if err := a.redisClient.DelUserBanned(ctx, userID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	a.auditService.Record(ctx, adminID, adminRole, "user.unban", AuditTargetUser, userID, userID, "")
+	return nil
+}
+
+// ForcePasswordReset 强制用户重置密码：注销所有会话，并在重置前拒绝登录
+func (a *AdminService) ForcePasswordReset(ctx context.Context, adminID uint, adminRole string, userID uint) error {
+	if _, err := a.getTargetUser(ctx, adminID, userID); err != nil {
+		return err
+	}
+
+	if err := a.userDAO.SetMustResetPassword(ctx, userID, true); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if err := a.redisClient.DeleteUserSessions(ctx, userID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	a.auditService.Record(ctx, adminID, adminRole, "user.force_reset", AuditTargetUser, userID, userID, "")
+	return nil
+}
+
+// -------------------内容管理--------------------------------
+
+// SetPostFlag 设置帖子的置顶、锁定、隐藏状态，flag 取值见 postFlagColumns
+func (a *AdminService) SetPostFlag(ctx context.Context, adminID uint, adminRole string, postID uint, flag string, value bool) error {
+	column, ok := postFlagColumns[flag]
+	if !ok && flag != postFlagHide {
+		return erru.ErrInvalidParams
+	}
+
+	post, err := a.postDAO.GetPostById(ctx, postID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	if flag == postFlagHide {
+		err = setPostHidden(ctx, a.postDAO, postID, value)
+	} else {
+		err = a.postDAO.UpdatePostFlag(ctx, postID, column, value)
+	}
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	// 锁定只影响详情，置顶和隐藏还会改变列表
+	if flag == "lock" {
+		a.postCache.InvalidatePost(ctx, postID)
+	} else {
+		a.postCache.InvalidatePostAndLists(ctx, postID)
+	}
+
+	action := "post." + flag
+	if !value {
+		action = "post.un" + flag
+	}
+	a.auditService.Record(ctx, adminID, adminRole, action, AuditTargetPost, postID, post.UserID, "")
+	return nil
+}
+
+// RemoveComment 管理员删除评论，和用户自己删除一样采用逻辑删除
+func (a *AdminService) RemoveComment(ctx context.Context, adminID uint, adminRole string, commentID uint) error {
+	comment, err := a.postDAO.GetCommentById(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	oldContent := comment.Content
+
+	comment.Content = "该评论已被管理员删除"
+	if err := a.postDAO.UpdateComment(ctx, comment); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	a.mentionService.ClearMentions(ctx, comment.PostID, commentID)
+
+	a.auditService.Record(ctx, adminID, adminRole, "comment.remove", AuditTargetComment, commentID, comment.UserID,
+		fmt.Sprintf("原内容: %s", oldContent))
+	return nil
+}
+
+// -------------------标签管理--------------------------------
+func (a *AdminService) RenameTag(ctx context.Context, adminID uint, adminRole string, tagID uint, name string) error {
+	tag, err := a.tagDAO.GetTagById(ctx, tagID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	// 新名字已存在时应该使用合并
+	existing, err := a.tagDAO.GetTagByName(ctx, name)
+	if err == nil && existing.ID != tagID {
+		return erru.New("标签名已存在，请使用合并功能")
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	if err := a.tagDAO.RenameTag(ctx, tagID, name); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	// 帖子详情中的标签等缓存过期后更新
+	a.postCache.InvalidateLists(ctx)
+
+	a.auditService.Record(ctx, adminID, adminRole, "tag.rename", AuditTargetTag, tagID, 0,
+		fmt.Sprintf("%s -> %s", tag.Name, name))
+	return nil
+}
+
+// MergeTags 把 sourceID 合并进 targetID，合并后 sourceID 被删除
+func (a *AdminService) MergeTags(ctx context.Context, adminID uint, adminRole string, sourceID, targetID uint) error {
+	if sourceID == targetID {
+		return erru.ErrInvalidParams.WithMsg("不能把标签合并到自身")
+	}
+	source, err := a.tagDAO.GetTagById(ctx, sourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	target, err := a.tagDAO.GetTagById(ctx, targetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	if err := a.tagDAO.MergeTags(ctx, sourceID, targetID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	// 帖子详情中的标签等缓存过期后更新
+	a.postCache.InvalidateLists(ctx)
+
+	a.auditService.Record(ctx, adminID, adminRole, "tag.merge", AuditTargetTag, targetID, 0,
+		fmt.Sprintf("%s(%d) -> %s(%d)", source.Name, sourceID, target.Name, targetID))
+	return nil
+}
+
+// setPostHidden 隐藏已发布或已归档的帖子；取消隐藏后恢复为隐藏前的状态
+func setPostHidden(ctx context.Context, postDAO *dao.PostDAO, postID uint, hidden bool) error {
+	if hidden {
+		for _, from := range []string{models.PostStatusPublished, models.PostStatusArchived} {
+			ok, err := postDAO.UpdatePostStatus(ctx, postID, []string{from},
+				map[string]any{"status": models.PostStatusHidden, "status_before_hidden": from})
+			if err != nil || ok {
+				return err
+			}
+		}
+		return nil
+	}
+	// 记录隐藏前状态之前隐藏的帖子没有该字段，恢复为已发布
+	_, err := postDAO.UpdatePostStatus(ctx, postID,
+		[]string{models.PostStatusHidden},
+		map[string]any{"status": gorm.Expr("IF(status_before_hidden = ?, ?, ?)",
+			models.PostStatusArchived, models.PostStatusArchived, models.PostStatusPublished)})
+	return err
+}
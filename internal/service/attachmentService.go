@@ -0,0 +1,290 @@
+package service
+
+import (
+	"Nuxus/configs"
+	"Nuxus/internal/dao"
+	"Nuxus/internal/models"
+	"Nuxus/internal/storage"
+	"Nuxus/pkg/erru"
+	"Nuxus/pkg/imageutil"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const orphanBatchSize = 100 // 清理未引用图片时每批处理的数量
+
+// attachmentRefPattern 匹配正文中引用的配图，对象键形如 images/<用户ID>/<UUID>.png，缩略图带 _w320 等后缀
+var attachmentRefPattern = regexp.MustCompile(`images/\d+/([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})`)
+
+// AttachmentService 帖子配图的上传、引用关联和清理
+type AttachmentService struct {
+	attachmentDAO *dao.AttachmentDAO
+	postDAO       *dao.PostDAO
+	userDAO       *dao.UserDAO
+	objectStore   storage.ObjectStore
+	config        *configs.Config
+	logger        *slog.Logger
+}
+
+func NewAttachmentService(attachmentDAO *dao.AttachmentDAO, postDAO *dao.PostDAO, userDAO *dao.UserDAO, objectStore storage.ObjectStore, config *configs.Config, logger *slog.Logger) *AttachmentService {
+	return &AttachmentService{
+		attachmentDAO: attachmentDAO,
+		postDAO:       postDAO,
+		userDAO:       userDAO,
+		objectStore:   objectStore,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// UploadImage 校验并上传一张配图
+// 类型按文件内容判断；图片会被重新编码以去掉 EXIF 等元数据，并按配置生成缩略图和 WebP 版本
+func (a *AttachmentService) UploadImage(ctx context.Context, userID uint, file *multipart.FileHeader) (*models.Attachment, error) {
+	cfg := a.config.Upload
+	maxBytes := int64(cfg.MaxImageMB) << 20
+	if file.Size > maxBytes {
+		return nil, erru.ErrInvalidParams.WithMsg(fmt.Sprintf("图片大小不能超过%dMB", cfg.MaxImageMB))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	defer src.Close()
+	// 不信任表单中声明的大小，多读一个字节判断是否超限
+	data, err := io.ReadAll(io.LimitReader(src, maxBytes+1))
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, erru.ErrInvalidParams.WithMsg(fmt.Sprintf("图片大小不能超过%dMB", cfg.MaxImageMB))
+	}
+
+	contentType, err := imageutil.Sniff(data)
+	if err != nil {
+		return nil, erru.ErrInvalidParams.WithMsg("只支持上传 JPEG、PNG、GIF、WebP 格式的图片")
+	}
+	limits := imageutil.Limits{
+		MaxWidth:  cfg.MaxImageWidth,
+		MaxHeight: cfg.MaxImageHeight,
+		MaxPixels: cfg.MaxImageMegapixels * 1000 * 1000,
+
+		MaxFrames:      cfg.MaxGIFFrames,
+		MaxTotalPixels: cfg.MaxGIFMegapixels * 1000 * 1000,
+	}
+	original, img, err := imageutil.Sanitize(data, contentType, limits)
+	if errors.Is(err, imageutil.ErrTooLarge) {
+		return nil, erru.ErrInvalidParams.WithMsg(fmt.Sprintf("图片尺寸不能超过 %dx%d，且不能超过 %d 百万像素",
+			cfg.MaxImageWidth, cfg.MaxImageHeight, cfg.MaxImageMegapixels))
+	}
+	if err != nil {
+		return nil, erru.ErrInvalidParams.WithMsg("图片已损坏或无法识别").Wrap(err)
+	}
+
+	id := uuid.New().String()
+	base := fmt.Sprintf("images/%d/%s", userID, id)
+	attachment := &models.Attachment{
+		UUID:        id,
+		UserID:      userID,
+		Key:         base + imageutil.Ext(original.ContentType),
+		ContentType: original.ContentType,
+		Size:        int64(len(original.Data)),
+		Width:       original.Width,
+		Height:      original.Height,
+	}
+
+	uploaded := make([]string, 0, 1+2*len(cfg.ThumbnailWidths))
+	put := func(key string, image *imageutil.Image) (string, error) {
+		err := a.objectStore.Put(ctx, key, bytes.NewReader(image.Data), int64(len(image.Data)), image.ContentType)
+		if err != nil {
+			return "", err
+		}
+		uploaded = append(uploaded, key)
+		return storage.StableURL(a.objectStore, key)
+	}
+
+	err = func() error {
+		var err error
+		if attachment.URL, err = put(attachment.Key, original); err != nil {
+			return err
+		}
+		for _, width := range cfg.ThumbnailWidths {
+			if width <= 0 || width > original.Width {
+				continue
+			}
+			// 每个宽度一张原格式的缩略图，一张 WebP
+			for _, thumbType := range []string{original.ContentType, imageutil.TypeWebP} {
+				thumb, err := imageutil.Thumbnail(img, width, thumbType)
+				if err != nil {
+					return err
+				}
+				key := fmt.Sprintf("%s_w%d%s", base, width, imageutil.Ext(thumb.ContentType))
+				if slices.ContainsFunc(attachment.Variants, func(v *models.AttachmentVariant) bool { return v.Key == key }) {
+					continue
+				}
+				url, err := put(key, thumb)
+				if err != nil {
+					return err
+				}
+				attachment.Variants = append(attachment.Variants, &models.AttachmentVariant{
+					Key:         key,
+					URL:         url,
+					ContentType: thumb.ContentType,
+					Width:       thumb.Width,
+					Height:      thumb.Height,
+				})
+			}
+		}
+		return a.attachmentDAO.CreateAttachment(ctx, attachment)
+	}()
+	if err != nil {
+		// 已经上传的对象不会再被引用，直接删掉
+		deleteObjects(ctx, a.logger, a.objectStore, uploaded)
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	return attachment, nil
+}
+
+// SyncPostAttachments 把正文中引用的、作者自己上传的配图关联到帖子，
+// 并取消正文中不再引用的配图的关联，交给定时任务在宽限期后清理。失败只记录日志
+// 帖子删除时 content 传空字符串
+func (a *AttachmentService) SyncPostAttachments(ctx context.Context, userID, postID uint, content string) {
+	uuids := make([]string, 0)
+	for _, match := range attachmentRefPattern.FindAllStringSubmatch(content, -1) {
+		if !slices.Contains(uuids, match[1]) {
+			uuids = append(uuids, match[1])
+		}
+	}
+	if err := a.attachmentDAO.UnlinkAttachments(ctx, postID, uuids); err != nil {
+		a.logger.ErrorContext(ctx, "取消帖子配图关联失败", "post_id", postID, "err", err)
+	}
+	if len(uuids) == 0 {
+		return
+	}
+	if err := a.attachmentDAO.LinkAttachments(ctx, userID, postID, uuids); err != nil {
+		a.logger.ErrorContext(ctx, "关联帖子配图失败", "post_id", postID, "err", err)
+	}
+}
+
+// CheckObjectAccess 检查用户能否访问私有空间中的对象，viewerID 为 0 表示未登录
+// 头像只能访问用户当前使用的；配图在帖子公开后所有人可访问，之前只有上传者和帖子作者可以访问。
+// 没有权限和对象不存在一样返回 ErrResourceNotFound，不暴露对象是否存在
+func (a *AttachmentService) CheckObjectAccess(ctx context.Context, key string, viewerID uint) error {
+	if match := avatarKeyPattern.FindStringSubmatch(key); match != nil && match[0] == key {
+		userID, _ := strconv.ParseUint(match[1], 10, 64)
+		user, err := a.userDAO.GetUserById(ctx, uint(userID))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return erru.ErrResourceNotFound
+			}
+			return erru.ErrInternalServer.Wrap(err)
+		}
+		if !strings.Contains(user.Avatar, fmt.Sprintf("avatars/%s/%s", match[1], match[2])) {
+			return erru.ErrResourceNotFound
+		}
+		return nil
+	}
+
+	match := attachmentRefPattern.FindStringSubmatch(key)
+	if match == nil {
+		return erru.ErrResourceNotFound
+	}
+	attachment, err := a.attachmentDAO.GetAttachmentByUUID(ctx, match[1])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	// 只能访问这张图片自己的原图和缩略图
+	if key != attachment.Key && !slices.ContainsFunc(attachment.Variants, func(v *models.AttachmentVariant) bool { return v.Key == key }) {
+		return erru.ErrResourceNotFound
+	}
+	if viewerID != 0 && viewerID == attachment.UserID {
+		return nil
+	}
+	if attachment.PostID == 0 {
+		return erru.ErrResourceNotFound
+	}
+	post, err := a.postDAO.GetPostById(ctx, attachment.PostID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if post.IsVisible() || (viewerID != 0 && viewerID == post.UserID) {
+		return nil
+	}
+	return erru.ErrResourceNotFound
+}
+
+// CleanOrphans 删除上传后超过宽限期仍未被任何帖子引用的配图
+func (a *AttachmentService) CleanOrphans(ctx context.Context) {
+	before := time.Now().Add(-time.Duration(a.config.Upload.OrphanGraceHours) * time.Hour)
+	cleaned := 0
+	for {
+		orphans, err := a.attachmentDAO.ListOrphans(ctx, before, orphanBatchSize)
+		if err != nil {
+			a.logger.ErrorContext(ctx, "查询未引用的配图失败", "err", err)
+			return
+		}
+		for _, attachment := range orphans {
+			// 同一张图片被作者的多篇帖子引用时只关联其中一篇，这篇不再引用后改为关联到仍在引用的帖子
+			postID, err := a.postDAO.FindPostReferencing(ctx, attachment.UserID, attachment.UUID)
+			if err == nil && postID != 0 {
+				err = a.attachmentDAO.LinkAttachments(ctx, attachment.UserID, postID, []string{attachment.UUID})
+			}
+			if err != nil {
+				a.logger.ErrorContext(ctx, "检查配图引用失败", "attachment_id", attachment.ID, "err", err)
+				return
+			}
+			if postID != 0 {
+				continue
+			}
+			// 先删记录，这样在清理期间被引用的图片不会被误删
+			deleted, err := a.attachmentDAO.DeleteOrphan(ctx, attachment.ID)
+			if err != nil {
+				a.logger.ErrorContext(ctx, "删除配图记录失败", "attachment_id", attachment.ID, "err", err)
+				return
+			}
+			if !deleted {
+				continue
+			}
+			keys := []string{attachment.Key}
+			for _, variant := range attachment.Variants {
+				keys = append(keys, variant.Key)
+			}
+			deleteObjects(ctx, a.logger, a.objectStore, keys)
+			cleaned++
+		}
+		if len(orphans) < orphanBatchSize {
+			break
+		}
+	}
+	if cleaned > 0 {
+		a.logger.InfoContext(ctx, "清理未引用的配图", "count", cleaned)
+	}
+}
+
+// deleteObjects 删除不再使用的对象，失败只记录日志
+func deleteObjects(ctx context.Context, l *slog.Logger, store storage.ObjectStore, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			l.ErrorContext(ctx, "删除对象失败", "key", key, "err", err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"Nuxus/internal/dao"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/erru"
+	"Nuxus/pkg/rbac"
+	"context"
+	"log/slog"
+)
+
+// 审计日志中的目标类型
+const (
+	AuditTargetPost    = "post"
+	AuditTargetComment = "comment"
+	AuditTargetUser    = "user"
+	AuditTargetTag     = "tag"
+)
+
+type AuditService struct {
+	auditDAO *dao.AuditDAO
+	logger   *slog.Logger
+}
+
+func NewAuditService(auditDAO *dao.AuditDAO, logger *slog.Logger) *AuditService {
+	return &AuditService{auditDAO: auditDAO, logger: logger}
+}
+
+// Record 写入一条审计日志
+// 审计失败不应影响已经完成的操作，所以这里只记录日志
+func (a *AuditService) Record(ctx context.Context, actorID uint, actorRole, action, targetType string, targetID, ownerID uint, detail string) {
+	err := a.auditDAO.CreateAuditLog(ctx, &models.AuditLog{
+		ActorID:       actorID,
+		ActorRole:     actorRole,
+		Action:        action,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		TargetOwnerID: ownerID,
+		Detail:        detail,
+	})
+	if err != nil {
+		a.logger.ErrorContext(ctx, "写入审计日志失败", "action", action, "target_id", targetID, "err", err)
+	}
+}
+
+func (a *AuditService) ListAuditLogs(ctx context.Context, targetType string, page, size int) ([]*models.AuditLog, int64, error) {
+	logs, total, err := a.auditDAO.ListAuditLogs(ctx, targetType, page, size)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+	return logs, total, nil
+}
+
+// checkOwnership 校验操作者能否处理 ownerID 名下的内容
+// 本人直接放行；否则要求角色拥有 perm，此时返回 true 表示这是一次代操作，需要记录审计日志
+func checkOwnership(actorID uint, role string, ownerID uint, perm rbac.Permission) (bool, error) {
+	if actorID == ownerID {
+		return false, nil
+	}
+	if rbac.HasPermission(role, perm) {
+		return true, nil
+	}
+	return false, erru.ErrUnauthorized
+}
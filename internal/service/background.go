@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Background 跟踪请求中启动的异步任务（通知、推送时间线、发送邮件等），
+// 退出时等待它们结束后再关闭数据库和 Redis 连接池
+type Background struct {
+	wg     sync.WaitGroup
+	logger *slog.Logger
+}
+
+func NewBackground(logger *slog.Logger) *Background {
+	return &Background{logger: logger}
+}
+
+// Go 异步执行 fn。请求结束后 ctx 会被取消，fn 拿到的 ctx 只沿用其中的请求 ID 等值
+func (b *Background) Go(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer func() {
+			if err := recover(); err != nil {
+				b.logger.ErrorContext(ctx, "异步任务 panic", "err", err)
+			}
+		}()
+		fn(ctx)
+	}()
+}
+
+// Wait 等待所有异步任务结束，ctx 先到期时返回 false
+func (b *Background) Wait(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
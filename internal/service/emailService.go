@@ -3,87 +3,154 @@ package service
 
 import (
 	"Nuxus/configs"
-	"fmt"
+	"Nuxus/internal/dao"
+	"Nuxus/internal/mailer"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
 
-	"gopkg.in/gomail.v2"
+const (
+	mailSendTimeout    = 30 * time.Second
+	mailRequeueBatch   = 100 // 每次最多移回发送队列的重试邮件数
+	mailTemplateReg    = "register"
+	mailTemplateReset  = "reset_password"
+	mailTemplateReport = "report_result"
 )
 
-type EmailService struct {
-	config *configs.Config
+// mailJob 发送队列中的一封邮件，入队时已经渲染好
+type mailJob struct {
+	ID        string          `json:"id"`
+	Message   *mailer.Message `json:"message"`
+	Attempts  int             `json:"attempts"` // 已经尝试发送的次数
+	LastError string          `json:"last_error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
 }
 
-func NewEmailService(config *configs.Config) *EmailService {
-	return &EmailService{config: config}
+// EmailService 渲染邮件模板并放入 Redis 发送队列，由后台任务异步发送，失败的邮件按指数退避重试
+type EmailService struct {
+	mailer      mailer.Mailer
+	templates   *mailer.Templates
+	redisClient *dao.RedisClient
+	config      *configs.Config
+	logger      *slog.Logger
 }
 
-// SendVerificationMail 是一个专门发送验证码邮件的便捷函数
-func (e *EmailService) SendRegisterMail(toEmail, code string) error {
-	cfg := e.config.SMTP
-
-	subject := fmt.Sprintf("[%s] 您的邮箱验证码", cfg.FromName)
-
-	// 这里是邮件正文，可以使用 HTML 来美化它
-	// 确保内容清晰地告诉用户验证码是什么，以及它的有效期
-	body := fmt.Sprintf(`
-	<html>
-	<body>
-		<h3>您好！</h3>
-		<p>感谢您注册 <strong>%s</strong>。您的邮箱验证码是：</p>
-		<h2 style="font-weight: bold; color: #1E90FF;">%s</h2>
-		<p>此验证码将在10分钟内失效，请尽快完成验证。</p>
-		<p>如果这不是您本人的操作，请忽略此邮件。</p>
-		<br/>
-		<p>此致</p>
-		<p><strong>%s 团队</strong></p>
-	</body>
-	</html>
-	`, cfg.FromName, code, cfg.FromName)
-
-	return e.sendMail(toEmail, subject, body)
+func NewEmailService(m mailer.Mailer, templates *mailer.Templates, redisClient *dao.RedisClient, config *configs.Config, logger *slog.Logger) *EmailService {
+	return &EmailService{
+		mailer:      m,
+		templates:   templates,
+		redisClient: redisClient,
+		config:      config,
+		logger:      logger,
+	}
 }
 
-// SendResetPasswordMail 发送重置密码邮件的便捷函数
-func (e *EmailService) SendResetPasswordMail(toEmail, code string) error {
-	cfg := e.config.SMTP
-	subject := fmt.Sprintf("[%s] 您的密码重置请求", cfg.FromName)
-	body := fmt.Sprintf(`
-    <html><body>
-        <h3>您好！</h3>
-        <p>我们收到了您在 <strong>%s</strong> 的密码重置请求。您的验证码是：</p>
-        <h2 style="font-weight: bold; color: #FF4500;">%s</h2>
-        <p>此验证码将在10分钟内失效。请使用此验证码来设置您的新密码。</p>
-        <p>如果这不是您本人的操作，请忽略此邮件。</p>
-    </body></html>
-    `, cfg.FromName, code)
-	return e.sendMail(toEmail, subject, body)
+// SendRegisterMail 发送注册验证码，ttl 是验证码的实际有效期，lang 可以直接传 Accept-Language 请求头
+func (e *EmailService) SendRegisterMail(ctx context.Context, lang, toEmail, code string, ttl time.Duration) error {
+	return e.enqueue(ctx, mailTemplateReg, lang, toEmail, e.codeData(code, ttl))
 }
 
-// sendMail 是底层的邮件发送实现
-// 它不关心邮件内容，只负责发送
-func (e *EmailService) sendMail(toEmail, subject, body string) error {
-	// 从全局配置中获取 SMTP 信息
-	cfg := e.config.SMTP
-
-	m := gomail.NewMessage()
+// SendResetPasswordMail 发送重置密码验证码
+func (e *EmailService) SendResetPasswordMail(ctx context.Context, lang, toEmail, code string, ttl time.Duration) error {
+	return e.enqueue(ctx, mailTemplateReset, lang, toEmail, e.codeData(code, ttl))
+}
 
-	// 设置发件人
-	// m.FormatAddress() 可以同时设置邮箱地址和发件人名称，避免乱码
-	m.SetHeader("From", m.FormatAddress(cfg.Username, cfg.FromName))
+// SendReportResultMail 通知举报人举报的处理结果，使用默认语言
+func (e *EmailService) SendReportResultMail(ctx context.Context, toEmail, targetDesc string, resolved bool, note string) error {
+	return e.enqueue(ctx, mailTemplateReport, e.config.Mail.DefaultLang, toEmail, map[string]any{
+		"Site":     e.config.SMTP.FromName,
+		"Target":   targetDesc,
+		"Resolved": resolved,
+		"Note":     note,
+	})
+}
 
-	// 设置收件人
-	m.SetHeader("To", toEmail)
+func (e *EmailService) codeData(code string, ttl time.Duration) map[string]any {
+	return map[string]any{
+		"Site":       e.config.SMTP.FromName,
+		"Code":       code,
+		"TTLMinutes": int(ttl.Minutes()),
+	}
+}
 
-	// 设置邮件主题
-	m.SetHeader("Subject", subject)
+// enqueue 渲染模板后放入发送队列，模板出错会立即返回，发送结果不影响调用方
+func (e *EmailService) enqueue(ctx context.Context, name, lang, toEmail string, data map[string]any) error {
+	msg, err := e.templates.Render(name, lang, data)
+	if err != nil {
+		return err
+	}
+	msg.To = toEmail
+
+	job, err := json.Marshal(&mailJob{
+		ID:        uuid.New().String(),
+		Message:   msg,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return e.redisClient.PushMailJob(ctx, string(job))
+}
 
-	// 设置邮件正文，指定为 HTML 格式
-	m.SetBody("text/html", body)
+// DeliverNext 从发送队列取出一封邮件发送，队列为空时最多等待 wait
+// 发送失败时按指数退避放入重试集合，超过最大次数后放入死信列表；只有访问 Redis 出错才返回错误
+func (e *EmailService) DeliverNext(ctx context.Context, wait time.Duration) error {
+	payload, err := e.redisClient.PopMailJob(ctx, wait)
+	if err != nil || payload == "" {
+		return err
+	}
+
+	var job mailJob
+	if err := json.Unmarshal([]byte(payload), &job); err != nil || job.Message == nil {
+		e.logger.ErrorContext(ctx, "无法解析的邮件任务, 放入死信列表", "payload", payload)
+		return e.redisClient.PushDeadMail(ctx, payload)
+	}
+
+	// 已经出队的邮件不跟随 ctx 取消，退出时也要发完，否则会丢失
+	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+	defer cancel()
+	err = e.mailer.Send(sendCtx, job.Message)
+	if err == nil {
+		return nil
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	retry, _ := json.Marshal(&job)
+	if job.Attempts >= e.config.Mail.MaxAttempts {
+		e.logger.ErrorContext(ctx, "邮件发送失败且不再重试", "mail_id", job.ID, "to", job.Message.To, "attempts", job.Attempts, "err", err)
+		return e.redisClient.PushDeadMail(ctx, string(retry))
+	}
+	delay := e.retryDelay(job.Attempts)
+	e.logger.WarnContext(ctx, "邮件发送失败, 稍后重试", "mail_id", job.ID, "to", job.Message.To, "attempts", job.Attempts, "retry_in", delay, "err", err)
+	return e.redisClient.ScheduleMailRetry(ctx, string(retry), time.Now().Add(delay))
+}
 
-	// 创建一个拨号器，用于连接 SMTP 服务器
-	// 参数：主机、端口、发件人邮箱、授权码
-	d := gomail.NewDialer(cfg.Host, cfg.Port, cfg.Username, cfg.Password)
+// retryDelay 第 attempts 次失败后等待的时间：retryBaseSeconds * 2^(attempts-1)，不超过 retryMaxSeconds
+func (e *EmailService) retryDelay(attempts int) time.Duration {
+	base := time.Duration(e.config.Mail.RetryBaseSeconds) * time.Second
+	limit := time.Duration(e.config.Mail.RetryMaxSeconds) * time.Second
+	delay := base
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
 
-	// 发送邮件
-	// d.DialAndSend() 会自动处理连接、认证和发送的全过程
-	return d.DialAndSend(m)
+// RequeueDueRetries 把到了重试时间的邮件移回发送队列
+func (e *EmailService) RequeueDueRetries(ctx context.Context) {
+	for {
+		n, err := e.redisClient.RequeueDueMails(ctx, time.Now(), mailRequeueBatch)
+		if err != nil {
+			e.logger.ErrorContext(ctx, "移回重试邮件失败", "err", err)
+			return
+		}
+		if n < mailRequeueBatch {
+			return
+		}
+	}
 }
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"Nuxus/configs"
+	"Nuxus/internal/dao"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/erru"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	fanoutBatchSize  = 500 // 推送收件箱时每批处理的粉丝数
+	feedBackfillSize = 20  // 关注后回填到收件箱的帖子数
+)
+
+// FeedService 关注时间线
+// 普通作者发帖时写扩散：把帖子推送到每个粉丝在 Redis 中的收件箱；
+// 粉丝数超过阈值的作者和关注的标签读扩散：读取时间线时再从数据库拉取，和收件箱合并。
+type FeedService struct {
+	followDAO           *dao.FollowDAO
+	postDAO             *dao.PostDAO
+	userDAO             *dao.UserDAO
+	redisClient         *dao.RedisClient
+	notificationService *NotificationService
+	config              *configs.Config
+	logger              *slog.Logger
+}
+
+func NewFeedService(followDAO *dao.FollowDAO, postDAO *dao.PostDAO, userDAO *dao.UserDAO, redisClient *dao.RedisClient, notificationService *NotificationService, config *configs.Config, logger *slog.Logger) *FeedService {
+	return &FeedService{
+		followDAO:           followDAO,
+		postDAO:             postDAO,
+		userDAO:             userDAO,
+		redisClient:         redisClient,
+		notificationService: notificationService,
+		config:              config,
+		logger:              logger,
+	}
+}
+
+// isFanoutAuthor 判断作者是否采用写扩散
+func (f *FeedService) isFanoutAuthor(followerCount int) bool {
+	return followerCount <= f.config.Feed.FanoutThreshold
+}
+
+func (f *FeedService) inboxSize() int64 {
+	return int64(f.config.Feed.InboxSize)
+}
+
+// FanOutPost 把新帖子推送到作者所有粉丝的收件箱，失败只记录日志
+func (f *FeedService) FanOutPost(ctx context.Context, post *models.Post) {
+	author, err := f.userDAO.GetUserById(ctx, post.UserID)
+	if err != nil {
+		f.logger.ErrorContext(ctx, "推送时间线失败", "post_id", post.ID, "err", err)
+		return
+	}
+	if !f.isFanoutAuthor(author.FollowerCount) {
+		return
+	}
+
+	entry := dao.FeedEntry{PostID: post.ID, Score: post.CreatedAt.UnixMilli()}
+	var afterID uint
+	for {
+		followerIDs, err := f.followDAO.ListFollowerIDs(ctx, post.UserID, afterID, fanoutBatchSize)
+		if err != nil {
+			f.logger.ErrorContext(ctx, "推送时间线失败", "post_id", post.ID, "err", err)
+			return
+		}
+		if len(followerIDs) == 0 {
+			return
+		}
+		if err := f.redisClient.PushToInboxes(ctx, followerIDs, entry, f.inboxSize()); err != nil {
+			f.logger.ErrorContext(ctx, "推送时间线失败", "post_id", post.ID, "err", err)
+			return
+		}
+		afterID = followerIDs[len(followerIDs)-1]
+	}
+}
+
+// NotifyTagSubscribers 通知帖子所带标签的关注者，同时关注了多个标签的用户只通知一次
+// 同一标签的新帖子在未读期间会合并成一条摘要通知
+func (f *FeedService) NotifyTagSubscribers(ctx context.Context, post *models.Post, tags []*models.Tag) {
+	notified := make(map[uint]bool)
+	for _, tag := range tags {
+		var afterID uint
+		for {
+			userIDs, err := f.followDAO.ListTagSubscriberIDs(ctx, tag.ID, afterID, fanoutBatchSize)
+			if err != nil {
+				f.logger.ErrorContext(ctx, "通知标签关注者失败", "post_id", post.ID, "tag_id", tag.ID, "err", err)
+				break
+			}
+			if len(userIDs) == 0 {
+				break
+			}
+			for _, userID := range userIDs {
+				if notified[userID] {
+					continue
+				}
+				notified[userID] = true
+				f.notificationService.Notify(ctx, &NotifyEvent{
+					RecipientID: userID,
+					ActorID:     post.UserID,
+					Type:        models.NotifyTypeTagPost,
+					PostID:      post.ID,
+					TagID:       tag.ID,
+					Content:     post.Title,
+				})
+			}
+			afterID = userIDs[len(userIDs)-1]
+		}
+	}
+}
+
+// backfillInbox 关注写扩散作者后，把他最近的帖子补进收件箱
+func (f *FeedService) backfillInbox(ctx context.Context, userID uint, followee *models.User) error {
+	if !f.isFanoutAuthor(followee.FollowerCount) {
+		return nil
+	}
+	posts, err := f.postDAO.ListPostRefsByAuthors(ctx, []uint{followee.ID}, time.Now(), feedBackfillSize)
+	if err != nil {
+		return err
+	}
+	return f.redisClient.AddInboxEntries(ctx, userID, postRefs2Entries(posts), f.inboxSize())
+}
+
+// purgeInbox 取消关注后，把该作者的帖子从收件箱中移除
+func (f *FeedService) purgeInbox(ctx context.Context, userID uint, followeeID uint) error {
+	posts, err := f.postDAO.ListPostRefsByAuthors(ctx, []uint{followeeID}, time.Now(), int(f.inboxSize()))
+	if err != nil {
+		return err
+	}
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+	return f.redisClient.RemoveInboxEntries(ctx, userID, postIDs)
+}
+
+// ensureInbox 收件箱不存在时（新用户、Redis 数据丢失）从数据库重建
+func (f *FeedService) ensureInbox(ctx context.Context, userID uint, authors []*dao.FollowingAuthor) error {
+	exists, err := f.redisClient.InboxExists(ctx, userID)
+	if err != nil || exists {
+		return err
+	}
+
+	authorIDs := make([]uint, 0, len(authors))
+	for _, author := range authors {
+		if f.isFanoutAuthor(author.FollowerCount) {
+			authorIDs = append(authorIDs, author.ID)
+		}
+	}
+	posts, err := f.postDAO.ListPostRefsByAuthors(ctx, authorIDs, time.Now(), int(f.inboxSize()))
+	if err != nil {
+		return err
+	}
+	return f.redisClient.AddInboxEntries(ctx, userID, postRefs2Entries(posts), f.inboxSize())
+}
+
+func postRefs2Entries(posts []*models.Post) []dao.FeedEntry {
+	entries := make([]dao.FeedEntry, 0, len(posts))
+	for _, post := range posts {
+		entries = append(entries, dao.FeedEntry{PostID: post.ID, Score: post.CreatedAt.UnixMilli()})
+	}
+	return entries
+}
+
+// feedCursor 时间线游标，格式为 "<发布时间毫秒>_<帖子ID>"，返回严格排在游标之后的帖子
+type feedCursor struct {
+	ms int64
+	id uint
+}
+
+func parseFeedCursor(cursor string) (feedCursor, error) {
+	if cursor == "" {
+		// 没有游标时从当前时间开始，留一点余量容忍时钟误差
+		return feedCursor{ms: time.Now().Add(time.Minute).UnixMilli(), id: ^uint(0)}, nil
+	}
+	msStr, idStr, ok := strings.Cut(cursor, "_")
+	if !ok {
+		return feedCursor{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	ms, err := strconv.ParseInt(msStr, 10, 64)
+	if err != nil {
+		return feedCursor{}, err
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return feedCursor{}, err
+	}
+	return feedCursor{ms: ms, id: uint(id)}, nil
+}
+
+func (c feedCursor) String() string {
+	return fmt.Sprintf("%d_%d", c.ms, c.id)
+}
+
+// before 判断 c 在时间线上是否排在 other 之后（更早）
+func (c feedCursor) before(other feedCursor) bool {
+	if c.ms != other.ms {
+		return c.ms < other.ms
+	}
+	return c.id < other.id
+}
+
+// Timeline 读取用户的关注时间线，返回本页帖子和下一页的游标，没有更多时游标为空
+func (f *FeedService) Timeline(ctx context.Context, userID uint, cursor string, size int) ([]*models.Post, string, error) {
+	cur, err := parseFeedCursor(cursor)
+	if err != nil {
+		return nil, "", erru.ErrInvalidParams.WithMsg("无效的游标")
+	}
+
+	authors, err := f.followDAO.ListFollowingAuthors(ctx, userID)
+	if err != nil {
+		return nil, "", erru.ErrInternalServer.Wrap(err)
+	}
+	if cursor == "" {
+		if err := f.ensureInbox(ctx, userID, authors); err != nil {
+			return nil, "", erru.ErrInternalServer.Wrap(err)
+		}
+	}
+
+	// 每个来源都多取一些，合并去重后仍然够一页
+	fetch := size * 2
+	candidates := make(map[uint]int64)
+
+	// 1. 收件箱：写扩散作者的帖子
+	entries, err := f.redisClient.ReadInbox(ctx, userID, cur.ms, int64(fetch))
+	if err != nil {
+		return nil, "", erru.ErrInternalServer.Wrap(err)
+	}
+	for _, entry := range entries {
+		candidates[entry.PostID] = entry.Score
+	}
+
+	// 2. 读扩散作者的帖子
+	pullAuthorIDs := make([]uint, 0)
+	for _, author := range authors {
+		if !f.isFanoutAuthor(author.FollowerCount) {
+			pullAuthorIDs = append(pullAuthorIDs, author.ID)
+		}
+	}
+	beforeTime := time.UnixMilli(cur.ms)
+	posts, err := f.postDAO.ListPostRefsByAuthors(ctx, pullAuthorIDs, beforeTime, fetch)
+	if err != nil {
+		return nil, "", erru.ErrInternalServer.Wrap(err)
+	}
+	for _, post := range posts {
+		candidates[post.ID] = post.CreatedAt.UnixMilli()
+	}
+
+	// 3. 关注标签下的帖子
+	tagIDs, err := f.followDAO.ListSubscribedTagIDs(ctx, userID)
+	if err != nil {
+		return nil, "", erru.ErrInternalServer.Wrap(err)
+	}
+	posts, err = f.postDAO.ListPostRefsByTags(ctx, tagIDs, beforeTime, fetch)
+	if err != nil {
+		return nil, "", erru.ErrInternalServer.Wrap(err)
+	}
+	for _, post := range posts {
+		candidates[post.ID] = post.CreatedAt.UnixMilli()
+	}
+
+	return f.paginate(ctx, cur, candidates, size)
+}
+
+// TagTimeline 只包含关注标签的时间线，tagID 不为 0 时只看其中一个标签
+func (f *FeedService) TagTimeline(ctx context.Context, userID uint, tagID uint, cursor string, size int) ([]*models.Post, string, error) {
+	cur, err := parseFeedCursor(cursor)
+	if err != nil {
+		return nil, "", erru.ErrInvalidParams.WithMsg("无效的游标")
+	}
+
+	tagIDs, err := f.followDAO.ListSubscribedTagIDs(ctx, userID)
+	if err != nil {
+		return nil, "", erru.ErrInternalServer.Wrap(err)
+	}
+	if tagID != 0 {
+		if !slices.Contains(tagIDs, tagID) {
+			return nil, "", erru.New("尚未关注该标签")
+		}
+		tagIDs = []uint{tagID}
+	}
+
+	posts, err := f.postDAO.ListPostRefsByTags(ctx, tagIDs, time.UnixMilli(cur.ms), size*2)
+	if err != nil {
+		return nil, "", erru.ErrInternalServer.Wrap(err)
+	}
+	candidates := make(map[uint]int64, len(posts))
+	for _, post := range posts {
+		candidates[post.ID] = post.CreatedAt.UnixMilli()
+	}
+	return f.paginate(ctx, cur, candidates, size)
+}
+
+// paginate 把候选帖子（ID -> 发布时间）按时间线排序，取游标之后的一页
+func (f *FeedService) paginate(ctx context.Context, cur feedCursor, candidates map[uint]int64, size int) ([]*models.Post, string, error) {
+	refs := make([]feedCursor, 0, len(candidates))
+	for id, ms := range candidates {
+		ref := feedCursor{ms: ms, id: id}
+		if ref.before(cur) {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[j].before(refs[i])
+	})
+	hasMore := len(refs) > size
+	if hasMore {
+		refs = refs[:size]
+	}
+
+	timeline, err := f.loadPosts(ctx, refs)
+	if err != nil {
+		return nil, "", erru.ErrInternalServer.Wrap(err)
+	}
+
+	nextCursor := ""
+	if hasMore {
+		nextCursor = refs[len(refs)-1].String()
+	}
+	return timeline, nextCursor, nil
+}
+
+// loadPosts 按时间线顺序加载帖子，已删除或被隐藏的帖子会被跳过
+func (f *FeedService) loadPosts(ctx context.Context, refs []feedCursor) ([]*models.Post, error) {
+	if len(refs) == 0 {
+		return []*models.Post{}, nil
+	}
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, strconv.FormatUint(uint64(ref.id), 10))
+	}
+	posts, err := f.postDAO.GetPostsByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	postsById := make(map[uint]*models.Post, len(posts))
+	for _, post := range posts {
+		postsById[post.ID] = post
+	}
+	timeline := make([]*models.Post, 0, len(posts))
+	for _, ref := range refs {
+		if post, ok := postsById[ref.id]; ok {
+			timeline = append(timeline, post)
+		}
+	}
+	return timeline, nil
+}
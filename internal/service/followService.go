@@ -0,0 +1,120 @@
+package service
+
+import (
+	"Nuxus/internal/dao"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/erru"
+	"context"
+	"errors"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type FollowService struct {
+	followDAO   *dao.FollowDAO
+	userDAO     *dao.UserDAO
+	tagDAO      *dao.TagDAO
+	feedService *FeedService
+	logger      *slog.Logger
+}
+
+func NewFollowService(followDAO *dao.FollowDAO, userDAO *dao.UserDAO, tagDAO *dao.TagDAO, feedService *FeedService, logger *slog.Logger) *FollowService {
+	return &FollowService{
+		followDAO:   followDAO,
+		userDAO:     userDAO,
+		tagDAO:      tagDAO,
+		feedService: feedService,
+		logger:      logger,
+	}
+}
+
+func (f *FollowService) getUser(ctx context.Context, userID uint) (*models.User, error) {
+	user, err := f.userDAO.GetUserById(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erru.ErrUserNotFound
+		}
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	return user, nil
+}
+
+// -------------------关注用户--------------------------------
+func (f *FollowService) FollowUser(ctx context.Context, userID, targetID uint) error {
+	if userID == targetID {
+		return erru.New("不能关注自己")
+	}
+	target, err := f.getUser(ctx, targetID)
+	if err != nil {
+		return err
+	}
+
+	created, err := f.followDAO.CreateFollow(ctx, userID, targetID)
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if created {
+		// 回填失败不影响关注结果，之后的新帖子仍会正常推送
+		if err := f.feedService.backfillInbox(ctx, userID, target); err != nil {
+			f.logger.ErrorContext(ctx, "回填时间线失败", "followee", targetID, "err", err)
+		}
+	}
+	return nil
+}
+
+func (f *FollowService) UnfollowUser(ctx context.Context, userID, targetID uint) error {
+	deleted, err := f.followDAO.DeleteFollow(ctx, userID, targetID)
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if deleted {
+		if err := f.feedService.purgeInbox(ctx, userID, targetID); err != nil {
+			f.logger.ErrorContext(ctx, "清理时间线失败", "followee", targetID, "err", err)
+		}
+	}
+	return nil
+}
+
+func (f *FollowService) ListFollowers(ctx context.Context, userID uint, page, size int) ([]*models.User, int64, error) {
+	if _, err := f.getUser(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+	users, total, err := f.followDAO.ListFollowers(ctx, userID, page, size)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+	return users, total, nil
+}
+
+func (f *FollowService) ListFollowing(ctx context.Context, userID uint, page, size int) ([]*models.User, int64, error) {
+	if _, err := f.getUser(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+	users, total, err := f.followDAO.ListFollowing(ctx, userID, page, size)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+	return users, total, nil
+}
+
+// -------------------关注标签--------------------------------
+func (f *FollowService) FollowTag(ctx context.Context, userID, tagID uint) error {
+	if _, err := f.tagDAO.GetTagById(ctx, tagID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if _, err := f.followDAO.CreateTagSubscription(ctx, userID, tagID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	return nil
+}
+
+func (f *FollowService) UnfollowTag(ctx context.Context, userID, tagID uint) error {
+	if err := f.followDAO.DeleteTagSubscription(ctx, userID, tagID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	return nil
+}
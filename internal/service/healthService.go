@@ -0,0 +1,69 @@
+package service
+
+import (
+	"Nuxus/internal/dao"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout 单个依赖检查的超时时间，探针的超时一般只有几秒
+const healthCheckTimeout = 2 * time.Second
+
+// 检查结果
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// HealthService 检查服务依赖的 MySQL 和 Redis 是否可用
+type HealthService struct {
+	repository  *dao.Repository
+	redisClient *dao.RedisClient
+	logger      *slog.Logger
+}
+
+func NewHealthService(repository *dao.Repository, redisClient *dao.RedisClient, logger *slog.Logger) *HealthService {
+	return &HealthService{
+		repository:  repository,
+		redisClient: redisClient,
+		logger:      logger,
+	}
+}
+
+// CheckDependencies 并发检查所有依赖，返回每个依赖的结果以及是否全部可用
+// 失败原因只记录日志，不返回给调用方
+func (h *HealthService) CheckDependencies(ctx context.Context) (map[string]string, bool) {
+	checks := map[string]func(context.Context) error{
+		"mysql": h.repository.Ping,
+		"redis": h.redisClient.Ping,
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]string, len(checks))
+		healthy = true
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			err := check(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = HealthOK
+			if err != nil {
+				h.logger.WarnContext(ctx, "依赖检查失败", "dependency", name, "err", err)
+				results[name] = HealthFail
+				healthy = false
+			}
+		}()
+	}
+	wg.Wait()
+	return results, healthy
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"Nuxus/internal/dao"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/erru"
+	"Nuxus/pkg/utils"
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// 单条内容最多解析的不同用户数，防止刷提及
+const maxMentionUsers = 20
+
+type MentionService struct {
+	mentionDAO          *dao.MentionDAO
+	userDAO             *dao.UserDAO
+	notificationService *NotificationService
+	background          *Background
+	logger              *slog.Logger
+}
+
+func NewMentionService(mentionDAO *dao.MentionDAO, userDAO *dao.UserDAO, notificationService *NotificationService, background *Background, logger *slog.Logger) *MentionService {
+	return &MentionService{
+		mentionDAO:          mentionDAO,
+		userDAO:             userDAO,
+		notificationService: notificationService,
+		background:          background,
+		logger:              logger,
+	}
+}
+
+// SyncMentions 解析内容中的 @用户名 并保存，返回解析成功的提及
+// 帖子正文传 commentID 为 0。只有新出现的用户会收到提及通知，编辑内容不会重复通知。
+// 提及是附带功能，失败只记录日志，返回 nil
+func (m *MentionService) SyncMentions(ctx context.Context, actorID, postID, commentID uint, content string) []*models.Mention {
+	mentions, newUserIDs, err := m.syncMentions(ctx, actorID, postID, commentID, content)
+	if err != nil {
+		m.logger.ErrorContext(ctx, "保存提及失败", "post_id", postID, "comment_id", commentID, "err", err)
+		return nil
+	}
+
+	for _, userID := range newUserIDs {
+		var excerpt string
+		for _, mention := range mentions {
+			if mention.UserID == userID {
+				excerpt = mention.Excerpt
+				break
+			}
+		}
+		m.background.Go(ctx, func(ctx context.Context) {
+			m.notificationService.Notify(ctx, &NotifyEvent{
+				RecipientID: userID,
+				ActorID:     actorID,
+				Type:        models.NotifyTypeMention,
+				PostID:      postID,
+				CommentID:   commentID,
+				Content:     excerpt,
+			})
+		})
+	}
+	return mentions
+}
+
+func (m *MentionService) syncMentions(ctx context.Context, actorID, postID, commentID uint, content string) ([]*models.Mention, []uint, error) {
+	spans := utils.ExtractMentions(content)
+
+	// 用户名不区分大小写，与数据库的排序规则保持一致
+	usernames := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, span := range spans {
+		key := strings.ToLower(span.Username)
+		if seen[key] || len(usernames) >= maxMentionUsers {
+			continue
+		}
+		seen[key] = true
+		usernames = append(usernames, span.Username)
+	}
+
+	users, err := m.userDAO.GetUsersByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, nil, err
+	}
+	usersByName := make(map[string]*models.User, len(users))
+	for _, user := range users {
+		usersByName[strings.ToLower(user.Username)] = user
+	}
+
+	runes := []rune(content)
+	mentions := make([]*models.Mention, 0, len(spans))
+	for _, span := range spans {
+		user, ok := usersByName[strings.ToLower(span.Username)]
+		if !ok {
+			continue
+		}
+		mentions = append(mentions, &models.Mention{
+			UserID:    user.ID,
+			Username:  user.Username,
+			ActorID:   actorID,
+			PostID:    postID,
+			CommentID: commentID,
+			Start:     span.Start,
+			End:       span.End,
+			Excerpt:   mentionExcerpt(runes, span.Start, span.End),
+		})
+	}
+
+	oldUserIDs, err := m.mentionDAO.ListMentionedUserIDs(ctx, postID, commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.mentionDAO.ReplaceMentions(ctx, postID, commentID, mentions); err != nil {
+		return nil, nil, err
+	}
+
+	notified := make(map[uint]bool, len(oldUserIDs))
+	for _, userID := range oldUserIDs {
+		notified[userID] = true
+	}
+	newUserIDs := make([]uint, 0)
+	for _, mention := range mentions {
+		if !notified[mention.UserID] {
+			notified[mention.UserID] = true
+			newUserIDs = append(newUserIDs, mention.UserID)
+		}
+	}
+	return mentions, newUserIDs, nil
+}
+
+// mentionExcerpt 截取提及处前后的一段内容作为摘要
+func mentionExcerpt(runes []rune, start, end int) string {
+	from := max(0, start-30)
+	to := min(len(runes), end+60)
+	excerpt := strings.Join(strings.Fields(string(runes[from:to])), " ")
+	if from > 0 {
+		excerpt = "…" + excerpt
+	}
+	if to < len(runes) {
+		excerpt += "…"
+	}
+	return excerpt
+}
+
+// ClearMentions 删除帖子正文或某条评论中的提及，用于内容被删除时
+func (m *MentionService) ClearMentions(ctx context.Context, postID, commentID uint) {
+	if err := m.mentionDAO.ReplaceMentions(ctx, postID, commentID, nil); err != nil {
+		m.logger.ErrorContext(ctx, "删除提及失败", "post_id", postID, "comment_id", commentID, "err", err)
+	}
+}
+
+func (m *MentionService) ListPostMentions(ctx context.Context, postID uint) ([]*models.Mention, error) {
+	mentions, err := m.mentionDAO.ListPostMentions(ctx, postID)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	return mentions, nil
+}
+
+func (m *MentionService) ListUserMentions(ctx context.Context, userID uint, page, size int) ([]*models.Mention, int64, error) {
+	mentions, total, err := m.mentionDAO.ListUserMentions(ctx, userID, page, size)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+	return mentions, total, nil
+}
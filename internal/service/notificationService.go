@@ -0,0 +1,179 @@
+package service
+
+import (
+	"Nuxus/internal/dao"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/erru"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	notifyAggregateWindow = 24 * time.Hour   // 未读通知在这段时间内有更新才会继续合并
+	notifyUnreadCacheTTL  = 10 * time.Minute // 未读数缓存时间
+	notifyContentMaxLen   = 100              // 通知摘要的最大长度（字符数）
+)
+
+// NotifyEvent 一次需要通知用户的事件
+type NotifyEvent struct {
+	RecipientID uint
+	ActorID     uint
+	Type        string
+	PostID      uint
+	CommentID   uint
+	TagID       uint
+	Content     string
+}
+
+type NotificationService struct {
+	notificationDAO *dao.NotificationDAO
+	userDAO         *dao.UserDAO
+	redisClient     *dao.RedisClient
+	logger          *slog.Logger
+}
+
+func NewNotificationService(notificationDAO *dao.NotificationDAO, userDAO *dao.UserDAO, redisClient *dao.RedisClient, logger *slog.Logger) *NotificationService {
+	return &NotificationService{
+		notificationDAO: notificationDAO,
+		userDAO:         userDAO,
+		redisClient:     redisClient,
+		logger:          logger,
+	}
+}
+
+// Notify 投递一条通知
+// 通知是附带功能，失败只记录日志，不影响触发它的业务操作
+func (n *NotificationService) Notify(ctx context.Context, event *NotifyEvent) {
+	if err := n.notify(ctx, event); err != nil {
+		n.logger.ErrorContext(ctx, "投递通知失败", "type", event.Type, "recipient", event.RecipientID, "err", err)
+	}
+}
+
+func (n *NotificationService) notify(ctx context.Context, event *NotifyEvent) error {
+	// 自己的操作不通知自己
+	if event.RecipientID == 0 || event.RecipientID == event.ActorID {
+		return nil
+	}
+
+	recipient, err := n.userDAO.GetUserById(ctx, event.RecipientID)
+	if err != nil {
+		return err
+	}
+	if recipient.IsNotificationMuted(event.Type) {
+		return nil
+	}
+
+	content := abbreviate(event.Content, notifyContentMaxLen)
+	groupKey := notifyGroupKey(event)
+
+	// 可聚合的通知优先合并进最近的未读通知，未读数不变
+	if groupKey != "" {
+		existing, err := n.notificationDAO.FindAggregatable(ctx, event.RecipientID, groupKey, time.Now().Add(-notifyAggregateWindow))
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			newActor, err := n.notificationDAO.AddActor(ctx, existing.ID, event.ActorID)
+			if err != nil {
+				return err
+			}
+			return n.notificationDAO.BumpNotification(ctx, existing.ID, event.ActorID, event.PostID, content, newActor)
+		}
+	}
+
+	notification := &models.Notification{
+		UserID:     event.RecipientID,
+		Type:       event.Type,
+		PostID:     event.PostID,
+		CommentID:  event.CommentID,
+		TagID:      event.TagID,
+		Content:    content,
+		GroupKey:   groupKey,
+		ActorID:    event.ActorID,
+		ActorCount: 1,
+		EventCount: 1,
+	}
+	if err := n.notificationDAO.CreateNotification(ctx, notification); err != nil {
+		return err
+	}
+	if groupKey != "" {
+		if _, err := n.notificationDAO.AddActor(ctx, notification.ID, event.ActorID); err != nil {
+			return err
+		}
+	}
+	return n.redisClient.DelUnreadCount(ctx, event.RecipientID)
+}
+
+// notifyGroupKey 点赞、收藏按帖子聚合，标签新帖按标签聚合，其它类型每次单独通知
+func notifyGroupKey(event *NotifyEvent) string {
+	switch event.Type {
+	case models.NotifyTypeLike, models.NotifyTypeFavorite:
+		return fmt.Sprintf("%s:post:%d", event.Type, event.PostID)
+	case models.NotifyTypeTagPost:
+		return fmt.Sprintf("%s:tag:%d", event.Type, event.TagID)
+	default:
+		return ""
+	}
+}
+
+func abbreviate(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen]) + "…"
+}
+
+// -------------------通知中心--------------------------------
+func (n *NotificationService) ListNotifications(ctx context.Context, userID uint, unreadOnly bool, page, size int) ([]*models.Notification, int64, error) {
+	notifications, total, err := n.notificationDAO.ListNotifications(ctx, userID, unreadOnly, page, size)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+	return notifications, total, nil
+}
+
+// UnreadCount 查询未读通知数，优先读缓存
+func (n *NotificationService) UnreadCount(ctx context.Context, userID uint) (int64, error) {
+	count, ok, err := n.redisClient.GetUnreadCount(ctx, userID)
+	if err != nil {
+		n.logger.WarnContext(ctx, "读取未读通知数缓存失败", "err", err)
+	}
+	if ok {
+		return count, nil
+	}
+
+	count, err = n.notificationDAO.CountUnread(ctx, userID)
+	if err != nil {
+		return 0, erru.ErrInternalServer.Wrap(err)
+	}
+	if err := n.redisClient.SetUnreadCount(ctx, userID, count, notifyUnreadCacheTTL); err != nil {
+		n.logger.WarnContext(ctx, "写入未读通知数缓存失败", "err", err)
+	}
+	return count, nil
+}
+
+func (n *NotificationService) MarkRead(ctx context.Context, userID, notificationID uint) error {
+	affected, err := n.notificationDAO.MarkRead(ctx, userID, notificationID)
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if affected > 0 {
+		if err := n.redisClient.DelUnreadCount(ctx, userID); err != nil {
+			return erru.ErrInternalServer.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (n *NotificationService) MarkAllRead(ctx context.Context, userID uint) error {
+	if err := n.notificationDAO.MarkAllRead(ctx, userID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if err := n.redisClient.DelUnreadCount(ctx, userID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	return nil
+}
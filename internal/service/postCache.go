@@ -0,0 +1,155 @@
+package service
+
+import (
+	"Nuxus/configs"
+	"Nuxus/internal/dao"
+	"Nuxus/internal/dto"
+	"Nuxus/pkg/erru"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// negativeCacheValue 缓存中表示帖子不存在的值，不会和 JSON 冲突
+const negativeCacheValue = "-"
+
+// listCacheMaxSize 每页超过该数量的列表不缓存，避免查询条件组合过多
+const listCacheMaxSize = 50
+
+// PostCache 帖子详情和列表首页的读穿缓存
+// 缓存不存在时同一个 key 只有一个请求访问数据库，其他请求等待它的结果；
+// 不存在的帖子也会缓存一小段时间，防止反复查询不存在的 ID 打到数据库。
+type PostCache struct {
+	redisClient *dao.RedisClient
+	config      *configs.Config
+	logger      *slog.Logger
+	group       singleflight.Group
+}
+
+func NewPostCache(redisClient *dao.RedisClient, config *configs.Config, logger *slog.Logger) *PostCache {
+	return &PostCache{
+		redisClient: redisClient,
+		config:      config,
+		logger:      logger,
+	}
+}
+
+// ttl 在 seconds 秒的基础上随机增加最多 JitterPercent% 的时间
+func (c *PostCache) ttl(seconds int) time.Duration {
+	base := time.Duration(seconds) * time.Second
+	jitter := int64(base) * int64(c.config.Cache.JitterPercent) / 100
+	if jitter <= 0 {
+		return base
+	}
+	return base + time.Duration(rand.Int64N(jitter+1))
+}
+
+// GetPostDetail 读取帖子详情，缓存不存在时调用 load 从数据库加载
+func (c *PostCache) GetPostDetail(ctx context.Context, postID uint, load func(ctx context.Context) (*dto.PostDetailResDTO, error)) (*dto.PostDetailResDTO, error) {
+	versionKey, dataPrefix := dao.PostCacheKeys(postID)
+	return readThrough(ctx, c, versionKey, dataPrefix, c.config.Cache.PostTTLSeconds, true, load)
+}
+
+// GetPostList 读取帖子列表，只缓存每个标签（或全部帖子）的第一页
+func (c *PostCache) GetPostList(ctx context.Context, reqDto *dto.ListPostsReqDTO, load func(ctx context.Context) (*dto.ListPostsResDTO, error)) (*dto.ListPostsResDTO, error) {
+	if reqDto.Page != 1 || reqDto.Size > listCacheMaxSize {
+		return load(ctx)
+	}
+	versionKey, dataPrefix := dao.PostListCacheKeys(fmt.Sprintf("tag=%s&size=%d", reqDto.Tag, reqDto.Size))
+	return readThrough(ctx, c, versionKey, dataPrefix, c.config.Cache.ListTTLSeconds, false, load)
+}
+
+// InvalidatePost 帖子的计数（点赞、收藏、评论）等只影响详情的数据变化后调用。
+// 列表中的计数允许有偏差，等列表缓存过期后更新
+func (c *PostCache) InvalidatePost(ctx context.Context, postID uint) {
+	c.invalidate(ctx, postID, false)
+}
+
+// InvalidatePostAndLists 帖子发布、编辑、删除、隐藏、归档、置顶等影响列表内容或顺序的变化后调用，同时使所有列表缓存失效
+func (c *PostCache) InvalidatePostAndLists(ctx context.Context, postID uint) {
+	c.invalidate(ctx, postID, true)
+}
+
+func (c *PostCache) invalidate(ctx context.Context, postID uint, lists bool) {
+	if !c.config.Cache.Enabled {
+		return
+	}
+	versionKeys := make([]string, 0, 2)
+	versionKey, _ := dao.PostCacheKeys(postID)
+	versionKeys = append(versionKeys, versionKey)
+	if lists {
+		versionKeys = append(versionKeys, dao.KeyPostListCacheVersion)
+	}
+	if err := c.redisClient.BumpCacheVersions(ctx, versionKeys...); err != nil {
+		c.logger.ErrorContext(ctx, "清除帖子缓存失败", "post_id", postID, "err", err)
+	}
+}
+
+// InvalidateLists 标签改名、合并等影响多个帖子的操作后调用，帖子详情等待缓存过期
+func (c *PostCache) InvalidateLists(ctx context.Context) {
+	if !c.config.Cache.Enabled {
+		return
+	}
+	if err := c.redisClient.BumpCacheVersions(ctx, dao.KeyPostListCacheVersion); err != nil {
+		c.logger.ErrorContext(ctx, "清除帖子列表缓存失败", "err", err)
+	}
+}
+
+// readThrough 先读缓存，不存在时调用 load 加载并写入缓存。
+// negative 为 true 时 load 返回资源不存在也会被缓存
+func readThrough[T any](ctx context.Context, c *PostCache, versionKey, dataPrefix string, ttlSeconds int, negative bool,
+	load func(ctx context.Context) (*T, error)) (*T, error) {
+	if !c.config.Cache.Enabled {
+		return load(ctx)
+	}
+
+	// 读取缓存失败时直接查询数据库，Redis 故障不影响读取
+	version, data, ok, err := c.redisClient.GetVersionedCache(ctx, versionKey, dataPrefix)
+	if err != nil {
+		c.logger.WarnContext(ctx, "读取帖子缓存失败", "key", dataPrefix, "err", err)
+		return load(ctx)
+	}
+	if ok {
+		if negative && string(data) == negativeCacheValue {
+			return nil, erru.ErrResourceNotFound
+		}
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			return &value, nil
+		}
+	}
+
+	key := fmt.Sprintf("%s%d", dataPrefix, version)
+	result, err, _ := c.group.Do(key, func() (any, error) {
+		// 多个请求共用这次加载，不能因为第一个请求取消而让其他请求失败
+		loadCtx := context.WithoutCancel(ctx)
+		value, err := load(loadCtx)
+		if err != nil {
+			var appErr *erru.AppError
+			if negative && errors.As(err, &appErr) && appErr.Code == erru.ResourceNotFound {
+				if err := c.redisClient.SetVersionedCache(loadCtx, dataPrefix, version, []byte(negativeCacheValue), c.ttl(c.config.Cache.NegativeTTLSeconds)); err != nil {
+					c.logger.WarnContext(loadCtx, "写入帖子缓存失败", "key", key, "err", err)
+				}
+			}
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err == nil {
+			err = c.redisClient.SetVersionedCache(loadCtx, dataPrefix, version, data, c.ttl(ttlSeconds))
+		}
+		if err != nil {
+			c.logger.WarnContext(loadCtx, "写入帖子缓存失败", "key", key, "err", err)
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*T), nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"Nuxus/internal/dto"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/markdown"
+)
+
+// 帖子详情和列表会整体写入缓存，所以由 PostService 转换成响应，控制器中其他返回帖子的接口也使用这些函数
+
+func PostModel2InfoDTO(post *models.Post) *dto.PostInfoResDTO {
+	postInfo := &dto.PostInfoResDTO{
+		ID:    post.ID,
+		Title: post.Title,
+		Author: dto.UserInfoDTO{
+			ID:       post.User.ID,
+			UserName: post.User.Username,
+			Email:    post.User.Email,
+			Avatar:   post.User.Avatar,
+		},
+
+		ViewCount:     post.ViewCount,
+		LikeCount:     post.LikeCount,
+		CommentCount:  post.CommentCount,
+		FavoriteCount: post.FavoriteCount,
+		Excerpt:       post.Excerpt,
+		CreatedAt:     post.CreatedAt,
+	}
+	tags := make([]dto.TagInfoDTO, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		tags = append(tags, *TagModel2InfoDTO(tag))
+	}
+	postInfo.Tags = tags
+	return postInfo
+}
+
+// PostModel2DetailDTO doc 是正文的渲染结果，由 PostService.RenderPost 生成
+func PostModel2DetailDTO(post *models.Post, doc *markdown.Document) *dto.PostDetailResDTO {
+	postInfo := &dto.PostDetailResDTO{
+		ID:    post.ID,
+		Title: post.Title,
+		Author: dto.UserInfoDTO{
+			ID:       post.User.ID,
+			UserName: post.User.Username,
+			Email:    post.User.Email,
+			Avatar:   post.User.Avatar,
+		},
+		Content:       post.Content,
+		HTML:          doc.HTML,
+		TOC:           make([]dto.TOCItemDTO, 0, len(doc.TOC)),
+		ViewCount:     post.ViewCount,
+		LikeCount:     post.LikeCount,
+		CommentCount:  post.CommentCount,
+		FavoriteCount: post.FavoriteCount,
+		Mentions:      MentionModels2DTO(post.Mentions),
+		Status:        post.Status,
+		PublishAt:     post.PublishAt,
+		Revision:      post.Revision,
+		Edited:        post.EditedAt != nil,
+		EditedAt:      post.EditedAt,
+		CreatedAt:     post.CreatedAt,
+		UpdatedAt:     post.UpdatedAt,
+	}
+	for _, heading := range doc.TOC {
+		postInfo.TOC = append(postInfo.TOC, dto.TOCItemDTO{
+			Level: heading.Level,
+			Text:  heading.Text,
+			ID:    heading.ID,
+		})
+	}
+	tags := make([]dto.TagInfoDTO, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		tags = append(tags, *TagModel2InfoDTO(tag))
+	}
+	postInfo.Tags = tags
+	return postInfo
+}
+
+func TagModel2InfoDTO(tag *models.Tag) *dto.TagInfoDTO {
+	return &dto.TagInfoDTO{
+		ID:   tag.ID,
+		Name: tag.Name,
+	}
+}
+
+func MentionModels2DTO(mentions []*models.Mention) []dto.MentionDTO {
+	mentionsDto := make([]dto.MentionDTO, 0, len(mentions))
+	for _, mention := range mentions {
+		mentionsDto = append(mentionsDto, dto.MentionDTO{
+			UserID:   mention.UserID,
+			Username: mention.Username,
+			Start:    mention.Start,
+			End:      mention.End,
+		})
+	}
+	return mentionsDto
+}
@@ -1,89 +1,223 @@
 package service
 
 import (
+	"Nuxus/configs"
 	"Nuxus/internal/dao"
 	"Nuxus/internal/dto"
 	"Nuxus/internal/models"
 	"Nuxus/pkg/erru"
-	"log"
+	"Nuxus/pkg/markdown"
+	"Nuxus/pkg/rbac"
+	"Nuxus/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
 )
 
+// renderCacheTTL 正文渲染结果的缓存时间
+const renderCacheTTL = 24 * time.Hour
+
 type PostService struct {
-	postDAO     *dao.PostDAO
-	tagDAO      *dao.TagDAO
-	repository  *dao.Repository
-	redisClient *dao.RedisClient
+	postDAO             *dao.PostDAO
+	tagDAO              *dao.TagDAO
+	repository          *dao.Repository
+	redisClient         *dao.RedisClient
+	auditService        *AuditService
+	searchBackend       dao.SearchBackend
+	notificationService *NotificationService
+	mentionService      *MentionService
+	feedService         *FeedService
+	revisionDAO         *dao.RevisionDAO
+	attachmentService   *AttachmentService
+	rankService         *RankService
+	postCache           *PostCache
+	background          *Background
+	config              *configs.Config
+	logger              *slog.Logger
 }
 
-func NewPostService(postDAO *dao.PostDAO, tagDAO *dao.TagDAO, repository *dao.Repository, redisClient *dao.RedisClient) *PostService {
+func NewPostService(postDAO *dao.PostDAO, tagDAO *dao.TagDAO, repository *dao.Repository, redisClient *dao.RedisClient, auditService *AuditService, searchBackend dao.SearchBackend, notificationService *NotificationService, mentionService *MentionService, feedService *FeedService, revisionDAO *dao.RevisionDAO, attachmentService *AttachmentService, rankService *RankService, postCache *PostCache, background *Background, config *configs.Config, logger *slog.Logger) *PostService {
 	return &PostService{
-		postDAO:     postDAO,
-		tagDAO:      tagDAO,
-		repository:  repository,
-		redisClient: redisClient,
+		postDAO:             postDAO,
+		tagDAO:              tagDAO,
+		repository:          repository,
+		redisClient:         redisClient,
+		auditService:        auditService,
+		searchBackend:       searchBackend,
+		notificationService: notificationService,
+		mentionService:      mentionService,
+		feedService:         feedService,
+		revisionDAO:         revisionDAO,
+		attachmentService:   attachmentService,
+		rankService:         rankService,
+		postCache:           postCache,
+		background:          background,
+		config:              config,
+		logger:              logger,
 	}
 }
 
-func (p *PostService) ListPosts(reqDto *dto.ListPostsReqDTO) ([]*models.Post, int64, error) {
-	posts, total, err := p.postDAO.ListPosts(reqDto)
+// ListPosts 帖子列表，每个标签（或全部帖子）的第一页经过缓存
+func (p *PostService) ListPosts(ctx context.Context, reqDto *dto.ListPostsReqDTO) (*dto.ListPostsResDTO, error) {
+	return p.postCache.GetPostList(ctx, reqDto, func(ctx context.Context) (*dto.ListPostsResDTO, error) {
+		posts, total, err := p.postDAO.ListPosts(ctx, reqDto)
+		if err != nil {
+			return nil, erru.ErrInternalServer.Wrap(err)
+		}
+		postInfos := make([]dto.PostInfoResDTO, 0, len(posts))
+		for _, post := range posts {
+			postInfos = append(postInfos, *PostModel2InfoDTO(post))
+		}
+		return &dto.ListPostsResDTO{
+			Total: total,
+			Post:  postInfos,
+		}, nil
+	})
+}
+
+// SearchPosts 全文搜索帖子
+func (p *PostService) SearchPosts(ctx context.Context, reqDto *dto.SearchPostsReqDTO) ([]*dao.SearchHit, int64, error) {
+	hits, total, err := p.searchBackend.SearchPosts(ctx, reqDto)
 	if err != nil {
 		return nil, 0, erru.ErrInternalServer.Wrap(err)
 	}
-	return posts, total, nil
+	return hits, total, nil
 }
 
-func (p *PostService) GetPostById(id uint) (*models.Post, error) {
-	post, err := p.postDAO.GetPostById(id)
+func (p *PostService) GetPostById(ctx context.Context, id uint) (*models.Post, error) {
+	post, err := p.postDAO.GetPostById(ctx, id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erru.ErrResourceNotFound
+		}
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
+	// 草稿、被隐藏的帖子对外视同不存在
+	if !post.IsVisible() {
+		return nil, erru.ErrResourceNotFound
+	}
+	post.Mentions, err = p.mentionService.ListPostMentions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
 
-	// 异步更新
-	go func() {
-		// 增加浏览量
-		err := p.redisClient.IncrementPostViewCount(id)
+// GetPostDetail 帖子详情（含渲染后的正文），经过缓存
+func (p *PostService) GetPostDetail(ctx context.Context, id uint) (*dto.PostDetailResDTO, error) {
+	return p.postCache.GetPostDetail(ctx, id, func(ctx context.Context) (*dto.PostDetailResDTO, error) {
+		post, err := p.GetPostById(ctx, id)
 		if err != nil {
-			// TODO:异步错误处理
-			log.Fatal(erru.New("增加浏览量失败"))
-		}
-		// 增加热门积分
-		// 阅读 +10
-		// 点赞 +30
-		// 评论 +20
-		// 收藏 +30
-		err = p.redisClient.IncrementPostRank(id, 10)
+			return nil, err
+		}
+		return PostModel2DetailDTO(post, p.RenderPost(ctx, post)), nil
+	})
+}
+
+// RecordView 记录一次帖子浏览，viewer 标识访客（user:<ID> 或 ip:<IP>），
+// 同一访客在去重窗口内重复浏览只计一次浏览量和热门积分。浏览量只是统计数据，失败时不影响请求
+func (p *PostService) RecordView(ctx context.Context, postID uint, tagIDs []uint, viewer string) {
+	window := time.Duration(p.config.View.DedupMinutes) * time.Minute
+	counted, err := p.redisClient.RecordPostView(ctx, postID, viewer, window)
+	if err != nil {
+		p.logger.ErrorContext(ctx, "记录浏览量失败", "post_id", postID, "err", err)
+		return
+	}
+	if counted {
+		p.rankService.Record(ctx, postID, tagIDs, RankActionView, 1)
+	}
+}
+
+// RenderPost 把帖子正文渲染成 HTML 和目录，已发布的帖子按版本缓存
+// 草稿的内容会在同一个版本号下反复修改，不缓存
+func (p *PostService) RenderPost(ctx context.Context, post *models.Post) *markdown.Document {
+	cacheable := !post.IsDraft()
+	if cacheable {
+		data, ok, err := p.redisClient.GetRenderedPost(ctx, post.ID, post.Revision)
 		if err != nil {
-			log.Fatal(erru.New("增加热门积分失败"))
+			p.logger.WarnContext(ctx, "读取正文渲染缓存失败", "post_id", post.ID, "err", err)
 		}
-	}()
-	return post, nil
+		var doc markdown.Document
+		if ok && json.Unmarshal(data, &doc) == nil {
+			return &doc
+		}
+	}
+
+	doc, err := markdown.Render(post.Content)
+	if err != nil {
+		// 渲染失败时客户端仍然可以使用原始内容
+		p.logger.ErrorContext(ctx, "渲染帖子正文失败", "post_id", post.ID, "err", err)
+		return &markdown.Document{TOC: make([]*markdown.Heading, 0)}
+	}
+	if cacheable {
+		data, _ := json.Marshal(doc)
+		if err := p.redisClient.SetRenderedPost(ctx, post.ID, post.Revision, data, renderCacheTTL); err != nil {
+			p.logger.WarnContext(ctx, "写入正文渲染缓存失败", "post_id", post.ID, "err", err)
+		}
+	}
+	return doc
 }
 
-func (p *PostService) ListPopularPosts(limit int) ([]*models.Post, error) {
+// ListPopularPosts 获取热门榜或按天、周、累计的互动榜单，可以只看某个标签
+func (p *PostService) ListPopularPosts(ctx context.Context, reqDto *dto.ListPopularPostsReqDTO) ([]*models.Post, error) {
+	period := reqDto.Period
+	var tagID uint
+	if reqDto.Tag != "" {
+		// 标签只有按天、周、累计的榜单
+		switch period {
+		case "":
+			period = dao.RankPeriodWeek
+		case dao.RankPeriodHot:
+			return nil, erru.ErrInvalidParams.WithMsg("标签榜单不支持 hot")
+		}
+		tag, err := p.tagDAO.GetTagByName(ctx, reqDto.Tag)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, erru.ErrResourceNotFound
+			}
+			return nil, erru.ErrInternalServer.Wrap(err)
+		}
+		tagID = tag.ID
+	} else if period == "" {
+		period = dao.RankPeriodHot
+	}
+
 	// get popular postIds from redis
-	ids, err := p.redisClient.GetPopularPostIDs(int64(limit))
+	ids, err := p.rankService.RankedPostIDs(ctx, period, tagID, reqDto.Limit)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
-
 	if len(ids) == 0 {
-		return nil, erru.New("没有热门文章")
+		return []*models.Post{}, nil
 	}
 
-	// get post from mysql
-	posts, err := p.postDAO.GetPostsByIds(ids)
+	// get post from mysql，已删除、隐藏、归档的帖子不会返回
+	posts, err := p.postDAO.GetPostsByIds(ctx, ids)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
-
-	return posts, err
+	// 按榜单的顺序返回
+	rank := make(map[string]int, len(ids))
+	for i, id := range ids {
+		rank[id] = i
+	}
+	slices.SortFunc(posts, func(a, b *models.Post) int {
+		return rank[fmt.Sprint(a.ID)] - rank[fmt.Sprint(b.ID)]
+	})
+	return posts, nil
 }
 
-func (p *PostService) CreatePost(userID uint, reqDto *dto.CreatePostReqDTO) (*models.Post, error) {
+func (p *PostService) CreatePost(ctx context.Context, userID uint, reqDto *dto.CreatePostReqDTO) (*models.Post, error) {
 	// find or create Tags
-	tags, err := p.tagDAO.FindOrCreateTagsByNames(reqDto.Tags)
+	tags, err := p.tagDAO.FindOrCreateTagsByNames(ctx, reqDto.Tags)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
@@ -92,76 +226,177 @@ func (p *PostService) CreatePost(userID uint, reqDto *dto.CreatePostReqDTO) (*mo
 
 	post.Title = reqDto.Title
 	post.Content = reqDto.Content
+	post.Excerpt = markdown.Excerpt(reqDto.Content, models.PostExcerptLen)
 	post.UserID = userID
 	post.Tags = tags
+	post.Status = models.PostStatusPublished
+	// 指定了未来的发布时间则等定时任务发布
+	if reqDto.PublishAt != nil && reqDto.PublishAt.After(time.Now()) {
+		post.Status = models.PostStatusScheduled
+		post.PublishAt = reqDto.PublishAt
+	}
 
-	err = p.postDAO.CreatePost(post)
+	err = p.postDAO.CreatePost(ctx, post)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
 
-	fullPost, err := p.postDAO.GetPostById(post.ID)
+	fullPost, err := p.postDAO.GetPostById(ctx, post.ID)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
+	p.attachmentService.SyncPostAttachments(ctx, userID, post.ID, post.Content)
+	if fullPost.Status == models.PostStatusPublished {
+		p.afterPublish(ctx, fullPost)
+	}
 
 	return fullPost, nil
 }
 
-func (p *PostService) UpdatePost(userId uint, postId uint, reqDto dto.UpdatePostReqDTO) (*models.Post, error) {
+// afterPublish 帖子发布后的附带操作：解析 @提及、推送关注时间线、通知标签关注者
+func (p *PostService) afterPublish(ctx context.Context, post *models.Post) {
+	// 发布前访问过的 ID 可能被缓存为不存在
+	p.postCache.InvalidatePostAndLists(ctx, post.ID)
+	post.Mentions = p.mentionService.SyncMentions(ctx, post.UserID, post.ID, 0, post.Content)
+	p.background.Go(ctx, func(ctx context.Context) {
+		p.feedService.FanOutPost(ctx, post)
+		p.feedService.NotifyTagSubscribers(ctx, post, post.Tags)
+	})
+}
+
+func (p *PostService) UpdatePost(ctx context.Context, userId uint, role string, postId uint, reqDto dto.UpdatePostReqDTO) (*models.Post, error) {
 	// 更新逻辑
 	// 1.检查post是否存在
-	// 2.检查是不是当前user的post
+	// 2.检查是不是当前user的post，或者是否有权限编辑他人的post
 	// 3.更新post
 
-	post, err := p.postDAO.GetPostById(postId)
+	post, err := p.postDAO.GetPostById(ctx, postId)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
-	if post.UserID != userId {
-		return nil, erru.ErrUnauthorized
+	onBehalf, err := checkOwnership(userId, role, post.UserID, rbac.PermPostEditAny)
+	if err != nil {
+		return nil, err
 	}
+	if post.IsDraft() {
+		return nil, erru.New("草稿请通过草稿接口保存")
+	}
+	oldTitle := post.Title
 
-	tags, err := p.tagDAO.FindOrCreateTagsByNames(reqDto.Tags)
+	tags, err := p.tagDAO.FindOrCreateTagsByNames(ctx, reqDto.Tags)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
 
-	post.Title = reqDto.Title
-	post.Content = reqDto.Content
-	post.Tags = tags
+	post, err = p.editPost(ctx, userId, postId, reqDto.Title, reqDto.Content, tags, 0)
+	if err != nil {
+		return nil, err
+	}
+	// 版主代为编辑时，提及仍然记在作者名下，也只关联作者自己上传的配图
+	post.Mentions = p.mentionService.SyncMentions(ctx, post.UserID, postId, 0, post.Content)
+	p.attachmentService.SyncPostAttachments(ctx, post.UserID, postId, post.Content)
+	if onBehalf {
+		p.auditService.Record(ctx, userId, role, "post.update", AuditTargetPost, postId, post.UserID,
+			fmt.Sprintf("原标题: %s", oldTitle))
+	}
+	return post, nil
+}
+
+// editPost 修改帖子内容并追加一个版本，内容和标签都没有变化时不产生新版本
+// rollbackFrom 不为 0 表示这次修改是回滚到该版本
+func (p *PostService) editPost(ctx context.Context, editorId uint, postId uint, title, content string, tags []*models.Tag, rollbackFrom int) (*models.Post, error) {
+	err := p.repository.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		post, err := p.postDAO.GetPostForUpdate(tx, postId)
+		if err != nil {
+			return err
+		}
+		if post.Title == title && post.Content == content && slices.Equal(tagNames(post.Tags), tagNames(tags)) {
+			return nil
+		}
+
+		// 引入编辑历史之前发布的帖子没有版本记录，先把当前内容补记为第一个版本
+		count, err := p.revisionDAO.CountRevisions(tx, postId)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			base := currentRevision(post)
+			if post.EditedAt != nil {
+				base.CreatedAt = *post.EditedAt
+			}
+			if err := p.revisionDAO.CreateRevision(tx, base); err != nil {
+				return err
+			}
+		}
 
-	post, err = p.postDAO.UpdatePost(post)
+		now := time.Now()
+		post.Title = title
+		post.Content = content
+		post.Excerpt = markdown.Excerpt(content, models.PostExcerptLen)
+		post.Tags = tags
+		post.Revision++
+		post.EditedAt = &now
+		if err := p.postDAO.UpdatePost(tx, post); err != nil {
+			return err
+		}
+		return p.revisionDAO.CreateRevision(tx, &models.PostRevision{
+			PostID:       postId,
+			Version:      post.Revision,
+			Title:        title,
+			Content:      content,
+			Tags:         tagNames(tags),
+			EditorID:     editorId,
+			RollbackFrom: rollbackFrom,
+			CreatedAt:    now,
+		})
+	})
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	p.postCache.InvalidatePostAndLists(ctx, postId)
+
+	post, err := p.postDAO.GetPostById(ctx, postId)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
 	return post, nil
 }
 
-func (p *PostService) DeletePost(postId uint, userId uint) error {
-	post, err := p.postDAO.GetPostById(postId)
+func (p *PostService) DeletePost(ctx context.Context, postId uint, userId uint, role string) error {
+	post, err := p.postDAO.GetPostById(ctx, postId)
 	if err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
-	if post.UserID != userId {
-		return erru.ErrUnauthorized
+	onBehalf, err := checkOwnership(userId, role, post.UserID, rbac.PermPostDeleteAny)
+	if err != nil {
+		return err
 	}
 
-	return p.postDAO.DeletePost(postId)
+	if err := p.postDAO.DeletePost(ctx, postId); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	p.rankService.RemovePost(ctx, post)
+	p.attachmentService.SyncPostAttachments(ctx, post.UserID, postId, "")
+	p.postCache.InvalidatePostAndLists(ctx, postId)
+	if onBehalf {
+		p.auditService.Record(ctx, userId, role, "post.delete", AuditTargetPost, postId, post.UserID,
+			fmt.Sprintf("标题: %s", post.Title))
+	}
+	return nil
 }
 
 // -------------------评论相关------------------------------
-func (p *PostService) ListComment(postId uint, page int, size int) ([]*models.Comment, int64, error) {
+func (p *PostService) ListComment(ctx context.Context, postId uint, page int, size int) ([]*models.Comment, int64, error) {
 	// 评论列表逻辑
 	// 1.检查帖子是否存在
 	// 2.dao进行分页查询
 
-	_, err := p.postDAO.GetPostById(postId)
+	_, err := p.postDAO.GetPostById(ctx, postId)
 	if err != nil {
 		return nil, 0, erru.ErrInternalServer.Wrap(err)
 	}
 
-	comments, total, err := p.postDAO.ListComment(postId, page, size)
+	comments, total, err := p.postDAO.ListComment(ctx, postId, page, size)
 	if err != nil {
 		return nil, 0, erru.ErrInternalServer.Wrap(err)
 	}
@@ -169,12 +404,99 @@ func (p *PostService) ListComment(postId uint, page int, size int) ([]*models.Co
 	return comments, total, nil
 }
 
-func (p *PostService) CreateComment(req *dto.CreateCommentReqDTO, userId uint, postId uint) (*models.Comment, error) {
+// CommentThread 是评论区的一个楼层：顶级评论及其最早的几条回复
+type CommentThread struct {
+	Comment    *models.Comment
+	Replies    []*models.Comment
+	ReplyCount int64
+}
+
+// ListCommentTree 分页查询楼层，每个楼层内联最早的 replyLimit 条回复
+func (p *PostService) ListCommentTree(ctx context.Context, postId uint, page int, size int, replyLimit int) ([]*CommentThread, int64, error) {
+	_, err := p.postDAO.GetPostById(ctx, postId)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+
+	tops, total, err := p.postDAO.ListTopComments(ctx, postId, page, size)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+
+	rootIds := make([]uint, 0, len(tops))
+	for _, top := range tops {
+		rootIds = append(rootIds, top.ID)
+	}
+
+	counts, err := p.postDAO.CountReplies(ctx, rootIds)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+
+	// 没有回复的楼层不必再查
+	var previews []*models.Comment
+	if replyLimit > 0 && len(counts) > 0 {
+		previews, err = p.postDAO.ListReplyPreviews(ctx, rootIds, replyLimit)
+		if err != nil {
+			return nil, 0, erru.ErrInternalServer.Wrap(err)
+		}
+	}
+	repliesByRoot := make(map[uint][]*models.Comment, len(tops))
+	for _, reply := range previews {
+		repliesByRoot[reply.RootID] = append(repliesByRoot[reply.RootID], reply)
+	}
+
+	threads := make([]*CommentThread, 0, len(tops))
+	for _, top := range tops {
+		threads = append(threads, &CommentThread{
+			Comment:    top,
+			Replies:    repliesByRoot[top.ID],
+			ReplyCount: counts[top.ID],
+		})
+	}
+	return threads, total, nil
+}
+
+// ListReplies 按游标查询楼层内的回复，commentId 可以是楼层内任意一条评论
+// 返回本页回复以及是否还有更多
+func (p *PostService) ListReplies(ctx context.Context, commentId uint, cursor uint, size int) ([]*models.Comment, bool, error) {
+	comment, err := p.postDAO.GetCommentById(ctx, commentId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, erru.ErrResourceNotFound
+		}
+		return nil, false, erru.ErrInternalServer.Wrap(err)
+	}
+
+	rootId := comment.RootID
+	if rootId == 0 {
+		rootId = comment.ID
+	}
+
+	// 多取一条用来判断是否还有下一页
+	replies, err := p.postDAO.ListReplies(ctx, rootId, cursor, size+1)
+	if err != nil {
+		return nil, false, erru.ErrInternalServer.Wrap(err)
+	}
+	hasMore := len(replies) > size
+	if hasMore {
+		replies = replies[:size]
+	}
+	return replies, hasMore, nil
+}
+
+func (p *PostService) CreateComment(ctx context.Context, req *dto.CreateCommentReqDTO, userId uint, postId uint) (*models.Comment, error) {
 
-	_, err := p.postDAO.GetPostById(postId)
+	post, err := p.postDAO.GetPostById(ctx, postId)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
+	if err := checkInteractive(post); err != nil {
+		return nil, err
+	}
+	if post.IsLocked {
+		return nil, erru.New("帖子已被锁定，无法评论")
+	}
 
 	comment := &models.Comment{
 		Content:  req.Content,
@@ -183,9 +505,28 @@ func (p *PostService) CreateComment(req *dto.CreateCommentReqDTO, userId uint, p
 		PostID:   postId,
 	}
 
+	// 回复必须指向同一帖子下的评论，并归入父评论所在的楼层
+	var parent *models.Comment
+	if req.ParentId != 0 {
+		parent, err = p.postDAO.GetCommentById(ctx, req.ParentId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, erru.ErrInvalidParams.WithMsg("回复的评论不存在")
+			}
+			return nil, erru.ErrInternalServer.Wrap(err)
+		}
+		if parent.PostID != postId {
+			return nil, erru.ErrInvalidParams.WithMsg("回复的评论不属于该帖子")
+		}
+		comment.RootID = parent.RootID
+		if comment.RootID == 0 {
+			comment.RootID = parent.ID
+		}
+	}
+
 	// TODO:（数据库事务）
 	// 确保“创建评论”和“帖子评论数+1”这两个操作，要么都成功，要么都失败
-	p.repository.DB().Transaction(func(tx *gorm.DB) error {
+	err = p.repository.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. 在事务中创建评论
 		if err := p.postDAO.CreateComment(tx, comment); err != nil {
 			return err
@@ -196,50 +537,131 @@ func (p *PostService) CreateComment(req *dto.CreateCommentReqDTO, userId uint, p
 		}
 		return nil // 返回 nil，事务就会被提交
 	})
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
 
-	fullComment, err := p.postDAO.GetCommentById(comment.ID)
+	fullComment, err := p.postDAO.GetCommentById(ctx, comment.ID)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
 
+	fullComment.Mentions = p.mentionService.SyncMentions(ctx, userId, postId, comment.ID, comment.Content)
+	p.postCache.InvalidatePost(ctx, postId)
+	p.rankService.Record(ctx, post.ID, postTagIDs(post), RankActionComment, 1)
+	p.background.Go(ctx, func(ctx context.Context) {
+		p.notifyComment(ctx, post, parent, fullComment)
+	})
+
 	return fullComment, nil
 }
 
-func (p *PostService) DeleteComment(commentId uint, userId uint) error {
-	comment, err := p.postDAO.GetCommentById(commentId)
+// checkInteractive 只有已发布的帖子可以评论、点赞、收藏
+func checkInteractive(post *models.Post) error {
+	switch post.Status {
+	case models.PostStatusPublished:
+		return nil
+	case models.PostStatusArchived:
+		return erru.New("帖子已归档，无法互动")
+	default:
+		return erru.ErrResourceNotFound
+	}
+}
+
+// notifyComment 通知被回复的评论作者和帖子作者，同一个人只通知一次
+func (p *PostService) notifyComment(ctx context.Context, post *models.Post, parent *models.Comment, comment *models.Comment) {
+	if parent != nil {
+		p.notificationService.Notify(ctx, &NotifyEvent{
+			RecipientID: parent.UserID,
+			ActorID:     comment.UserID,
+			Type:        models.NotifyTypeReply,
+			PostID:      post.ID,
+			CommentID:   comment.ID,
+			Content:     comment.Content,
+		})
+		if parent.UserID == post.UserID {
+			return
+		}
+	}
+	p.notificationService.Notify(ctx, &NotifyEvent{
+		RecipientID: post.UserID,
+		ActorID:     comment.UserID,
+		Type:        models.NotifyTypeComment,
+		PostID:      post.ID,
+		CommentID:   comment.ID,
+		Content:     comment.Content,
+	})
+}
+
+func (p *PostService) UpdateComment(ctx context.Context, commentId uint, userId uint, role string, reqDto *dto.UpdateCommentReqDTO) (*models.Comment, error) {
+	comment, err := p.postDAO.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+
+	onBehalf, err := checkOwnership(userId, role, comment.UserID, rbac.PermCommentEditAny)
+	if err != nil {
+		return nil, err
+	}
+	oldContent := comment.Content
+
+	comment.Content = reqDto.Content
+	if err := p.postDAO.UpdateComment(ctx, comment); err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	comment.Mentions = p.mentionService.SyncMentions(ctx, comment.UserID, comment.PostID, commentId, comment.Content)
+	if onBehalf {
+		p.auditService.Record(ctx, userId, role, "comment.update", AuditTargetComment, commentId, comment.UserID,
+			fmt.Sprintf("原内容: %s", oldContent))
+	}
+	return comment, nil
+}
+
+func (p *PostService) DeleteComment(ctx context.Context, commentId uint, userId uint, role string) error {
+	comment, err := p.postDAO.GetCommentById(ctx, commentId)
 	if err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
 
-	if userId != comment.UserID {
-		return erru.ErrUnauthorized
+	onBehalf, err := checkOwnership(userId, role, comment.UserID, rbac.PermCommentDeleteAny)
+	if err != nil {
+		return err
 	}
+	oldContent := comment.Content
 
 	// TODO:递归删除 or 逻辑删除
 	// 我选 后者
 	comment.Content = "该评论已删除"
-	err = p.postDAO.UpdateComment(comment)
+	err = p.postDAO.UpdateComment(ctx, comment)
 	if err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
-	return err
+	p.mentionService.ClearMentions(ctx, comment.PostID, commentId)
+	if onBehalf {
+		p.auditService.Record(ctx, userId, role, "comment.delete", AuditTargetComment, commentId, comment.UserID,
+			fmt.Sprintf("原内容: %s", oldContent))
+	}
+	return nil
 }
 
 // --------------------点赞、收藏------------------------------
-func (p *PostService) LikePost(postId uint, userId uint) (bool, int64, error) {
+func (p *PostService) LikePost(ctx context.Context, postId uint, userId uint) (bool, int64, error) {
 	// 验证
-	post, err := p.postDAO.GetPostById(postId)
+	post, err := p.postDAO.GetPostById(ctx, postId)
 	if err != nil {
 		return false, 0, erru.ErrInternalServer.Wrap(err)
 	}
 	// if post.UserID != userId {
 	// 	return false, 0, erru.ErrUnauthorized
 	// }
+	if err := checkInteractive(post); err != nil {
+		return false, 0, err
+	}
 	if userId == 0 {
 		return false, 0, erru.ErrUnauthorized
 	}
 	// 查询是否点赞
-	isLiked, err := p.postDAO.IsLiked(userId, postId)
+	isLiked, err := p.postDAO.IsLiked(ctx, userId, postId)
 	if err != nil {
 		return false, 0, erru.ErrInternalServer.Wrap(err)
 	}
@@ -247,7 +669,7 @@ func (p *PostService) LikePost(postId uint, userId uint) (bool, int64, error) {
 	var actionState bool
 	var newLikeCount int
 
-	err = p.repository.DB().Transaction(func(tx *gorm.DB) error {
+	err = p.repository.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 
 		if isLiked == true {
 			err := p.postDAO.RemoveLike(tx, userId, postId)
@@ -271,31 +693,47 @@ func (p *PostService) LikePost(postId uint, userId uint) (bool, int64, error) {
 		return false, 0, erru.ErrInternalServer.Wrap(err)
 	}
 
+	p.postCache.InvalidatePost(ctx, postId)
+
 	// 点赞更新
 	newLikeCount = post.LikeCount
 	if actionState == false {
 		newLikeCount--
+		p.rankService.Record(ctx, post.ID, postTagIDs(post), RankActionLike, -1)
 	} else {
 		newLikeCount++
+		p.rankService.Record(ctx, post.ID, postTagIDs(post), RankActionLike, 1)
+		p.background.Go(ctx, func(ctx context.Context) {
+			p.notificationService.Notify(ctx, &NotifyEvent{
+				RecipientID: post.UserID,
+				ActorID:     userId,
+				Type:        models.NotifyTypeLike,
+				PostID:      postId,
+				Content:     post.Title,
+			})
+		})
 	}
 
 	return actionState, int64(newLikeCount), nil
 }
 
-func (p *PostService) FavoritePost(postId uint, userId uint) (bool, int64, error) {
+func (p *PostService) FavoritePost(ctx context.Context, postId uint, userId uint) (bool, int64, error) {
 	// 验证
-	post, err := p.postDAO.GetPostById(postId)
+	post, err := p.postDAO.GetPostById(ctx, postId)
 	if err != nil {
 		return false, 0, erru.ErrInternalServer.Wrap(err)
 	}
 	// if post.UserID != userId {
 	// 	return false, 0, erru.ErrUnauthorized
 	// }
+	if err := checkInteractive(post); err != nil {
+		return false, 0, err
+	}
 	if userId == 0 {
 		return false, 0, erru.ErrUnauthorized
 	}
 	// 查询是否收藏
-	isFavorite, err := p.postDAO.IsFavorite(userId, postId)
+	isFavorite, err := p.postDAO.IsFavorite(ctx, userId, postId)
 	if err != nil {
 		return false, 0, erru.ErrInternalServer.Wrap(err)
 	}
@@ -303,7 +741,7 @@ func (p *PostService) FavoritePost(postId uint, userId uint) (bool, int64, error
 	var actionState bool
 	var newFavoriteCount int
 
-	err = p.repository.DB().Transaction(func(tx *gorm.DB) error {
+	err = p.repository.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if isFavorite == true {
 			err := p.postDAO.RemoveFavorite(tx, userId, postId)
 			if err != nil {
@@ -326,27 +764,409 @@ func (p *PostService) FavoritePost(postId uint, userId uint) (bool, int64, error
 		return false, 0, erru.ErrInternalServer.Wrap(err)
 	}
 
+	p.postCache.InvalidatePost(ctx, postId)
+
 	// 点赞更新
 	newFavoriteCount = post.FavoriteCount
 	if actionState == false {
 		newFavoriteCount--
+		p.rankService.Record(ctx, post.ID, postTagIDs(post), RankActionFavorite, -1)
 	} else {
 		newFavoriteCount++
+		p.rankService.Record(ctx, post.ID, postTagIDs(post), RankActionFavorite, 1)
+		p.background.Go(ctx, func(ctx context.Context) {
+			p.notificationService.Notify(ctx, &NotifyEvent{
+				RecipientID: post.UserID,
+				ActorID:     userId,
+				Type:        models.NotifyTypeFavorite,
+				PostID:      postId,
+				Content:     post.Title,
+			})
+		})
 	}
 
 	return actionState, int64(newFavoriteCount), nil
 }
 
-func (p *PostService) GetUserStatus(userId, postId uint) (bool, bool, error) {
-	liked, err := p.postDAO.IsFavorite(userId, postId)
+func (p *PostService) GetUserStatus(ctx context.Context, userId, postId uint) (bool, bool, error) {
+	liked, err := p.postDAO.IsFavorite(ctx, userId, postId)
 	if err != nil {
 		return false, false, erru.ErrInternalServer.Wrap(err)
 	}
 
-	favorited, err := p.postDAO.IsLiked(userId, postId)
+	favorited, err := p.postDAO.IsLiked(ctx, userId, postId)
 	if err != nil {
 		return false, false, erru.ErrInternalServer.Wrap(err)
 	}
 
 	return liked, favorited, nil
 }
+
+// -------------------编辑历史------------------------------
+// RevisionDiff 两个版本之间的差异，标题和正文是 unified diff 格式
+type RevisionDiff struct {
+	From        *models.PostRevision
+	To          *models.PostRevision
+	TitleDiff   string
+	ContentDiff string
+	TagsAdded   []string
+	TagsRemoved []string
+}
+
+// revisionDiffContext diff 中每个改动块保留的上下文行数
+const revisionDiffContext = 3
+
+// getRevisionPost 查询帖子并校验能否查看、回滚它的编辑历史：只有作者和版主可以
+func (p *PostService) getRevisionPost(ctx context.Context, userId uint, role string, postId uint) (*models.Post, bool, error) {
+	post, err := p.postDAO.GetPostById(ctx, postId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, erru.ErrResourceNotFound
+		}
+		return nil, false, erru.ErrInternalServer.Wrap(err)
+	}
+	onBehalf, err := checkOwnership(userId, role, post.UserID, rbac.PermPostEditAny)
+	if err != nil {
+		return nil, false, err
+	}
+	return post, onBehalf, nil
+}
+
+// ListRevisions 查询帖子的版本列表，最新的在前，不含正文
+func (p *PostService) ListRevisions(ctx context.Context, userId uint, role string, postId uint) (*models.Post, []*models.PostRevision, error) {
+	post, _, err := p.getRevisionPost(ctx, userId, role, postId)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisions, err := p.revisionDAO.ListRevisions(ctx, postId)
+	if err != nil {
+		return nil, nil, erru.ErrInternalServer.Wrap(err)
+	}
+	// 从未编辑过的帖子没有版本记录，当前内容就是唯一的版本
+	if len(revisions) == 0 {
+		revisions = []*models.PostRevision{currentRevision(post)}
+	}
+	return post, revisions, nil
+}
+
+func (p *PostService) GetRevision(ctx context.Context, userId uint, role string, postId uint, version int) (*models.PostRevision, error) {
+	post, _, err := p.getRevisionPost(ctx, userId, role, postId)
+	if err != nil {
+		return nil, err
+	}
+	return p.loadRevision(ctx, post, version)
+}
+
+// DiffRevisions 比较帖子的两个版本
+func (p *PostService) DiffRevisions(ctx context.Context, userId uint, role string, postId uint, from, to int) (*RevisionDiff, error) {
+	post, _, err := p.getRevisionPost(ctx, userId, role, postId)
+	if err != nil {
+		return nil, err
+	}
+	fromRev, err := p.loadRevision(ctx, post, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := p.loadRevision(ctx, post, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromName, toName := fmt.Sprintf("v%d", from), fmt.Sprintf("v%d", to)
+	titleDiff, err := utils.UnifiedDiff(fromRev.Title, toRev.Title, fromName, toName, revisionDiffContext)
+	if err != nil {
+		return nil, diffError(err)
+	}
+	contentDiff, err := utils.UnifiedDiff(fromRev.Content, toRev.Content, fromName, toName, revisionDiffContext)
+	if err != nil {
+		return nil, diffError(err)
+	}
+	diff := &RevisionDiff{
+		From:        fromRev,
+		To:          toRev,
+		TitleDiff:   titleDiff,
+		ContentDiff: contentDiff,
+		TagsAdded:   make([]string, 0),
+		TagsRemoved: make([]string, 0),
+	}
+	for _, tag := range toRev.Tags {
+		if !slices.Contains(fromRev.Tags, tag) {
+			diff.TagsAdded = append(diff.TagsAdded, tag)
+		}
+	}
+	for _, tag := range fromRev.Tags {
+		if !slices.Contains(toRev.Tags, tag) {
+			diff.TagsRemoved = append(diff.TagsRemoved, tag)
+		}
+	}
+	return diff, nil
+}
+
+func diffError(err error) error {
+	if errors.Is(err, utils.ErrDiffTooLarge) {
+		return erru.New("两个版本差异过大，无法比较")
+	}
+	return erru.ErrInternalServer.Wrap(err)
+}
+
+// RollbackPost 把帖子恢复成某个历史版本的内容，回滚本身作为一个新版本记录，历史不会丢失
+func (p *PostService) RollbackPost(ctx context.Context, userId uint, role string, postId uint, version int) (*models.Post, error) {
+	post, onBehalf, err := p.getRevisionPost(ctx, userId, role, postId)
+	if err != nil {
+		return nil, err
+	}
+	if version == post.Revision {
+		return nil, erru.New("帖子已经是该版本")
+	}
+	revision, err := p.loadRevision(ctx, post, version)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := p.tagDAO.FindOrCreateTagsByNames(ctx, revision.Tags)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	post, err = p.editPost(ctx, userId, postId, revision.Title, revision.Content, tags, version)
+	if err != nil {
+		return nil, err
+	}
+	post.Mentions = p.mentionService.SyncMentions(ctx, post.UserID, postId, 0, post.Content)
+	p.attachmentService.SyncPostAttachments(ctx, post.UserID, postId, post.Content)
+	if onBehalf {
+		p.auditService.Record(ctx, userId, role, "post.rollback", AuditTargetPost, postId, post.UserID,
+			fmt.Sprintf("回滚到版本 %d", version))
+	}
+	return post, nil
+}
+
+// loadRevision 查询帖子的某个版本，从未编辑过的帖子只有当前版本
+func (p *PostService) loadRevision(ctx context.Context, post *models.Post, version int) (*models.PostRevision, error) {
+	revision, err := p.revisionDAO.GetRevision(ctx, post.ID, version)
+	if err == nil {
+		return revision, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	if version == post.Revision {
+		return currentRevision(post), nil
+	}
+	return nil, erru.ErrResourceNotFound.WithMsg("版本不存在")
+}
+
+// currentRevision 用帖子的当前内容构造一个版本，用于还没有版本记录的帖子
+func currentRevision(post *models.Post) *models.PostRevision {
+	return &models.PostRevision{
+		PostID:    post.ID,
+		Version:   post.Revision,
+		Title:     post.Title,
+		Content:   post.Content,
+		Tags:      tagNames(post.Tags),
+		EditorID:  post.UserID,
+		Editor:    post.User,
+		CreatedAt: post.CreatedAt,
+	}
+}
+
+// tagNames 返回排好序的标签名，便于比较两组标签是否相同
+func tagNames(tags []*models.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// -------------------草稿与发布------------------------------
+// draftPublishBatch 定时任务每次最多发布的帖子数
+const draftPublishBatch = 100
+
+// SaveDraft 自动保存草稿，draftId 为 0 时新建；草稿不记录编辑历史，也不解析 @提及
+func (p *PostService) SaveDraft(ctx context.Context, userId uint, draftId uint, reqDto *dto.SaveDraftReqDTO) (*models.Post, error) {
+	tags, err := p.tagDAO.FindOrCreateTagsByNames(ctx, reqDto.Tags)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+
+	if draftId == 0 {
+		post := &models.Post{
+			Title:   reqDto.Title,
+			Content: reqDto.Content,
+			Excerpt: markdown.Excerpt(reqDto.Content, models.PostExcerptLen),
+			UserID:  userId,
+			Tags:    tags,
+			Status:  models.PostStatusDraft,
+		}
+		if err := p.postDAO.CreatePost(ctx, post); err != nil {
+			return nil, erru.ErrInternalServer.Wrap(err)
+		}
+		draftId = post.ID
+	} else {
+		post, err := p.getDraft(ctx, userId, draftId)
+		if err != nil {
+			return nil, err
+		}
+		post.Title = reqDto.Title
+		post.Content = reqDto.Content
+		post.Excerpt = markdown.Excerpt(reqDto.Content, models.PostExcerptLen)
+		post.Tags = tags
+		err = p.repository.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return p.postDAO.UpdatePost(tx, post)
+		})
+		if err != nil {
+			return nil, erru.ErrInternalServer.Wrap(err)
+		}
+	}
+
+	p.attachmentService.SyncPostAttachments(ctx, userId, draftId, reqDto.Content)
+
+	post, err := p.postDAO.GetPostById(ctx, draftId)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	return post, nil
+}
+
+// getDraft 查询用户自己的草稿，别人的草稿视同不存在
+func (p *PostService) getDraft(ctx context.Context, userId uint, draftId uint) (*models.Post, error) {
+	post, err := p.postDAO.GetPostById(ctx, draftId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erru.ErrResourceNotFound
+		}
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	if post.UserID != userId || !post.IsDraft() {
+		return nil, erru.ErrResourceNotFound
+	}
+	return post, nil
+}
+
+func (p *PostService) GetDraft(ctx context.Context, userId uint, draftId uint) (*models.Post, error) {
+	return p.getDraft(ctx, userId, draftId)
+}
+
+func (p *PostService) ListDrafts(ctx context.Context, userId uint, page, size int) ([]*models.Post, int64, error) {
+	posts, total, err := p.postDAO.ListDrafts(ctx, userId, page, size)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+	return posts, total, nil
+}
+
+func (p *PostService) DeleteDraft(ctx context.Context, userId uint, draftId uint) error {
+	if _, err := p.getDraft(ctx, userId, draftId); err != nil {
+		return err
+	}
+	if err := p.postDAO.DeletePost(ctx, draftId); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	p.attachmentService.SyncPostAttachments(ctx, userId, draftId, "")
+	return nil
+}
+
+// PublishDraft 发布草稿，publishAt 晚于当前时间时改为定时发布
+func (p *PostService) PublishDraft(ctx context.Context, userId uint, draftId uint, publishAt *time.Time) (*models.Post, error) {
+	post, err := p.getDraft(ctx, userId, draftId)
+	if err != nil {
+		return nil, err
+	}
+	// 草稿保存时不做校验，发布前按发帖的要求检查
+	if utf8.RuneCountInString(post.Title) < 3 {
+		return nil, erru.ErrInvalidParams.WithMsg("标题至少需要 3 个字符")
+	}
+	if utf8.RuneCountInString(post.Content) < 5 {
+		return nil, erru.ErrInvalidParams.WithMsg("正文至少需要 5 个字符")
+	}
+
+	if publishAt != nil && publishAt.After(time.Now()) {
+		_, err := p.postDAO.UpdatePostStatus(ctx, draftId,
+			[]string{models.PostStatusDraft, models.PostStatusScheduled},
+			map[string]any{"status": models.PostStatusScheduled, "publish_at": publishAt})
+		if err != nil {
+			return nil, erru.ErrInternalServer.Wrap(err)
+		}
+	} else if err := p.publishNow(ctx, draftId); err != nil {
+		return nil, err
+	}
+
+	post, err = p.postDAO.GetPostById(ctx, draftId)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	return post, nil
+}
+
+// publishNow 立即发布草稿或定时帖子，帖子已经被发布过时什么也不做
+func (p *PostService) publishNow(ctx context.Context, postId uint) error {
+	published, err := p.postDAO.UpdatePostStatus(ctx, postId,
+		[]string{models.PostStatusDraft, models.PostStatusScheduled},
+		map[string]any{"status": models.PostStatusPublished, "publish_at": nil, "created_at": time.Now()})
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if !published {
+		return nil
+	}
+
+	post, err := p.postDAO.GetPostById(ctx, postId)
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	p.afterPublish(ctx, post)
+	return nil
+}
+
+// PublishDuePosts 发布到期的定时帖子，由定时任务调用
+func (p *PostService) PublishDuePosts(ctx context.Context) {
+	ids, err := p.postDAO.ListDuePostIDs(ctx, time.Now(), draftPublishBatch)
+	if err != nil {
+		p.logger.ErrorContext(ctx, "查询到期的定时帖子失败", "err", err)
+		return
+	}
+	for _, id := range ids {
+		if err := p.publishNow(ctx, id); err != nil {
+			p.logger.ErrorContext(ctx, "定时发布帖子失败", "post_id", id, "err", err)
+		}
+	}
+	if len(ids) > 0 {
+		p.logger.InfoContext(ctx, "定时发布帖子", "count", len(ids))
+	}
+}
+
+// ArchivePost 归档或取消归档帖子，作者本人和版主可以操作
+func (p *PostService) ArchivePost(ctx context.Context, userId uint, role string, postId uint, archived bool) error {
+	post, err := p.postDAO.GetPostById(ctx, postId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	onBehalf, err := checkOwnership(userId, role, post.UserID, rbac.PermPostEditAny)
+	if err != nil {
+		return err
+	}
+
+	from, to, action := models.PostStatusPublished, models.PostStatusArchived, "post.archive"
+	if !archived {
+		from, to, action = models.PostStatusArchived, models.PostStatusPublished, "post.unarchive"
+	}
+	ok, err := p.postDAO.UpdatePostStatus(ctx, postId, []string{from}, map[string]any{"status": to})
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if !ok {
+		if archived {
+			return erru.New("只有已发布的帖子可以归档")
+		}
+		return erru.New("帖子没有归档")
+	}
+	p.postCache.InvalidatePostAndLists(ctx, postId)
+	if onBehalf {
+		p.auditService.Record(ctx, userId, role, action, AuditTargetPost, postId, post.UserID, "")
+	}
+	return nil
+}
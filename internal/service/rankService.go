@@ -0,0 +1,177 @@
+package service
+
+import (
+	"Nuxus/configs"
+	"Nuxus/internal/dao"
+	"Nuxus/internal/models"
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+// 计入热门榜单的互动
+const (
+	RankActionView     = "view"
+	RankActionLike     = "like"
+	RankActionComment  = "comment"
+	RankActionFavorite = "favorite"
+)
+
+// rankDayLayout 按天榜单 key 中的日期格式
+const rankDayLayout = "20060102"
+
+// RankService 热门榜单
+// 每次互动实时累加到全站和帖子所属标签的按天榜单中；定时任务用最近几天的积分按发布时间衰减计算热度，并汇总周榜。
+// 累计榜由定时任务按数据库中帖子的互动计数重建，包含上线之前的历史数据，积分不会因为裁剪而丢失。
+type RankService struct {
+	postDAO     *dao.PostDAO
+	redisClient *dao.RedisClient
+	config      *configs.Config
+	logger      *slog.Logger
+}
+
+func NewRankService(postDAO *dao.PostDAO, redisClient *dao.RedisClient, config *configs.Config, logger *slog.Logger) *RankService {
+	return &RankService{
+		postDAO:     postDAO,
+		redisClient: redisClient,
+		config:      config,
+		logger:      logger,
+	}
+}
+
+func (r *RankService) weight(action string) float64 {
+	switch action {
+	case RankActionView:
+		return r.config.Rank.ViewWeight
+	case RankActionLike:
+		return r.config.Rank.LikeWeight
+	case RankActionComment:
+		return r.config.Rank.CommentWeight
+	case RankActionFavorite:
+		return r.config.Rank.FavoriteWeight
+	default:
+		return 0
+	}
+}
+
+// dayTTL 按天榜单的保留时间，要覆盖周榜和热度计算用到的天数
+func (r *RankService) dayTTL() time.Duration {
+	return time.Duration(max(r.config.Rank.MaxAgeDays, 7)+2) * 24 * time.Hour
+}
+
+// recentDays 从 now 所在的那天往前共 n 天的日期
+func recentDays(now time.Time, n int) []string {
+	days := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		days = append(days, now.AddDate(0, 0, -i).Format(rankDayLayout))
+	}
+	return days
+}
+
+func postTagIDs(post *models.Post) []uint {
+	ids := make([]uint, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		ids = append(ids, tag.ID)
+	}
+	return ids
+}
+
+// Record 记录帖子的一次互动，tagIDs 是帖子的标签，count 为 -1 表示取消（取消点赞、取消收藏）。
+// 榜单只是统计数据，失败时只记录日志
+func (r *RankService) Record(ctx context.Context, postID uint, tagIDs []uint, action string, count int) {
+	score := r.weight(action) * float64(count)
+	if score == 0 {
+		return
+	}
+	day := time.Now().Format(rankDayLayout)
+	if err := r.redisClient.AddPostScore(ctx, postID, tagIDs, score, day, r.dayTTL()); err != nil {
+		r.logger.ErrorContext(ctx, "更新热门积分失败", "post_id", postID, "action", action, "err", err)
+	}
+}
+
+// RemovePost 帖子删除后从所有榜单中移除
+func (r *RankService) RemovePost(ctx context.Context, post *models.Post) {
+	days := recentDays(time.Now(), max(r.config.Rank.MaxAgeDays, 7)+2)
+	if err := r.redisClient.RemovePostFromRanks(ctx, post.ID, postTagIDs(post), days); err != nil {
+		r.logger.ErrorContext(ctx, "从热门榜单移除帖子失败", "post_id", post.ID, "err", err)
+	}
+}
+
+// RankedPostIDs 获取榜单上排名前 limit 的帖子 ID，tagID 为 0 时是全站榜单
+func (r *RankService) RankedPostIDs(ctx context.Context, period string, tagID uint, limit int) ([]string, error) {
+	day := time.Now().Format(rankDayLayout)
+	return r.redisClient.GetRankedPostIDs(ctx, period, tagID, day, int64(limit))
+}
+
+// hotScore 热度 = 积分 / (发布后的小时数 + 2) ^ gravity
+func (r *RankService) hotScore(points float64, age time.Duration) float64 {
+	return points / math.Pow(max(age.Hours(), 0)+2, r.config.Rank.Gravity)
+}
+
+// Refresh 重新计算热门榜，汇总周榜，重建累计榜
+func (r *RankService) Refresh(ctx context.Context) {
+	now := time.Now()
+	if err := r.refreshHot(ctx, now); err != nil {
+		r.logger.ErrorContext(ctx, "计算热门榜失败", "err", err)
+	}
+	if err := r.redisClient.RebuildRankBoards(ctx, recentDays(now, 7), recentDays(now, 8)); err != nil {
+		r.logger.ErrorContext(ctx, "汇总周榜失败", "err", err)
+	}
+	if err := r.refreshAllTime(ctx); err != nil {
+		r.logger.ErrorContext(ctx, "重建累计榜失败", "err", err)
+	}
+}
+
+// refreshAllTime 按帖子的浏览、点赞、评论、收藏数加权重建全站和各个标签的累计榜，每个榜单保留 BoardSize 个
+func (r *RankService) refreshAllTime(ctx context.Context) error {
+	weights := dao.RankWeights{
+		View:     r.config.Rank.ViewWeight,
+		Like:     r.config.Rank.LikeWeight,
+		Comment:  r.config.Rank.CommentWeight,
+		Favorite: r.config.Rank.FavoriteWeight,
+	}
+	site, err := r.postDAO.ListTopPostScores(ctx, weights, r.config.Rank.BoardSize)
+	if err != nil {
+		return err
+	}
+	tags, err := r.postDAO.ListTopTagPostScores(ctx, weights, r.config.Rank.BoardSize)
+	if err != nil {
+		return err
+	}
+	return r.redisClient.ReplaceAllTimeBoards(ctx, site, tags)
+}
+
+func (r *RankService) refreshHot(ctx context.Context, now time.Time) error {
+	maxAge := time.Duration(r.config.Rank.MaxAgeDays) * 24 * time.Hour
+	// 帖子的互动都发生在发布之后，最近 maxAgeDays+1 天的积分就是这段时间内发布的帖子的全部积分
+	scores, err := r.redisClient.SumDailyScores(ctx, recentDays(now, r.config.Rank.MaxAgeDays+1))
+	if err != nil {
+		return err
+	}
+	ids := make([]uint, 0, len(scores))
+	for _, entry := range scores {
+		ids = append(ids, entry.PostID)
+	}
+	// 删除、隐藏、归档的帖子不在结果中，不会进入热门榜
+	publishTimes, err := r.postDAO.ListPublishTimes(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	hot := make([]dao.RankEntry, 0, len(publishTimes))
+	for _, entry := range scores {
+		publishedAt, ok := publishTimes[entry.PostID]
+		if !ok || entry.Score <= 0 || now.Sub(publishedAt) > maxAge {
+			continue
+		}
+		hot = append(hot, dao.RankEntry{PostID: entry.PostID, Score: r.hotScore(entry.Score, now.Sub(publishedAt))})
+	}
+	sort.Slice(hot, func(i, j int) bool { return hot[i].Score > hot[j].Score })
+	if len(hot) > r.config.Rank.HotSize {
+		hot = hot[:r.config.Rank.HotSize]
+	}
+	r.logger.InfoContext(ctx, "热门榜已更新", "posts", len(hot))
+	return r.redisClient.ReplaceHotPosts(ctx, hot)
+}
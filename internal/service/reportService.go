@@ -0,0 +1,245 @@
+package service
+
+import (
+	"Nuxus/internal/dao"
+	"Nuxus/internal/dto"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/erru"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	reportDedupWindow = 24 * time.Hour // 同一用户对同一目标的重复举报间隔
+	reportRateWindow  = time.Hour      // 举报频率统计窗口
+	reportRateLimit   = 10             // 每个窗口内最多举报次数
+)
+
+type ReportService struct {
+	reportDAO    *dao.ReportDAO
+	postDAO      *dao.PostDAO
+	userDAO      *dao.UserDAO
+	redisClient  *dao.RedisClient
+	emailService *EmailService
+	auditService *AuditService
+	postCache    *PostCache
+	background   *Background
+	logger       *slog.Logger
+}
+
+func NewReportService(reportDAO *dao.ReportDAO, postDAO *dao.PostDAO, userDAO *dao.UserDAO, redisClient *dao.RedisClient, emailService *EmailService, auditService *AuditService, postCache *PostCache, background *Background, logger *slog.Logger) *ReportService {
+	return &ReportService{
+		reportDAO:    reportDAO,
+		postDAO:      postDAO,
+		userDAO:      userDAO,
+		redisClient:  redisClient,
+		emailService: emailService,
+		auditService: auditService,
+		postCache:    postCache,
+		background:   background,
+		logger:       logger,
+	}
+}
+
+func (r *ReportService) CreateReport(ctx context.Context, userId uint, reqDto *dto.CreateReportReqDTO) (*models.Report, error) {
+	// 举报流程：
+	// 1.检查被举报的目标是否存在
+	// 2.去重、限流
+	// 3.写入举报
+	if err := r.checkTarget(ctx, userId, reqDto.TargetType, reqDto.TargetID); err != nil {
+		return nil, err
+	}
+
+	pending, err := r.reportDAO.HasPendingReport(ctx, userId, reqDto.TargetType, reqDto.TargetID)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	if pending {
+		return nil, erru.New("您已举报过该内容，请等待处理")
+	}
+	first, err := r.redisClient.MarkReported(ctx, userId, reqDto.TargetType, reqDto.TargetID, reportDedupWindow)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	if !first {
+		return nil, erru.New("您已举报过该内容，请勿重复举报")
+	}
+
+	count, err := r.redisClient.IncrReportCount(ctx, userId, reportRateWindow)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	if count > reportRateLimit {
+		r.redisClient.UnmarkReported(ctx, userId, reqDto.TargetType, reqDto.TargetID)
+		return nil, erru.New("举报过于频繁，请稍后再试")
+	}
+
+	report := &models.Report{
+		ReporterID: userId,
+		TargetType: reqDto.TargetType,
+		TargetID:   reqDto.TargetID,
+		Reason:     reqDto.Reason,
+		Detail:     reqDto.Detail,
+		Status:     models.ReportStatusPending,
+	}
+	if err := r.reportDAO.CreateReport(ctx, report); err != nil {
+		r.redisClient.UnmarkReported(ctx, userId, reqDto.TargetType, reqDto.TargetID)
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	return report, nil
+}
+
+// checkTarget 检查被举报的目标是否存在
+func (r *ReportService) checkTarget(ctx context.Context, userId uint, targetType string, targetID uint) error {
+	var err error
+	switch targetType {
+	case models.ReportTargetPost:
+		var post *models.Post
+		post, err = r.postDAO.GetPostById(ctx, targetID)
+		if err == nil && !post.IsVisible() {
+			return erru.ErrResourceNotFound
+		}
+	case models.ReportTargetComment:
+		var comment *models.Comment
+		comment, err = r.postDAO.GetCommentById(ctx, targetID)
+		if err == nil && comment.IsHidden {
+			return erru.ErrResourceNotFound
+		}
+	case models.ReportTargetUser:
+		if targetID == userId {
+			return erru.New("不能举报自己")
+		}
+		_, err = r.userDAO.GetUserById(ctx, targetID)
+	default:
+		return erru.ErrInvalidParams
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return erru.ErrResourceNotFound
+		}
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	return nil
+}
+
+// -------------------处理队列--------------------------------
+func (r *ReportService) ListReports(ctx context.Context, reqDto *dto.ListReportsReqDTO) ([]*models.Report, int64, error) {
+	reports, total, err := r.reportDAO.ListReports(ctx, reqDto.Status, reqDto.TargetType, reqDto.Page, reqDto.Size)
+	if err != nil {
+		return nil, 0, erru.ErrInternalServer.Wrap(err)
+	}
+	return reports, total, nil
+}
+
+// ResolveReport 确认举报属实，可选隐藏被举报的内容
+// 同一目标下其它待处理的举报会一并处理，并通知所有举报人
+func (r *ReportService) ResolveReport(ctx context.Context, handlerId uint, role string, reportId uint, reqDto *dto.HandleReportReqDTO) error {
+	report, err := r.getPendingReport(ctx, reportId)
+	if err != nil {
+		return err
+	}
+
+	hidden := false
+	if reqDto.HideTarget {
+		if err := r.hideTarget(ctx, handlerId, role, report); err != nil {
+			return err
+		}
+		hidden = report.TargetType != models.ReportTargetUser
+	}
+
+	return r.finishReports(ctx, handlerId, report, models.ReportStatusResolved, reqDto.Note, hidden)
+}
+
+// DismissReport 驳回举报
+func (r *ReportService) DismissReport(ctx context.Context, handlerId uint, reportId uint, reqDto *dto.HandleReportReqDTO) error {
+	report, err := r.getPendingReport(ctx, reportId)
+	if err != nil {
+		return err
+	}
+	return r.finishReports(ctx, handlerId, report, models.ReportStatusDismissed, reqDto.Note, false)
+}
+
+func (r *ReportService) getPendingReport(ctx context.Context, reportId uint) (*models.Report, error) {
+	report, err := r.reportDAO.GetReportById(ctx, reportId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, erru.ErrResourceNotFound
+		}
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	if report.Status != models.ReportStatusPending {
+		return nil, erru.New("该举报已处理")
+	}
+	return report, nil
+}
+
+// hideTarget 隐藏被举报的帖子或评论，用户类举报需要到管理后台封禁
+func (r *ReportService) hideTarget(ctx context.Context, handlerId uint, role string, report *models.Report) error {
+	detail := fmt.Sprintf("举报 #%d", report.ID)
+	switch report.TargetType {
+	case models.ReportTargetPost:
+		post, err := r.postDAO.GetPostById(ctx, report.TargetID)
+		if err != nil {
+			return erru.ErrInternalServer.Wrap(err)
+		}
+		if err := setPostHidden(ctx, r.postDAO, report.TargetID, true); err != nil {
+			return erru.ErrInternalServer.Wrap(err)
+		}
+		r.postCache.InvalidatePostAndLists(ctx, report.TargetID)
+		r.auditService.Record(ctx, handlerId, role, "post.hide", AuditTargetPost, report.TargetID, post.UserID, detail)
+	case models.ReportTargetComment:
+		comment, err := r.postDAO.GetCommentById(ctx, report.TargetID)
+		if err != nil {
+			return erru.ErrInternalServer.Wrap(err)
+		}
+		if err := r.postDAO.HideComment(ctx, report.TargetID); err != nil {
+			return erru.ErrInternalServer.Wrap(err)
+		}
+		r.auditService.Record(ctx, handlerId, role, "comment.hide", AuditTargetComment, report.TargetID, comment.UserID, detail)
+	default:
+		return erru.New("用户举报请到管理后台处理")
+	}
+	return nil
+}
+
+func (r *ReportService) finishReports(ctx context.Context, handlerId uint, report *models.Report, status, note string, hidden bool) error {
+	reports, err := r.reportDAO.ListPendingByTarget(ctx, report.TargetType, report.TargetID)
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	ids := make([]uint, 0, len(reports))
+	for _, item := range reports {
+		ids = append(ids, item.ID)
+	}
+	if err := r.reportDAO.HandleReports(ctx, ids, status, handlerId, note, hidden); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+
+	r.notifyReporters(ctx, reports, status == models.ReportStatusResolved, note)
+	return nil
+}
+
+// notifyReporters 异步通知举报人，发送失败不影响处理结果
+func (r *ReportService) notifyReporters(ctx context.Context, reports []*models.Report, resolved bool, note string) {
+	targetNames := map[string]string{
+		models.ReportTargetPost:    "帖子",
+		models.ReportTargetComment: "评论",
+		models.ReportTargetUser:    "用户",
+	}
+	r.background.Go(ctx, func(ctx context.Context) {
+		for _, report := range reports {
+			targetDesc := fmt.Sprintf("%s（ID: %d）", targetNames[report.TargetType], report.TargetID)
+			err := r.emailService.SendReportResultMail(ctx, report.Reporter.Email, targetDesc, resolved, note)
+			if err != nil {
+				r.logger.ErrorContext(ctx, "发送举报处理结果邮件失败", "report_id", report.ID, "err", err)
+			}
+		}
+	})
+}
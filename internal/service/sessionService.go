@@ -0,0 +1,173 @@
+package service
+
+import (
+	"Nuxus/configs"
+	"Nuxus/internal/dao"
+	"Nuxus/internal/models"
+	"Nuxus/pkg/erru"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionService 管理登录会话和刷新令牌
+// 每次登录创建一个会话（对应一台设备），刷新令牌的格式为 "<会话ID>.<随机串>"，
+// Redis 中只保存随机串的哈希，每次刷新都会轮换。
+type SessionService struct {
+	userDAO     *dao.UserDAO
+	redisClient *dao.RedisClient
+	config      *configs.Config
+	logger      *slog.Logger
+}
+
+func NewSessionService(userDAO *dao.UserDAO, redisClient *dao.RedisClient, config *configs.Config, logger *slog.Logger) *SessionService {
+	return &SessionService{
+		userDAO:     userDAO,
+		redisClient: redisClient,
+		config:      config,
+		logger:      logger,
+	}
+}
+
+func (s *SessionService) refreshTTL() time.Duration {
+	return time.Duration(s.config.JWT.RefreshExpireHours) * time.Hour
+}
+
+// CreateSession 登录成功后创建会话，返回会话 ID 和刷新令牌
+func (s *SessionService) CreateSession(ctx context.Context, userID uint, userAgent, ip string) (string, string, error) {
+	secret, err := newRefreshSecret()
+	if err != nil {
+		return "", "", erru.ErrInternalServer.Wrap(err)
+	}
+
+	now := time.Now()
+	session := &dao.SessionInfo{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		RefreshHash: hashRefreshSecret(secret),
+		UserAgent:   userAgent,
+		IP:          ip,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+	}
+	if err := s.redisClient.CreateSession(ctx, session, s.refreshTTL()); err != nil {
+		return "", "", erru.ErrInternalServer.Wrap(err)
+	}
+
+	return session.ID, session.ID + "." + secret, nil
+}
+
+// RefreshSession 校验并轮换刷新令牌
+// 如果提交的是已经被轮换掉的旧令牌，说明令牌可能泄露，直接注销整个会话
+func (s *SessionService) RefreshSession(ctx context.Context, refreshToken, userAgent, ip string) (*models.User, string, string, error) {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return nil, "", "", erru.ErrRefreshTokenInvalid
+	}
+
+	session, err := s.redisClient.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, "", "", erru.ErrInternalServer.Wrap(err)
+	}
+	if session == nil {
+		return nil, "", "", erru.ErrRefreshTokenInvalid
+	}
+
+	newSecret, err := newRefreshSecret()
+	if err != nil {
+		return nil, "", "", erru.ErrInternalServer.Wrap(err)
+	}
+
+	result, err := s.redisClient.RotateSessionRefresh(ctx, sessionID, hashRefreshSecret(secret), hashRefreshSecret(newSecret), ip, userAgent, s.refreshTTL())
+	if err != nil {
+		return nil, "", "", erru.ErrInternalServer.Wrap(err)
+	}
+	switch result {
+	case -1:
+		return nil, "", "", erru.ErrRefreshTokenInvalid
+	case 0:
+		s.logger.WarnContext(ctx, "检测到刷新令牌重放", "user_id", session.UserID, "session_id", sessionID, "ip", ip)
+		if err := s.redisClient.DeleteSession(ctx, session.UserID, sessionID); err != nil {
+			return nil, "", "", erru.ErrInternalServer.Wrap(err)
+		}
+		return nil, "", "", erru.ErrRefreshTokenReused
+	}
+
+	user, err := s.userDAO.GetUserById(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.redisClient.DeleteSession(ctx, session.UserID, sessionID)
+			return nil, "", "", erru.ErrUserNotFound
+		}
+		return nil, "", "", erru.ErrInternalServer.Wrap(err)
+	}
+	if user.IsBanned(time.Now()) {
+		s.redisClient.DeleteSession(ctx, session.UserID, sessionID)
+		return nil, "", "", banError(user)
+	}
+
+	return user, sessionID, sessionID + "." + newSecret, nil
+}
+
+func (s *SessionService) ListSessions(ctx context.Context, userID uint) ([]*dao.SessionInfo, error) {
+	sessions, err := s.redisClient.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession 撤销当前用户的某个会话
+func (s *SessionService) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	session, err := s.redisClient.GetSession(ctx, sessionID)
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	// 不存在或不属于当前用户，统一按资源不存在处理
+	if session == nil || session.UserID != userID {
+		return erru.ErrResourceNotFound
+	}
+	if err := s.redisClient.DeleteSession(ctx, userID, sessionID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	return nil
+}
+
+// RevokeOtherSessions 撤销除 keepSessionID 以外的所有会话
+func (s *SessionService) RevokeOtherSessions(ctx context.Context, userID uint, keepSessionID string) error {
+	sessions, err := s.redisClient.ListUserSessions(ctx, userID)
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	for _, session := range sessions {
+		if session.ID == keepSessionID {
+			continue
+		}
+		if err := s.redisClient.DeleteSession(ctx, userID, session.ID); err != nil {
+			return erru.ErrInternalServer.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func newRefreshSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
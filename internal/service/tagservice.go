@@ -4,6 +4,7 @@ import (
 	"Nuxus/internal/dao"
 	"Nuxus/internal/dto"
 	"Nuxus/pkg/erru"
+	"context"
 )
 
 type TagService struct {
@@ -14,11 +15,20 @@ func NewTagService(tagDAO *dao.TagDAO) *TagService{
 	return &TagService{tagDAO: tagDAO}
 }
 
-func (t *TagService) ListTags(sortedBy string) ([]*dto.ListTagsResDTO, error) {
+func (t *TagService) ListTags(ctx context.Context, sortedBy string) ([]*dto.ListTagsResDTO, error) {
 
-	listTags, err := t.tagDAO.ListTags(sortedBy)
+	listTags, err := t.tagDAO.ListTags(ctx, sortedBy)
 	if err != nil {
 		return nil, erru.ErrInternalServer.Wrap(err)
 	}
 	return listTags, nil
 }
+
+// ListSubscribedTags 查询用户关注的标签
+func (t *TagService) ListSubscribedTags(ctx context.Context, userID uint) ([]*dto.ListTagsResDTO, error) {
+	tags, err := t.tagDAO.ListSubscribedTags(ctx, userID)
+	if err != nil {
+		return nil, erru.ErrInternalServer.Wrap(err)
+	}
+	return tags, nil
+}
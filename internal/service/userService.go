@@ -7,40 +7,54 @@ import (
 	"Nuxus/internal/models"
 	"Nuxus/pkg/erru"
 	"Nuxus/pkg/utils"
+	"context"
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// verifyCodeTTL 注册和重置密码验证码的有效期
+const verifyCodeTTL = 5 * time.Minute
+
 type UserService struct {
-	userDAO      *dao.UserDAO
-	redisClient  *dao.RedisClient
-	emailService *EmailService
-	config       *configs.Config
+	userDAO        *dao.UserDAO
+	redisClient    *dao.RedisClient
+	emailService   *EmailService
+	accountService *AccountService
+	background     *Background
+	config         *configs.Config
+	logger         *slog.Logger
 }
 
-func NewUserService(userDAO *dao.UserDAO, redisClient *dao.RedisClient, emailService *EmailService, config *configs.Config) *UserService {
+func NewUserService(userDAO *dao.UserDAO, redisClient *dao.RedisClient, emailService *EmailService, accountService *AccountService, background *Background, config *configs.Config, logger *slog.Logger) *UserService {
 	return &UserService{
-		userDAO:      userDAO,
-		redisClient:  redisClient,
-		emailService: emailService,
-		config:       config,
+		userDAO:        userDAO,
+		redisClient:    redisClient,
+		emailService:   emailService,
+		accountService: accountService,
+		background:     background,
+		config:         config,
+		logger:         logger,
 	}
 }
 
-func (us *UserService) Register(reqDto *dto.RegisterReqDTO) error {
+func (us *UserService) Register(ctx context.Context, reqDto *dto.RegisterReqDTO) error {
 	// 注册流程：
 	// 1. 检查邮箱用户是否存在
 	// 2. 生成验证码，放在redis里
 	// 3. 发送验证码
-	_, err := us.userDAO.GetUserByEmail(reqDto.Email)
+	_, err := us.userDAO.GetUserByEmail(ctx, reqDto.Email)
 	if err == nil {
 		return erru.ErrEmailAlreadyUsed.Wrap(err)
 	}
 
-	isCool, err := us.redisClient.CheckSendCooldown(reqDto.Email)
+	isCool, err := us.redisClient.CheckSendCooldown(ctx, reqDto.Email)
 	if err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
@@ -50,33 +64,28 @@ func (us *UserService) Register(reqDto *dto.RegisterReqDTO) error {
 
 	code := utils.GenerateRandomCode(6)
 
-	err = us.redisClient.SetVerifyCode(reqDto.Email, code, 5*time.Minute)
+	err = us.redisClient.SetVerifyCode(ctx, reqDto.Email, code, verifyCodeTTL)
 	if err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
 
-	if err := us.emailService.SendRegisterMail(reqDto.Email, code); err != nil {
+	if err := us.emailService.SendRegisterMail(ctx, reqDto.Lang, reqDto.Email, code, verifyCodeTTL); err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
 
 	return nil
 }
 
-func (us *UserService) VerifyRegister(reqDto *dto.VerifyRegisterReqDTO) error {
+func (us *UserService) VerifyRegister(ctx context.Context, reqDto *dto.VerifyRegisterReqDTO) error {
 	// 验证流程：
 	// 1.从redis中取出验证码
 	// 2.验证正确性
 	// 3.创建用户，返回token
-	code, err := us.redisClient.GetVerificationCode(reqDto.Email)
-	if err != nil {
-		return erru.ErrInternalServer.Wrap(err)
+	if err := us.checkVerifyCode(ctx, reqDto.Email, reqDto.Code); err != nil {
+		return err
 	}
 
-	if code != reqDto.Code {
-		return erru.New("验证码错误")
-	}
-
-	us.redisClient.DelVerificationCode(reqDto.Email)
+	us.redisClient.DelVerificationCode(ctx, reqDto.Email)
 
 	// 按理来说不可能
 	// _, err = dao.GetUserByEmail(reqDto.Email)
@@ -96,20 +105,30 @@ func (us *UserService) VerifyRegister(reqDto *dto.VerifyRegisterReqDTO) error {
 		Password: string(encryptedPwd),
 	}
 
-	_, err = us.userDAO.CreateUser(user)
+	_, err = us.userDAO.CreateUser(ctx, user)
 	if err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
+	// 默认头像异步生成，对象存储慢或不可用时不拖慢注册；失败不影响注册，用户之后可以自己上传
+	us.background.Go(ctx, func(ctx context.Context) {
+		us.accountService.GenerateDefaultAvatar(ctx, user)
+	})
 
 	return nil
 }
 
-func (us *UserService) Login(req *dto.LoginReqDTO) (*models.User, error) {
-	user, err := us.userDAO.GetUserByIdentifier(req.Identifier)
+func (us *UserService) Login(ctx context.Context, req *dto.LoginReqDTO) (*models.User, error) {
+	// 账号或 IP 被锁定时不再校验密码
+	account := strings.ToLower(strings.TrimSpace(req.Identifier))
+	if err := us.checkLoginLock(ctx, account, req.ClientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := us.userDAO.GetUserByIdentifier(ctx, req.Identifier)
 	if err != nil {
 		// 如果错误是 gorm.ErrRecordNotFound，说明用户不存在
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, erru.ErrUserNotFound
+			return nil, us.loginFailed(ctx, account, req.ClientIP, erru.ErrUserNotFound)
 		}
 		// 其他数据库错误
 		return nil, erru.ErrInternalServer.Wrap(err)
@@ -118,14 +137,116 @@ func (us *UserService) Login(req *dto.LoginReqDTO) (*models.User, error) {
 	// 验证密码
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return nil, erru.ErrPasswordIncorrect
+		return nil, us.loginFailed(ctx, account, req.ClientIP, erru.ErrPasswordIncorrect)
+	}
+	if err := us.redisClient.ClearAuthFailures(ctx, dao.AuthScopeAccount, account); err != nil {
+		us.logger.ErrorContext(ctx, "清除登录失败次数失败", "account", account, "err", err)
+	}
+
+	// 检查账号状态
+	if user.IsBanned(time.Now()) {
+		return nil, banError(user)
+	}
+	if user.MustResetPassword {
+		return nil, erru.ErrPasswordResetRequired
 	}
 
 	return user, nil
 }
 
-func (us *UserService) RequestReset(reqDto *dto.RequestResetReqDTO) error {
-	_, err := us.userDAO.GetUserByEmail(reqDto.Email)
+// checkLoginLock 账号或 IP 处于锁定中时返回带有剩余时间的错误
+func (us *UserService) checkLoginLock(ctx context.Context, account, ip string) error {
+	var wait time.Duration
+	for scope, subject := range map[string]string{dao.AuthScopeAccount: account, dao.AuthScopeIP: ip} {
+		ttl, err := us.redisClient.GetAuthLockTTL(ctx, scope, subject)
+		if err != nil {
+			return erru.ErrInternalServer.Wrap(err)
+		}
+		wait = max(wait, ttl)
+	}
+	if wait > 0 {
+		return lockedError(wait)
+	}
+	return nil
+}
+
+// loginFailed 把一次失败同时计入账号和 IP，这次失败触发锁定时返回锁定错误，否则返回 cause
+func (us *UserService) loginFailed(ctx context.Context, account, ip string, cause *erru.AppError) error {
+	cfg := us.config.Auth
+	window := time.Duration(cfg.FailureWindowMinutes) * time.Minute
+	baseLock := time.Duration(cfg.LockBaseSeconds) * time.Second
+	maxLock := time.Duration(cfg.LockMaxSeconds) * time.Second
+
+	counters := []struct {
+		scope, subject string
+		threshold      int
+	}{
+		{dao.AuthScopeAccount, account, cfg.MaxAccountFailures},
+		{dao.AuthScopeIP, ip, cfg.MaxIPFailures},
+	}
+	var wait time.Duration
+	for _, c := range counters {
+		lock, err := us.redisClient.RecordAuthFailure(ctx, c.scope, c.subject, c.threshold, window, baseLock, maxLock)
+		if err != nil {
+			us.logger.ErrorContext(ctx, "记录登录失败次数失败", "scope", c.scope, "subject", c.subject, "err", err)
+			continue
+		}
+		wait = max(wait, lock)
+	}
+	if wait > 0 {
+		return lockedError(wait)
+	}
+	return cause
+}
+
+// lockedError 尝试次数过多的错误，retry_after 向上取整到秒
+func lockedError(wait time.Duration) *erru.AppError {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	return erru.ErrTooManyAttempts.
+		WithMsg(fmt.Sprintf("尝试次数过多，请在 %d 秒后再试", seconds)).
+		WithData(dto.RetryAfterDTO{RetryAfter: seconds})
+}
+
+// checkVerifyCode 校验邮箱验证码，输错达到上限后验证码失效，需要重新获取
+func (us *UserService) checkVerifyCode(ctx context.Context, email, code string) error {
+	stored, err := us.redisClient.GetVerificationCode(ctx, email)
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if stored == "" {
+		return erru.ErrInvaliVerifyCode.WithMsg("验证码已失效，请重新获取")
+	}
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(code)) == 1 {
+		return nil
+	}
+
+	attempts, err := us.redisClient.IncrVerifyAttempts(ctx, email, verifyCodeTTL)
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	if attempts >= int64(us.config.Auth.MaxCodeAttempts) {
+		if err := us.redisClient.DelVerificationCode(ctx, email); err != nil {
+			return erru.ErrInternalServer.Wrap(err)
+		}
+		return erru.ErrInvaliVerifyCode.WithMsg("验证码错误次数过多，已失效，请重新获取")
+	}
+	return erru.ErrInvaliVerifyCode
+}
+
+// banError 构造带封禁原因和到期时间的错误提示
+func banError(user *models.User) *erru.AppError {
+	msg := "账号已被封禁"
+	if user.BanReason != "" {
+		msg += "，原因：" + user.BanReason
+	}
+	if user.BanExpiresAt != nil {
+		msg += "，解封时间：" + user.BanExpiresAt.Format("2006-01-02 15:04")
+	}
+	return erru.ErrUserBanned.WithMsg(msg)
+}
+
+func (us *UserService) RequestReset(ctx context.Context, reqDto *dto.RequestResetReqDTO) error {
+	_, err := us.userDAO.GetUserByEmail(ctx, reqDto.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return erru.ErrUserNotFound
@@ -133,7 +254,7 @@ func (us *UserService) RequestReset(reqDto *dto.RequestResetReqDTO) error {
 		return erru.ErrInternalServer.Wrap(err)
 	}
 
-	isCool, err := us.redisClient.CheckSendCooldown(reqDto.Email)
+	isCool, err := us.redisClient.CheckSendCooldown(ctx, reqDto.Email)
 	if err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
@@ -143,22 +264,22 @@ func (us *UserService) RequestReset(reqDto *dto.RequestResetReqDTO) error {
 
 	code := utils.GenerateRandomCode(6)
 
-	err = us.redisClient.SetVerifyCode(reqDto.Email, code, 5*time.Minute)
+	err = us.redisClient.SetVerifyCode(ctx, reqDto.Email, code, verifyCodeTTL)
 	if err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
 
-	if err := us.emailService.SendResetPasswordMail(reqDto.Email, code); err != nil {
+	if err := us.emailService.SendResetPasswordMail(ctx, reqDto.Lang, reqDto.Email, code, verifyCodeTTL); err != nil {
 		return erru.ErrInternalServer.Wrap(err)
 	}
 
 	return nil
 }
 
-func (us *UserService) VerifyReset(reqDto *dto.VerifyResetReqDTO) error {
+func (us *UserService) VerifyReset(ctx context.Context, reqDto *dto.VerifyResetReqDTO) error {
 
 	// 1.检查是否存在user
-	user, err := us.userDAO.GetUserByEmail(reqDto.Email)
+	user, err := us.userDAO.GetUserByEmail(ctx, reqDto.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return erru.ErrUserNotFound
@@ -166,9 +287,8 @@ func (us *UserService) VerifyReset(reqDto *dto.VerifyResetReqDTO) error {
 		return erru.ErrInternalServer.Wrap(err)
 	}
 	// 2.检查验证码是否正确
-	code, err := us.redisClient.GetVerificationCode(reqDto.Email)
-	if err != nil || code != reqDto.Code {
-		return erru.New("验证码错误")
+	if err := us.checkVerifyCode(ctx, reqDto.Email, reqDto.Code); err != nil {
+		return err
 	}
 
 	// 3.检查密码是否相同
@@ -182,7 +302,16 @@ func (us *UserService) VerifyReset(reqDto *dto.VerifyResetReqDTO) error {
 	if err != nil {
 		return erru.New("加密失败")
 	}
-	err = us.userDAO.UpdateUserPassword(user.ID, string(newPwd))
+	err = us.userDAO.UpdateUserPassword(ctx, user.ID, string(newPwd))
+	if err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
+	us.redisClient.DelVerificationCode(ctx, reqDto.Email)
+
+	// 5.密码已修改，注销该用户的所有会话，旧 token 立即失效
+	if err := us.redisClient.DeleteUserSessions(ctx, user.ID); err != nil {
+		return erru.ErrInternalServer.Wrap(err)
+	}
 
-	return err
+	return nil
 }
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"Nuxus/configs"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalRoute 本地存储的文件由 Gin 在这个路径下提供
+const LocalRoute = "/uploads/"
+
+// ErrInvalidSignature 私有文件的签名不正确或已过期
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// LocalStore 把对象保存在本地磁盘上，适合开发环境和单机部署
+type LocalStore struct {
+	root    string
+	baseURL string
+	private bool
+	secret  []byte
+}
+
+func NewLocalStore(conf *configs.LocalStorageConfig) (*LocalStore, error) {
+	if conf.Private && conf.SignSecret == "" {
+		return nil, errors.New("storage.local.signSecret is required for private local storage")
+	}
+	if err := os.MkdirAll(conf.Root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{
+		root:    conf.Root,
+		baseURL: strings.TrimSuffix(conf.BaseURL, "/"),
+		private: conf.Private,
+		secret:  []byte(conf.SignSecret),
+	}, nil
+}
+
+// filePath 把对象键转换成磁盘路径，清理掉 .. 之类的成分，保证不会跳出根目录
+func (l *LocalStore) filePath(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (l *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	dst := l.filePath(key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	// 先写临时文件再改名，避免读到写了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (l *LocalStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(l.filePath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *LocalStore) URL(key string, expires time.Duration) (string, error) {
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	u := l.baseURL + LocalRoute + key
+	if !l.private {
+		return u, nil
+	}
+	deadline := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	query := url.Values{"e": {deadline}, "s": {l.sign(key, deadline)}}
+	return u + "?" + query.Encode(), nil
+}
+
+func (l *LocalStore) Private() bool {
+	return l.private
+}
+
+// Open 校验访问参数并返回文件在磁盘上的路径，供静态文件路由使用
+func (l *LocalStore) Open(key string, query url.Values) (string, error) {
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if l.private {
+		deadline := query.Get("e")
+		expireAt, err := strconv.ParseInt(deadline, 10, 64)
+		if err != nil || time.Now().Unix() > expireAt {
+			return "", ErrInvalidSignature
+		}
+		if !hmac.Equal([]byte(query.Get("s")), []byte(l.sign(key, deadline))) {
+			return "", ErrInvalidSignature
+		}
+	}
+	p := l.filePath(key)
+	info, err := os.Stat(p)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fs.ErrNotExist
+	}
+	return p, nil
+}
+
+func (l *LocalStore) sign(key, deadline string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(key + "\n" + deadline))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"Nuxus/configs"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+// QiniuStore 七牛云 Kodo
+type QiniuStore struct {
+	conf   *configs.QiniuConfig
+	mac    *auth.Credentials
+	cfg    *storage.Config
+	domain string // 带协议的访问域名
+}
+
+func NewQiniuStore(conf *configs.QiniuConfig) *QiniuStore {
+	domain := conf.Domain
+	if !strings.Contains(domain, "://") {
+		scheme := "http://"
+		if conf.UseHTTPS {
+			scheme = "https://"
+		}
+		domain = scheme + domain
+	}
+	return &QiniuStore{
+		conf: conf,
+		mac:  qbox.NewMac(conf.AccessKey, conf.SecretKey),
+		cfg: &storage.Config{
+			Zone:     getQiniuZone(conf.Zone),
+			UseHTTPS: conf.UseHTTPS,
+		},
+		domain: strings.TrimSuffix(domain, "/"),
+	}
+}
+
+func getQiniuZone(zoneStr string) *storage.Zone {
+	switch zoneStr {
+	case "ZoneHuadong":
+		return &storage.ZoneHuadong
+	case "ZoneHuabei":
+		return &storage.ZoneHuabei
+	case "ZoneHuanan":
+		return &storage.ZoneHuanan
+	case "ZoneBeimei":
+		return &storage.ZoneBeimei
+	case "ZoneXinjiapo":
+		return &storage.ZoneXinjiapo
+	default:
+		return &storage.ZoneHuadong // 默认华东
+	}
+}
+
+func (q *QiniuStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	// 指定 key 的上传凭证，允许覆盖同名对象
+	putPolicy := storage.PutPolicy{Scope: q.conf.Bucket + ":" + key}
+	uploadToken := putPolicy.UploadToken(q.mac)
+
+	formUploader := storage.NewFormUploader(q.cfg)
+	ret := storage.PutRet{}
+	extra := &storage.PutExtra{MimeType: contentType}
+	return formUploader.Put(ctx, &ret, uploadToken, key, r, size, extra)
+}
+
+func (q *QiniuStore) Delete(ctx context.Context, key string) error {
+	err := storage.NewBucketManager(q.mac, q.cfg).Delete(q.conf.Bucket, key)
+	// 612: 对象不存在
+	var qiniuErr *storage.ErrorInfo
+	if errors.As(err, &qiniuErr) && qiniuErr.Code == 612 {
+		return nil
+	}
+	return err
+}
+
+func (q *QiniuStore) URL(key string, expires time.Duration) (string, error) {
+	if !q.conf.Private {
+		return storage.MakePublicURLv2(q.domain, key), nil
+	}
+	deadline := time.Now().Add(expires).Unix()
+	return storage.MakePrivateURLv2(q.mac, q.domain, key, deadline), nil
+}
+
+func (q *QiniuStore) Private() bool {
+	return q.conf.Private
+}
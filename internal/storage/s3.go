@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"Nuxus/configs"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store 兼容 S3 协议的对象存储，如 AWS S3、MinIO、Cloudflare R2
+type S3Store struct {
+	client  *minio.Client
+	bucket  string
+	private bool
+	baseURL string // 公开空间的访问地址前缀
+}
+
+func NewS3Store(conf *configs.S3StorageConfig) (*S3Store, error) {
+	if conf.Bucket == "" {
+		return nil, errors.New("storage.s3.bucket is required")
+	}
+	client, err := minio.New(conf.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(conf.AccessKey, conf.SecretKey, ""),
+		Secure: conf.UseSSL,
+		Region: conf.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := strings.TrimSuffix(conf.PublicBaseURL, "/")
+	if baseURL == "" {
+		// 默认使用路径风格的地址：<endpoint>/<bucket>
+		endpoint := client.EndpointURL()
+		baseURL = endpoint.Scheme + "://" + endpoint.Host + "/" + conf.Bucket
+	}
+	return &S3Store{
+		client:  client,
+		bucket:  conf.Bucket,
+		private: conf.Private,
+		baseURL: baseURL,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	// S3 删除不存在的对象本身就不报错
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Store) URL(key string, expires time.Duration) (string, error) {
+	if !s.private {
+		return s.baseURL + "/" + (&url.URL{Path: key}).EscapedPath(), nil
+	}
+	u, err := s.client.PresignedGetObject(context.Background(), s.bucket, key, expires, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (s *S3Store) Private() bool {
+	return s.private
+}
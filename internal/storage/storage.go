@@ -0,0 +1,55 @@
+// Package storage 对象存储的统一接口，后端可以是七牛云、本地磁盘或兼容 S3 的服务
+package storage
+
+import (
+	"Nuxus/configs"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// 对象存储后端
+const (
+	DriverQiniu = "qiniu"
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// FileRoute 私有空间对象的跳转接口，访问时重新生成签名地址
+const FileRoute = "/api/v1/files/"
+
+// ObjectStore 对象存储，key 是对象在存储中的路径，如 avatars/1/xxx.png
+type ObjectStore interface {
+	// Put 上传对象，同名对象会被覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete 删除对象，对象不存在时不报错
+	Delete(ctx context.Context, key string) error
+	// URL 返回对象的访问地址，私有空间返回 expires 后失效的签名地址
+	URL(key string, expires time.Duration) (string, error)
+	// Private 是否为私有空间，私有空间的签名地址会过期，不能直接保存
+	Private() bool
+}
+
+// NewObjectStore 根据配置创建对象存储
+func NewObjectStore(config *configs.Config) (ObjectStore, error) {
+	switch config.Storage.Driver {
+	case DriverQiniu:
+		return NewQiniuStore(&config.Qiniu), nil
+	case DriverLocal:
+		return NewLocalStore(&config.Storage.Local)
+	case DriverS3:
+		return NewS3Store(&config.Storage.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.Storage.Driver)
+	}
+}
+
+// StableURL 返回可以保存到数据库的地址
+// 公开空间直接是对象地址；私有空间指向 FileRoute，由跳转接口在每次访问时重新签名
+func StableURL(store ObjectStore, key string) (string, error) {
+	if store.Private() {
+		return FileRoute + key, nil
+	}
+	return store.URL(key, 0)
+}
@@ -0,0 +1,19 @@
+package tasks
+
+import (
+	"Nuxus/internal/service"
+	"context"
+)
+
+type CleanupTask struct {
+	attachmentService *service.AttachmentService
+}
+
+func NewCleanupTask(attachmentService *service.AttachmentService) *CleanupTask {
+	return &CleanupTask{attachmentService: attachmentService}
+}
+
+// CleanOrphanAttachments 清理上传后一直没有被帖子引用的配图
+func (t *CleanupTask) CleanOrphanAttachments() {
+	t.attachmentService.CleanOrphans(context.Background())
+}
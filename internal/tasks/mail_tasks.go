@@ -0,0 +1,35 @@
+package tasks
+
+import (
+	"Nuxus/internal/service"
+	"context"
+	"log/slog"
+	"time"
+)
+
+const mailPollTimeout = 5 * time.Second // 发送队列为空时每次阻塞等待的时间
+
+type MailTask struct {
+	emailService *service.EmailService
+	logger       *slog.Logger
+}
+
+func NewMailTask(emailService *service.EmailService, logger *slog.Logger) *MailTask {
+	return &MailTask{emailService: emailService, logger: logger}
+}
+
+// Run 持续从发送队列取出邮件发送，直到 ctx 被取消
+func (t *MailTask) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := t.emailService.DeliverNext(ctx, mailPollTimeout); err != nil && ctx.Err() == nil {
+			t.logger.ErrorContext(ctx, "读取邮件队列失败", "err", err)
+			// Redis 不可用时避免空转
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// RequeueRetries 把到了重试时间的邮件移回发送队列
+func (t *MailTask) RequeueRetries() {
+	t.emailService.RequeueDueRetries(context.Background())
+}
@@ -0,0 +1,19 @@
+package tasks
+
+import (
+	"Nuxus/internal/service"
+	"context"
+)
+
+type PublishTask struct {
+	postService *service.PostService
+}
+
+func NewPublishTask(postService *service.PostService) *PublishTask {
+	return &PublishTask{postService: postService}
+}
+
+// PublishScheduledPosts 发布到达发布时间的定时帖子
+func (t *PublishTask) PublishScheduledPosts() {
+	t.postService.PublishDuePosts(context.Background())
+}
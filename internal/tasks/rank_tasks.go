@@ -0,0 +1,19 @@
+package tasks
+
+import (
+	"Nuxus/internal/service"
+	"context"
+)
+
+type RankTask struct {
+	rankService *service.RankService
+}
+
+func NewRankTask(rankService *service.RankService) *RankTask {
+	return &RankTask{rankService: rankService}
+}
+
+// RefreshRanks 重新计算热门榜，并汇总周榜、裁剪累计榜
+func (t *RankTask) RefreshRanks() {
+	t.rankService.Refresh(context.Background())
+}
@@ -1,90 +1,154 @@
 package tasks
 
 import (
+	"Nuxus/configs"
 	"Nuxus/internal/dao"
+	"Nuxus/internal/metrics"
 	"context"
-	"log"
+	"log/slog"
 	"strconv"
-	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+// flushLockTTL 写入浏览量的锁的有效期，要大于一次写入的耗时，实例崩溃后锁最多保留这么久
+const flushLockTTL = time.Minute
+
+// flushDBTimeout 从拿到锁开始，写入数据库的事务必须在这段时间内完成，否则回滚留到下次。
+// 要比 flushLockTTL 短，保证锁过期、其他实例重新读取 processing 之前事务已经结束
+const flushDBTimeout = 40 * time.Second
+
 type SyncTask struct {
 	postDAO     *dao.PostDAO
 	redisClient *dao.RedisClient
+	config      *configs.Config
+	logger      *slog.Logger
+	metrics     *metrics.Metrics
+
+	// 旧版本的浏览量 key 每个进程只迁移一次
+	migrateOnce sync.Once
 }
 
-func NewSyncTask(postDAO *dao.PostDAO, redisClient *dao.RedisClient) *SyncTask {
+func NewSyncTask(postDAO *dao.PostDAO, redisClient *dao.RedisClient, config *configs.Config, logger *slog.Logger, m *metrics.Metrics) *SyncTask {
 	return &SyncTask{
+		postDAO:     postDAO,
 		redisClient: redisClient,
+		config:      config,
+		logger:      logger,
+		metrics:     m,
 	}
 }
 
+// SyncViewCountsToDB 把 Redis 中累计的浏览量写入数据库：
+// 1. 把 pending 中的浏览量原子地合并到 processing 中，之后的浏览继续计入 pending
+// 2. 在一个事务中把 processing 中的浏览量累加到帖子上，事务要在锁过期之前结束
+// 3. 事务提交后才删除 processing，失败时留到下次和新的浏览量一起写入
 func (s *SyncTask) SyncViewCountsToDB() {
-	log.Println("开始同步浏览量到DB")
-
 	ctx := context.Background()
-	var cursor uint64
-	var keys []string
-	var err error
+	s.logger.InfoContext(ctx, "开始同步浏览量到DB")
 
-	// 1. 使用 SCAN 安全地遍历所有帖子的浏览量 Key
-	// 我们每次扫描100个key，直到游标回到0
-	matchPattern := "nexus:post:view:*"
-	for {
-		var scanResult []string
-		scanResult, cursor, err = s.redisClient.Scan(ctx, cursor, matchPattern, 100)
-		if err != nil {
-			log.Printf("扫描 Redis Key 失败: %v", err)
-			return // 发生错误，终止本次任务
-		}
-		keys = append(keys, scanResult...)
-		if cursor == 0 { // 遍历完成
-			break
-		}
+	// 任何一个阶段出错都算这次同步失败
+	failed := false
+	fail := func(stage string) {
+		failed = true
+		s.metrics.SyncFailures.WithLabelValues(stage).Inc()
 	}
+	defer func() {
+		if failed {
+			s.metrics.SyncRuns.WithLabelValues("failure").Inc()
+			return
+		}
+		s.metrics.SyncRuns.WithLabelValues("success").Inc()
+		s.metrics.SyncLastSuccess.Set(float64(time.Now().Unix()))
+	}()
 
-	if len(keys) == 0 {
-		log.Println("没有需要同步的浏览量数据。")
+	// 多个实例同时运行时只让一个写入，避免同一份 processing 被重复累加
+	token := uuid.New().String()
+	locked, err := s.redisClient.AcquireViewFlushLock(ctx, token, flushLockTTL)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "获取浏览量同步锁失败", "err", err)
+		fail("lock")
+		return
+	}
+	if !locked {
+		s.logger.InfoContext(ctx, "其他实例正在同步浏览量，跳过本次")
 		return
 	}
-	log.Printf("发现 %d 个需要同步的帖子浏览量。", len(keys))
+	dbCtx, cancel := context.WithTimeout(ctx, flushDBTimeout)
+	defer cancel()
+	defer func() {
+		if err := s.redisClient.ReleaseViewFlushLock(ctx, token); err != nil {
+			s.logger.WarnContext(ctx, "释放浏览量同步锁失败", "err", err)
+		}
+	}()
 
-	// 2. 遍历所有 Key，进行数据同步
-	for _, key := range keys {
-		// 3. 施展“乾坤挪移”大法 (GETSET)，原子性地获取并清零
-		// GETSET key 0 会返回 key 的旧值，然后将 key 的值设为 "0"
-		// 这确保了我们拿到的是准确的增量，并且不会丢失在操作期间的新增浏览
-		incrementStr, err := s.redisClient.GetSet(ctx, key, "0")
+	s.migrateOnce.Do(func() {
+		moved, err := s.redisClient.MigrateLegacyViewCounts(ctx)
 		if err != nil {
-			log.Printf("获取并重置 Key [%s] 失败: %v", key, err)
-			continue // 跳过这个 key，处理下一个
+			// 没迁移完的 key 不会丢失，下次启动时继续迁移
+			s.logger.ErrorContext(ctx, "迁移旧版本浏览量失败", "moved", moved, "err", err)
+			fail("migrate")
+			return
 		}
-
-		increment, _ := strconv.ParseInt(incrementStr, 10, 64)
-		if increment == 0 {
-			continue // 如果增量为0，无需更新数据库
+		if moved > 0 {
+			s.logger.InfoContext(ctx, "已迁移旧版本浏览量", "keys", moved)
 		}
+	})
 
-		// 4. 从 Key 中解析出 Post ID
-		// key 的格式是 "nexus:post:view:123"
-		parts := strings.Split(key, ":")
-		if len(parts) != 4 {
-			log.Printf("无效的 Key 格式: %s", key)
+	pending, err := s.redisClient.MovePendingViews(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "移动待同步浏览量失败", "err", err)
+		fail("move")
+		return
+	}
+	s.metrics.SyncPendingPosts.Set(float64(pending))
+	if pending == 0 {
+		s.logger.InfoContext(ctx, "没有需要同步的浏览量数据")
+		return
+	}
+
+	views, err := s.redisClient.GetProcessingViews(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "读取待同步浏览量失败", "err", err)
+		fail("read")
+		return
+	}
+	counts := make(map[uint]int64, len(views))
+	var total int64
+	for field, value := range views {
+		postID, err := strconv.ParseUint(field, 10, 64)
+		increment, err2 := strconv.ParseInt(value, 10, 64)
+		if err != nil || err2 != nil || postID == 0 {
+			// 无法解析的字段随 processing 一起删除，避免每次都失败
+			s.logger.WarnContext(ctx, "无效的浏览量数据", "post_id", field, "increment", value)
+			fail("parse")
 			continue
 		}
-		postID, _ := strconv.ParseUint(parts[3], 10, 64)
-		if postID == 0 {
+		if increment <= 0 {
 			continue
 		}
+		counts[uint(postID)] = increment
+		total += increment
+	}
+	s.logger.InfoContext(ctx, "发现需要同步的帖子浏览量", "count", len(counts))
 
-		// 5. 将增量归入“宗门宝库” (MySQL)
-		err = s.postDAO.AddPostViewCount(uint(postID), int(increment))
-		if err != nil {
-			log.Printf("更新帖子ID [%d] 的浏览量失败: %v。丢失的增量: %d", postID, err, increment)
-			// 重要：这里需要有错误处理策略。最简单的是记录日志。
-			// 复杂些可以把失败的任务放入一个“重试队列”。
-			return
-		}
+	if err := s.postDAO.AddPostViewCounts(dbCtx, counts, s.config.View.FlushBatchSize); err != nil {
+		// 事务已回滚（包括超时），processing 保留到下次重试
+		s.logger.ErrorContext(ctx, "更新帖子浏览量失败，下次重试", "posts", len(counts), "increment", total, "err", err)
+		fail("update")
+		return
+	}
+	if total > 0 {
+		s.metrics.SyncIncrementsFlushed.Add(float64(total))
+	}
+
+	if err := s.redisClient.DelProcessingViews(ctx); err != nil {
+		// 删除失败时下次会重复累加这部分浏览量，只能记录下来
+		s.logger.ErrorContext(ctx, "删除已同步的浏览量失败", "posts", len(counts), "increment", total, "err", err)
+		fail("delete")
+		return
 	}
-	log.Println("同步帖子浏览量任务完成。")
+	s.logger.InfoContext(ctx, "同步帖子浏览量任务完成", "posts", len(counts), "increment", total)
 }
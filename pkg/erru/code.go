@@ -6,18 +6,27 @@ const (
 	OK                  = 0
 	InternalServerError = 10001
 	InvalidParams       = 10002
+	TooManyRequests     = 10003 // 触发接口限流
+	ServiceUnavailable  = 10004 // 依赖的 MySQL、Redis 等不可用
 
 	// ================== 用户相关错误 =================
-	UserNotFound      = 20001
-	PasswordIncorrect = 20002
-	EmailAlreadyUsed  = 20003
-	InvalidVerifyCode = 20004
+	UserNotFound          = 20001
+	PasswordIncorrect     = 20002
+	EmailAlreadyUsed      = 20003
+	InvalidVerifyCode     = 20004
+	UserBanned            = 20005
+	PasswordResetRequired = 20006
+	TooManyAttempts       = 20007 // 失败次数过多，暂时锁定
 
 	// ================== 认证授权相关 =================
 	TokenNotFound = 30001
 	TokenInvalid  = 30002
 	TokenExpired  = 30003
 	Unauthorized  = 30004 // 已认证，但无权访问资源
+	TokenRevoked  = 30005 // 会话已被撤销（退出登录、修改密码等）
+
+	RefreshTokenInvalid = 30006
+	RefreshTokenReused  = 30007 // 已轮换的刷新令牌被再次使用，疑似泄露
 
 	// ================== 资源相关错误 =================
 	ResourceNotFound     = 40001
@@ -28,19 +37,28 @@ const (
 
 // 预先定义好常用的错误，可以直接在代码中使用
 var (
-	ErrOK             = &AppError{Code: OK, Msg: "成功"}
-	ErrInternalServer = &AppError{Code: InternalServerError, Msg: "服务器内部错误"}
-	ErrInvalidParams  = &AppError{Code: InvalidParams, Msg: "参数无效"}
+	ErrOK                 = &AppError{Code: OK, Msg: "成功"}
+	ErrInternalServer     = &AppError{Code: InternalServerError, Msg: "服务器内部错误"}
+	ErrInvalidParams      = &AppError{Code: InvalidParams, Msg: "参数无效"}
+	ErrTooManyRequests    = &AppError{Code: TooManyRequests, Msg: "请求过于频繁，请稍后再试"}
+	ErrServiceUnavailable = &AppError{Code: ServiceUnavailable, Msg: "服务暂不可用"}
 
-	ErrUserNotFound      = &AppError{Code: UserNotFound, Msg: "用户不存在"}
-	ErrPasswordIncorrect = &AppError{Code: PasswordIncorrect, Msg: "密码错误"}
-	ErrEmailAlreadyUsed  = &AppError{Code: EmailAlreadyUsed, Msg: "邮箱已被注册"}
-	ErrInvaliVerifyCode  = &AppError{Code: InvalidVerifyCode, Msg: "验证码错误"}
+	ErrUserNotFound          = &AppError{Code: UserNotFound, Msg: "用户不存在"}
+	ErrPasswordIncorrect     = &AppError{Code: PasswordIncorrect, Msg: "密码错误"}
+	ErrEmailAlreadyUsed      = &AppError{Code: EmailAlreadyUsed, Msg: "邮箱已被注册"}
+	ErrInvaliVerifyCode      = &AppError{Code: InvalidVerifyCode, Msg: "验证码错误"}
+	ErrUserBanned            = &AppError{Code: UserBanned, Msg: "账号已被封禁"}
+	ErrPasswordResetRequired = &AppError{Code: PasswordResetRequired, Msg: "管理员要求您重置密码，请通过邮箱重置后再登录"}
+	ErrTooManyAttempts       = &AppError{Code: TooManyAttempts, Msg: "尝试次数过多，请稍后再试"}
 
 	ErrTokenNotFound = &AppError{Code: TokenNotFound, Msg: "未找到认证Token"}
 	ErrTokenInvalid  = &AppError{Code: TokenInvalid, Msg: "认证Token无效"}
 	ErrTokenExpired  = &AppError{Code: TokenExpired, Msg: "认证Token已过期"}
 	ErrUnauthorized  = &AppError{Code: Unauthorized, Msg: "无权执行此操作"}
+	ErrTokenRevoked  = &AppError{Code: TokenRevoked, Msg: "登录状态已失效，请重新登录"}
+
+	ErrRefreshTokenInvalid = &AppError{Code: RefreshTokenInvalid, Msg: "刷新令牌无效"}
+	ErrRefreshTokenReused  = &AppError{Code: RefreshTokenReused, Msg: "刷新令牌已被使用，会话已注销，请重新登录"}
 
 	ErrResourceNotFound     = &AppError{Code: ResourceNotFound, Msg: "资源未找到"}
 	ErrInvalidRequestHeader = &AppError{Code: InvalidRequestHeader, Msg: "请求头错误"}
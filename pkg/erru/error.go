@@ -7,6 +7,7 @@ type AppError struct {
 	Code int    // 业务错误码
 	Msg  string // 面向用户的错误信息
 	Err  error  // 包装的原始错误，用于日志记录
+	Data any    // 随错误返回给客户端的附加数据，如需要等待的时间
 }
 
 // Error 实现 Go 内置的 error 接口
@@ -30,6 +31,27 @@ func (e *AppError) Wrap(err error) *AppError {
 		Code: e.Code,
 		Msg:  e.Msg,
 		Err:  err,
+		Data: e.Data,
+	}
+}
+
+// WithMsg 返回一个使用相同错误码、但替换了用户提示信息的新实例
+func (e *AppError) WithMsg(msg string) *AppError {
+	return &AppError{
+		Code: e.Code,
+		Msg:  msg,
+		Err:  e.Err,
+		Data: e.Data,
+	}
+}
+
+// WithData 返回一个附带了响应数据的新实例
+func (e *AppError) WithData(data any) *AppError {
+	return &AppError{
+		Code: e.Code,
+		Msg:  e.Msg,
+		Err:  e.Err,
+		Data: data,
 	}
 }
 
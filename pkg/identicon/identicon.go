@@ -0,0 +1,69 @@
+// Package identicon 根据字符串生成左右对称的像素头像，同一个字符串总是得到同一张图
+package identicon
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+const grid = 5 // 5×5 的格子，左三列由哈希决定，右两列镜像
+
+var background = color.RGBA{R: 240, G: 240, B: 240, A: 255}
+
+// New 生成 size×size 的头像，四周留出约一格的边距
+func New(seed string, size int) image.Image {
+	sum := sha256.Sum256([]byte(seed))
+
+	// 前两个字节决定色相，第三个字节微调饱和度，亮度固定，保证颜色不会太浅或太暗
+	hue := float64(uint16(sum[0])<<8|uint16(sum[1])) / 65536
+	saturation := 0.45 + float64(sum[2])/255*0.25
+	foreground := hslToRGB(hue, saturation, 0.55)
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{background, foreground})
+	cell := size / (grid + 1)
+	offset := (size - cell*grid) / 2
+	for row := 0; row < grid; row++ {
+		for col := 0; col < (grid+1)/2; col++ {
+			if sum[3+row*3+col]&1 == 0 {
+				continue
+			}
+			for _, c := range []int{col, grid - 1 - col} {
+				rect := image.Rect(offset+c*cell, offset+row*cell, offset+(c+1)*cell, offset+(row+1)*cell)
+				draw.Draw(img, rect, &image.Uniform{C: foreground}, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img
+}
+
+// hslToRGB h、s、l 的取值范围都是 [0, 1)
+func hslToRGB(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h*6, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch int(h * 6) {
+	case 0:
+		r, g, b = c, x, 0
+	case 1:
+		r, g, b = x, c, 0
+	case 2:
+		r, g, b = 0, c, x
+	case 3:
+		r, g, b = 0, x, c
+	case 4:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 255,
+	}
+}
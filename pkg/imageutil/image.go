@@ -0,0 +1,266 @@
+// Package imageutil 校验用户上传的图片，重新编码去掉元数据，并生成缩略图
+package imageutil
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // 注册 WebP 解码器
+)
+
+// 支持的图片类型
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+	TypeGIF  = "image/gif"
+	TypeWebP = "image/webp"
+)
+
+const jpegQuality = 88
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image dimensions exceed limit")
+	ErrInvalidCrop     = errors.New("crop box is outside the image")
+)
+
+// Limits 图片尺寸限制，为 0 的项不限制
+type Limits struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int
+	// 只对保留所有帧的 GIF 生效：帧数，以及所有帧的像素之和（解码后每个像素占一个字节）
+	MaxFrames      int
+	MaxTotalPixels int
+}
+
+// Image 编码后的图片
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Sniff 根据文件内容（而不是扩展名或客户端声明的类型）判断图片类型
+func Sniff(data []byte) (string, error) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case TypeJPEG, TypePNG, TypeGIF, TypeWebP:
+		return contentType, nil
+	default:
+		return "", ErrUnsupportedType
+	}
+}
+
+// Ext 图片类型对应的扩展名
+func Ext(contentType string) string {
+	switch contentType {
+	case TypeJPEG:
+		return ".jpg"
+	case TypePNG:
+		return ".png"
+	case TypeGIF:
+		return ".gif"
+	case TypeWebP:
+		return ".webp"
+	default:
+		return ""
+	}
+}
+
+// Decode 校验尺寸后解码图片，JPEG 会按 EXIF 中的方向旋转，GIF 只取第一帧
+func Decode(data []byte, limits Limits) (image.Image, error) {
+	if err := checkLimits(data, limits); err != nil {
+		return nil, err
+	}
+	return imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+}
+
+// checkLimits 只读取头部的尺寸，超限的图片不做完整解码
+func checkLimits(data []byte, limits Limits) error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if (limits.MaxWidth > 0 && cfg.Width > limits.MaxWidth) ||
+		(limits.MaxHeight > 0 && cfg.Height > limits.MaxHeight) ||
+		(limits.MaxPixels > 0 && cfg.Width*cfg.Height > limits.MaxPixels) {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// Sanitize 校验尺寸后解码并重新编码图片，EXIF、注释等元数据不会被带到新文件中
+// JPEG 会先按 EXIF 中的方向旋转，GIF 保留所有帧
+// 返回重新编码后的图片和解码出的图像（GIF 为第一帧），后者用于生成缩略图
+func Sanitize(data []byte, contentType string, limits Limits) (*Image, image.Image, error) {
+	if contentType == TypeGIF {
+		if err := checkLimits(data, limits); err != nil {
+			return nil, nil, err
+		}
+		if err := checkGIFFrames(data, limits); err != nil {
+			return nil, nil, err
+		}
+		return sanitizeGIF(data)
+	}
+
+	img, err := Decode(data, limits)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := Encode(img, contentType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, img, nil
+}
+
+// checkGIFFrames 解码所有帧之前先扫描 GIF 的块结构，统计帧数和每帧的像素，
+// 防止高度压缩的小文件解码出大量的大尺寸帧
+func checkGIFFrames(data []byte, limits Limits) error {
+	if limits.MaxFrames <= 0 && limits.MaxTotalPixels <= 0 {
+		return nil
+	}
+	// 文件头 6 字节，逻辑屏幕描述符 7 字节
+	if len(data) < 13 {
+		return errGIFFormat
+	}
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1) // 全局颜色表
+	}
+	frames, pixels := 0, 0
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // 扩展块：标签后面是数据子块
+			end, err := skipSubBlocks(data, pos+2)
+			if err != nil {
+				return err
+			}
+			pos = end
+		case 0x2C: // 图像描述符：位置和尺寸各 2 字节，之后是标志位
+			if pos+10 > len(data) {
+				return errGIFFormat
+			}
+			width := int(data[pos+5]) | int(data[pos+6])<<8
+			height := int(data[pos+7]) | int(data[pos+8])<<8
+			frames++
+			pixels += width * height
+			if (limits.MaxFrames > 0 && frames > limits.MaxFrames) ||
+				(limits.MaxTotalPixels > 0 && pixels > limits.MaxTotalPixels) {
+				return ErrTooLarge
+			}
+			next := pos + 10
+			if flags := data[pos+9]; flags&0x80 != 0 {
+				next += 3 << (flags&0x07 + 1) // 局部颜色表
+			}
+			// 跳过 LZW 最小码长，之后是图像数据子块
+			end, err := skipSubBlocks(data, next+1)
+			if err != nil {
+				return err
+			}
+			pos = end
+		case 0x3B: // 文件结束
+			return nil
+		default:
+			return errGIFFormat
+		}
+	}
+	return nil
+}
+
+var errGIFFormat = errors.New("gif: malformed block structure")
+
+// skipSubBlocks 跳过从 pos 开始的数据子块（长度字节 + 数据，以长度 0 结束），返回之后的位置
+func skipSubBlocks(data []byte, pos int) (int, error) {
+	for {
+		if pos >= len(data) {
+			return 0, errGIFFormat
+		}
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			return pos, nil
+		}
+		pos += size
+	}
+}
+
+func sanitizeGIF(data []byte) (*Image, image.Image, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	// EncodeAll 只写出帧、调色板和循环次数，注释和其他扩展块会被丢弃
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, nil, err
+	}
+	out := &Image{
+		Data:        buf.Bytes(),
+		ContentType: TypeGIF,
+		Width:       g.Config.Width,
+		Height:      g.Config.Height,
+	}
+	return out, g.Image[0], nil
+}
+
+// Thumbnail 把图像等比缩放到 width 宽并编码为 contentType，原图不比 width 宽时不放大
+// GIF 缩略图只取第一帧，编码为 PNG
+func Thumbnail(img image.Image, width int, contentType string) (*Image, error) {
+	if img.Bounds().Dx() > width {
+		img = imaging.Resize(img, width, 0, imaging.Lanczos)
+	}
+	if contentType == TypeGIF {
+		contentType = TypePNG
+	}
+	return Encode(img, contentType)
+}
+
+// Crop 按裁剪框裁剪图像，坐标相对于图像左上角，超出图像的部分会被忽略
+func Crop(img image.Image, box image.Rectangle) (image.Image, error) {
+	bounds := img.Bounds()
+	box = box.Add(bounds.Min).Intersect(bounds)
+	if box.Empty() {
+		return nil, ErrInvalidCrop
+	}
+	return imaging.Crop(img, box), nil
+}
+
+// Square 从图像中间裁出最大的正方形，再缩放为 size×size
+func Square(img image.Image, size int) image.Image {
+	return imaging.Fill(img, size, size, imaging.Center, imaging.Lanczos)
+}
+
+// Encode 把图像编码为 contentType，支持 JPEG、PNG、WebP
+func Encode(img image.Image, contentType string) (*Image, error) {
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case TypeJPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case TypePNG:
+		err = png.Encode(&buf, img)
+	case TypeWebP:
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		err = ErrUnsupportedType
+	}
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	return &Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+	}, nil
+}
@@ -0,0 +1,173 @@
+// Package markdown 把帖子的 Markdown 正文渲染成经过过滤的 HTML，并提取目录和纯文本摘要
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Heading 目录中的一个标题，ID 与渲染后 HTML 中标题的 id 属性一致
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+// Document 渲染结果
+type Document struct {
+	HTML string     `json:"html"`
+	TOC  []*Heading `json:"toc"`
+}
+
+var md = goldmark.New(
+	// GFM：表格、删除线、自动链接、任务列表
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+)
+
+// policy 在 UGC 策略的基础上放行代码块的语言 class、标题锚点和任务列表的复选框
+// 原始 HTML 由 goldmark 默认转义，这里是第二道防线
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}()
+
+// Render 渲染 Markdown，返回过滤后的 HTML 和标题目录
+func Render(source string) (*Document, error) {
+	src := []byte(source)
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
+		return nil, err
+	}
+	return &Document{
+		HTML: policy.Sanitize(buf.String()),
+		TOC:  tableOfContents(doc, src),
+	}, nil
+}
+
+func tableOfContents(doc ast.Node, src []byte) []*Heading {
+	toc := make([]*Heading, 0)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		id, _ := heading.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		toc = append(toc, &Heading{
+			Level: heading.Level,
+			Text:  plainText(heading, src),
+			ID:    string(idBytes),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return toc
+}
+
+// Excerpt 提取正文开头的纯文本，去掉 Markdown 标记、代码块和图片，最多 maxLen 个字符
+func Excerpt(source string, maxLen int) string {
+	src := []byte(source)
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	var sb strings.Builder
+	for block := doc.FirstChild(); block != nil && utf8.RuneCountInString(sb.String()) < maxLen; block = block.NextSibling() {
+		if t := plainText(block, src); t != "" {
+			if sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(t)
+		}
+	}
+
+	excerpt := []rune(sb.String())
+	if len(excerpt) <= maxLen {
+		return string(excerpt)
+	}
+	return string(excerpt[:maxLen]) + "…"
+}
+
+// plainText 拼接节点下的文本，块级元素之间用空格分隔，多余的空白会被合并
+func plainText(node ast.Node, src []byte) string {
+	var sb strings.Builder
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock, *ast.RawHTML, *ast.Image, *extast.TaskCheckBox:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			sb.Write(n.Segment.Value(src))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(n.Value)
+		case *ast.AutoLink:
+			sb.Write(n.Label(src))
+		default:
+			if n.Type() == ast.TypeBlock && sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// headingIDs 生成标题锚点，保留中文等非 ASCII 字符（goldmark 默认会把它们去掉），重复的锚点追加序号
+type headingIDs struct {
+	used map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: make(map[string]bool)}
+}
+
+func (h *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(string(value)) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_' {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	base := strings.TrimSuffix(sb.String(), "-")
+	if base == "" {
+		base = "heading"
+	}
+
+	id := base
+	for i := 1; h.used[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	h.used[id] = true
+	return []byte(id)
+}
+
+func (h *headingIDs) Put(value []byte) {
+	h.used[string(value)] = true
+}
@@ -0,0 +1,56 @@
+// nexus/pkg/rbac/rbac.go
+package rbac
+
+// 角色，对应 models.User.Role 列
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Permission 表示一项可以授予角色的操作权限
+type Permission string
+
+const (
+	PermPostEditAny      Permission = "post:edit_any"      // 编辑任意帖子
+	PermPostDeleteAny    Permission = "post:delete_any"    // 删除任意帖子
+	PermCommentEditAny   Permission = "comment:edit_any"   // 编辑任意评论
+	PermCommentDeleteAny Permission = "comment:delete_any" // 删除任意评论
+	PermUserManage       Permission = "user:manage"        // 管理用户（封禁、改角色等）
+	PermTagManage        Permission = "tag:manage"         // 管理标签
+	PermReportHandle     Permission = "report:handle"      // 处理举报
+)
+
+// 版主的权限，管理员在此基础上追加
+var moderatorPermissions = []Permission{
+	PermPostEditAny,
+	PermPostDeleteAny,
+	PermCommentEditAny,
+	PermCommentDeleteAny,
+	PermReportHandle,
+}
+
+var rolePermissions = map[string]map[Permission]bool{
+	RoleUser:      {},
+	RoleModerator: toSet(moderatorPermissions),
+	RoleAdmin:     toSet(append([]Permission{PermUserManage, PermTagManage}, moderatorPermissions...)),
+}
+
+// HasPermission 判断角色是否拥有某项权限，未知角色没有任何权限
+func HasPermission(role string, perm Permission) bool {
+	return rolePermissions[role][perm]
+}
+
+// IsValidRole 判断角色名是否合法
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func toSet(perms []Permission) map[Permission]bool {
+	set := make(map[Permission]bool, len(perms))
+	for _, perm := range perms {
+		set[perm] = true
+	}
+	return set
+}
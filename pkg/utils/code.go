@@ -2,33 +2,30 @@
 package utils
 
 import (
+	"crypto/rand"
 	"fmt"
-	"math/rand"
+	"math/big"
 )
 
 // GenerateRandomCode 生成一个指定位数的随机数字验证码
+// 验证码用于身份验证，使用 crypto/rand 生成，不能被预测
 func GenerateRandomCode(width int) string {
 	// 断言：确保位数在合理范围内
-	if width <= 0 {
+	if width <= 0 || width > 18 {
 		width = 6 // 默认为6位
 	}
 
-	// 使用纳秒级时间戳作为随机数种子，确保每次生成的随机数都不同
-	// 在 Go 1.20+ 中，rand.Seed() 已被弃用，因为默认种子已经足够随机。
-	// 但为了兼容旧版本和更明确的意图，这里依然保留。
-	// 若使用 Go 1.20+，可以省略 rand.Seed 这一行。
-	// rand.Seed(time.Now().UnixNano())
+	// format 字符串，例如 "%06d" 表示如果数字不足6位，前面用0补齐
+	format := fmt.Sprintf("%%0%dd", width)
 
-	// format 字符串，例如 "%06v" 表示如果数字不足6位，前面用0补齐
-	format := fmt.Sprintf("%%0%dv", width)
-
-	// 计算最大值，例如6位就是 10^6 - 1 = 999999
-	max := int(pow10(width)) - 1
-
-	// 生成一个 [0, max] 范围内的随机整数
-	code := rand.Intn(max)
+	// 生成一个 [0, 10^width) 范围内的随机整数，例如6位就是 [0, 999999]
+	n, err := rand.Int(rand.Reader, big.NewInt(pow10(width)))
+	if err != nil {
+		// 系统随机源不可用时无法安全地继续
+		panic(fmt.Sprintf("crypto/rand unavailable: %v", err))
+	}
 
-	return fmt.Sprintf(format, code)
+	return fmt.Sprintf(format, n.Int64())
 }
 
 // pow10 是一个辅助函数，计算 10 的 n 次方
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffOp 逐行比较的一步操作：' ' 保留，'-' 删除，'+' 新增
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// UnifiedDiff 按行比较两段文本，输出 unified diff 格式（与 diff -u 一致），每个改动块保留 context 行上下文
+// 两段文本相同时返回空字符串，改动的行数超过 MaxDiffEdits 时返回 ErrDiffTooLarge
+func UnifiedDiff(oldText, newText, oldName, newName string, context int) (string, error) {
+	ops, err := diffLines(splitLines(oldText), splitLines(newText))
+	if err != nil {
+		return "", err
+	}
+
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	// oldLines[i] / newLines[i] 是 ops[i] 之前旧、新文本已经走过的行数
+	oldLines := make([]int, len(ops)+1)
+	newLines := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLines[i+1], newLines[i+1] = oldLines[i], newLines[i]
+		if op.kind != '+' {
+			oldLines[i+1]++
+		}
+		if op.kind != '-' {
+			newLines[i+1]++
+		}
+	}
+
+	for i := 0; i < len(changes); {
+		// 相邻改动之间的保留行不超过两倍上下文时合并成一个块
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context+1 {
+			j++
+		}
+		start := max(changes[i]-context, 0)
+		end := min(changes[j]+context+1, len(ops))
+
+		oldStart, oldCount := oldLines[start], oldLines[end]-oldLines[start]
+		newStart, newCount := newLines[start], newLines[end]-newLines[start]
+		// 块内有行时起始行号从 1 开始计；没有行时指向它前面的一行
+		if oldCount > 0 {
+			oldStart++
+		}
+		if newCount > 0 {
+			newStart++
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+		}
+		i = j + 1
+	}
+	return sb.String(), nil
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// MaxDiffEdits 逐行比较时允许的最大编辑步数（删除和新增的行数之和），
+// 比较的耗时和内存随编辑步数增长，超过时返回 ErrDiffTooLarge
+const MaxDiffEdits = 2000
+
+// ErrDiffTooLarge 两段文本差异过大，不生成 diff
+var ErrDiffTooLarge = errors.New("diff too large")
+
+// diffLines 用 Myers 算法求两组行的最短编辑序列，耗时 O((n+m)*D)，D 是编辑步数
+// 先去掉相同的头尾，只比较中间不同的部分
+func diffLines(a, b []string) ([]diffOp, error) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	middle, err := myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]diffOp, 0, prefix+len(middle)+suffix)
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, middle...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops, nil
+}
+
+// myersDiff 在编辑图上逐步增加编辑步数 d，v[k] 记录对角线 k = x - y 上走得最远的 x，
+// 每一步的 v 都保存下来，到达终点后倒推出编辑序列，内存为 O(D*D)
+func myersDiff(a, b []string) ([]diffOp, error) {
+	n, m := len(a), len(b)
+	maxD := min(n+m, MaxDiffEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // 从对角线 k+1 向下走一步：新增 b 的一行
+			} else {
+				x = v[offset+k-1] + 1 // 从对角线 k-1 向右走一步：删除 a 的一行
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+				return backtrackDiff(a, b, trace), nil
+			}
+		}
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+	}
+	return nil, ErrDiffTooLarge
+}
+
+// backtrackDiff 从终点倒推编辑序列，trace[d][k+d] 是第 d 步后对角线 k 上的 x
+func backtrackDiff(a, b []string, trace [][]int) []diffOp {
+	x, y := len(a), len(b)
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, diffOp{' ', a[x-1]})
+		x--
+		y--
+	}
+	slices.Reverse(ops)
+	return ops
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SplitKeywords 把搜索词按空白切分成关键词，统一转小写并去重
+// 长的关键词排在前面，高亮时优先匹配
+func SplitKeywords(query string) []string {
+	seen := make(map[string]bool)
+	keywords := make([]string, 0)
+	for _, field := range strings.Fields(query) {
+		keyword := strings.Map(unicode.ToLower, field)
+		if seen[keyword] {
+			continue
+		}
+		seen[keyword] = true
+		keywords = append(keywords, keyword)
+	}
+	sort.SliceStable(keywords, func(i, j int) bool {
+		return len([]rune(keywords[i])) > len([]rune(keywords[j]))
+	})
+	return keywords
+}
+
+// Highlight 对 text 做 HTML 转义，并用 <em> 包裹命中的关键词（不区分大小写）
+func Highlight(text string, keywords []string) string {
+	runes := []rune(text)
+	return highlightRunes(runes, lowerRunes(runes), keywords)
+}
+
+// Snippet 截取第一个关键词附近最多 width 个字符作为摘要并高亮
+// 连续空白会被压缩成一个空格，没有命中时从开头截取
+func Snippet(text string, keywords []string, width int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	lower := lowerRunes(runes)
+
+	start := 0
+	if pos := firstMatch(lower, keywords); pos > 0 {
+		// 命中位置前留出四分之一的上下文
+		start = max(0, pos-width/4)
+	}
+	end := min(len(runes), start+width)
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("…")
+	}
+	sb.WriteString(highlightRunes(runes[start:end], lower[start:end], keywords))
+	if end < len(runes) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
+
+func lowerRunes(runes []rune) []rune {
+	// 逐个字符转小写，保证和原文的下标一一对应
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// matchAt 返回在 lower[i:] 处命中的关键词长度，没有命中返回 0
+func matchAt(lower []rune, i int, keywords []string) int {
+	for _, keyword := range keywords {
+		kw := []rune(keyword)
+		if len(kw) == 0 || i+len(kw) > len(lower) {
+			continue
+		}
+		if string(lower[i:i+len(kw)]) == keyword {
+			return len(kw)
+		}
+	}
+	return 0
+}
+
+func firstMatch(lower []rune, keywords []string) int {
+	for i := range lower {
+		if matchAt(lower, i, keywords) > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func highlightRunes(runes, lower []rune, keywords []string) string {
+	var sb strings.Builder
+	for i := 0; i < len(runes); {
+		if n := matchAt(lower, i, keywords); n > 0 {
+			sb.WriteString("<em>")
+			sb.WriteString(html.EscapeString(string(runes[i : i+n])))
+			sb.WriteString("</em>")
+			i += n
+			continue
+		}
+		sb.WriteString(html.EscapeString(string(runes[i])))
+		i++
+	}
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+package utils
+
+import "unicode"
+
+// 用户名最大长度，与注册时的校验保持一致
+const mentionMaxLen = 20
+
+// MentionSpan 内容中的一处 @提及
+// Start、End 按字符（Unicode 码点）计，Start 指向 @，End 不包含
+type MentionSpan struct {
+	Username string
+	Start    int
+	End      int
+}
+
+// ExtractMentions 从 Markdown 文本中提取 @用户名
+// 代码块和行内代码中的内容会被跳过，紧跟在字母数字后面的 @（例如邮箱地址）不算提及
+func ExtractMentions(content string) []MentionSpan {
+	runes := []rune(content)
+	spans := make([]MentionSpan, 0)
+
+	inFence := false  // 是否在 ``` 代码块中
+	inInline := false // 是否在 ` 行内代码中
+	lineStart := true
+	for i := 0; i < len(runes); i++ {
+		if lineStart {
+			lineStart = false
+			if isFenceLine(runes[i:]) {
+				inFence = !inFence
+				// 围栏所在的整行都跳过
+				for i < len(runes) && runes[i] != '\n' {
+					i++
+				}
+				lineStart = true
+				inInline = false
+				continue
+			}
+		}
+
+		r := runes[i]
+		if r == '\n' {
+			lineStart = true
+			inInline = false
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if r == '`' {
+			inInline = !inInline
+			continue
+		}
+		if inInline || r != '@' {
+			continue
+		}
+		if i > 0 && isMentionRune(runes[i-1]) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isMentionRune(runes[end]) {
+			end++
+		}
+		if length := end - i - 1; length > 0 && length <= mentionMaxLen {
+			spans = append(spans, MentionSpan{
+				Username: string(runes[i+1 : end]),
+				Start:    i,
+				End:      end,
+			})
+		}
+		i = end - 1
+	}
+	return spans
+}
+
+// isFenceLine 判断一行是否是代码块的围栏（允许行首缩进）
+func isFenceLine(line []rune) bool {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+	if i+3 > len(line) {
+		return false
+	}
+	fence := string(line[i : i+3])
+	return fence == "```" || fence == "~~~"
+}
+
+func isMentionRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}